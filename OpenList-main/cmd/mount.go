@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountUser      string
+	mountReadOnly  bool
+	mountCacheSize int
+	mountOptions   []string
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount <remote-path> <mountpoint>",
+	Short: "Mount the storages to a local directory with FUSE",
+	Long: `Mount the storages to a local directory with FUSE
+the remote path is relative to the base path of the user,
+the binary must be built with the fuse tag`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user, err := op.GetAdmin()
+		if mountUser != "" {
+			user, err = op.GetUserByName(mountUser)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %+v", err)
+		}
+		if user.Disabled {
+			return fmt.Errorf("user [%s] is disabled", user.Username)
+		}
+		remotePath, err := user.JoinPath(args[0])
+		if err != nil {
+			return err
+		}
+		bootstrap.LoadStorages()
+		<-conf.StoragesLoadSignal()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx = context.WithValue(ctx, conf.UserKey, user)
+		utils.Log.Infof("mount [%s] at [%s] as user [%s]", remotePath, args[1], user.Username)
+		return mount(ctx, remotePath, args[1])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().StringVar(&mountUser, "user", "", "mount on behalf of this user, admin by default")
+	MountCmd.Flags().BoolVar(&mountReadOnly, "read-only", false, "mount read only")
+	MountCmd.Flags().IntVar(&mountCacheSize, "cache-size", 64, "size of the read cache in MB")
+	MountCmd.Flags().StringArrayVarP(&mountOptions, "option", "o", nil, "FUSE mount options, such as allow_other")
+}
@@ -0,0 +1,17 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+)
+
+func mount(ctx context.Context, remotePath, mountpoint string) error {
+	return fuse.Mount(ctx, remotePath, mountpoint, fuse.MountOptions{
+		ReadOnly:    mountReadOnly,
+		CacheBlocks: mountCacheSize,
+		Options:     mountOptions,
+	})
+}
@@ -0,0 +1,12 @@
+//go:build !fuse
+
+package cmd
+
+import (
+	"context"
+	"errors"
+)
+
+func mount(ctx context.Context, remotePath, mountpoint string) error {
+	return errors.New("this binary is built without FUSE support, rebuild it with -tags fuse")
+}
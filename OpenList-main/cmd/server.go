@@ -46,6 +46,10 @@ the address is defined in config file`,
 		bootstrap.InitOfflineDownloadTools()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
+		bootstrap.InitScheduledJobs()
+		bootstrap.InitAudit()
+		bootstrap.InitTrash()
+		bootstrap.InitFileVersions()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueSize     = 1024
+	batchSize     = 100
+	flushInterval = time.Second
+	pruneInterval = 24 * time.Hour
+)
+
+var (
+	queue   = make(chan *model.AuditEvent, queueSize)
+	started atomic.Bool
+	// the settings are set by SetOptions as the setting package depends on op, which records events
+	enabled       atomic.Bool
+	retentionDays atomic.Int64
+)
+
+// SetOptions applies the audit_log and audit_log_retention_days settings
+func SetOptions(enable bool, days int) {
+	enabled.Store(enable)
+	retentionDays.Store(int64(days))
+}
+
+// Init starts writing the recorded events to the database and pruning the expired ones,
+// events are dropped until it is called
+func Init() {
+	if !started.CompareAndSwap(false, true) {
+		return
+	}
+	go write()
+	go func() {
+		for {
+			if _, err := Prune(int(retentionDays.Load())); err != nil {
+				log.Errorf("failed prune audit events: %+v", err)
+			}
+			time.Sleep(pruneInterval)
+		}
+	}()
+}
+
+// RetentionDays returns how long the events are kept, 0 means forever
+func RetentionDays() int {
+	return int(retentionDays.Load())
+}
+
+// write saves the events in batches, as there may be many of them when a directory is uploaded
+func write() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*model.AuditEvent, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := db.CreateAuditEvents(batch); err != nil {
+			log.Errorf("failed save %d audit events: %+v", len(batch), err)
+		}
+		batch = make([]*model.AuditEvent, 0, batchSize)
+	}
+	for {
+		select {
+		case e := <-queue:
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Record records an event of the user of ctx, dstPath is only set for rename, move and copy.
+// The client ip and the protocol are taken from ctx too, the protocol is empty for internal operations.
+func Record(ctx context.Context, event, path, dstPath string, err error) {
+	var username string
+	var userId uint
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		username, userId = user.Username, user.ID
+	}
+	record(ctx, event, userId, username, path, dstPath, err)
+}
+
+// RecordLogin records a login attempt of username, ctx doesn't have the user yet
+func RecordLogin(ctx context.Context, user *model.User, username string, err error) {
+	event := model.AuditLogin
+	if err != nil {
+		event = model.AuditLoginFailed
+	}
+	var userId uint
+	if user != nil {
+		userId, username = user.ID, user.Username
+	}
+	record(ctx, event, userId, username, "", "", err)
+}
+
+func record(ctx context.Context, event string, userId uint, username, path, dstPath string, err error) {
+	if !started.Load() || !enabled.Load() {
+		return
+	}
+	e := &model.AuditEvent{
+		Time:     time.Now(),
+		Event:    event,
+		UserId:   userId,
+		Username: username,
+		Path:     path,
+		DstPath:  dstPath,
+		Success:  err == nil,
+	}
+	e.IP, _ = ctx.Value(conf.ClientIPKey).(string)
+	e.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("audit queue is full, drop event %s of %s on %s", event, username, path)
+	}
+}
+
+// Prune deletes the events older than days, nothing is deleted if days <= 0
+func Prune(days int) (int64, error) {
+	if days <= 0 {
+		return 0, nil
+	}
+	return db.DeleteAuditEventsBefore(time.Now().AddDate(0, 0, -days))
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:audit_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+// waitEvents waits for the events matching q to be written
+func waitEvents(t *testing.T, q model.AuditQuery, want int64) []model.AuditEvent {
+	t.Helper()
+	q.PageReq = model.PageReq{Page: 1, PerPage: 1000}
+	deadline := time.Now().Add(3 * flushInterval)
+	for {
+		events, count, err := db.GetAuditEvents(&q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count >= want || time.Now().After(deadline) {
+			if count != want {
+				t.Fatalf("got %d events matching %+v, want %d", count, q, want)
+			}
+			return events
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	user := &model.User{ID: 7, Username: "auditor"}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	ctx = context.WithValue(ctx, conf.ClientIPKey, "10.0.0.7")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolWebDAV)
+
+	SetOptions(true, 0)
+	Record(ctx, model.AuditUpload, "/before/init.txt", "", nil)
+	Init()
+	SetOptions(false, 0)
+	Record(ctx, model.AuditUpload, "/disabled.txt", "", nil)
+	SetOptions(true, 0)
+	defer SetOptions(false, 0)
+
+	// more than a batch is written
+	for range batchSize + 10 {
+		Record(ctx, model.AuditUpload, "/audit/a.txt", "", nil)
+	}
+	Record(ctx, model.AuditMove, "/audit/b.txt", "/audit/dir/b.txt", nil)
+	Record(ctx, model.AuditRemove, "/other/c.txt", "", errors.New("denied"))
+	RecordLogin(context.WithValue(context.Background(), conf.ProtocolKey, model.ProtocolFTP), nil, "intruder", errors.New("wrong password"))
+
+	waitEvents(t, model.AuditQuery{Username: "auditor"}, batchSize+12)
+	waitEvents(t, model.AuditQuery{Path: "/before"}, 0)
+	waitEvents(t, model.AuditQuery{Path: "/disabled.txt"}, 0)
+	waitEvents(t, model.AuditQuery{Path: "/audit"}, batchSize+11)
+	waitEvents(t, model.AuditQuery{Event: model.AuditUpload, IP: "10.0.0.7"}, batchSize+10)
+	moves := waitEvents(t, model.AuditQuery{Event: model.AuditMove, Protocol: model.ProtocolWebDAV}, 1)
+	if moves[0].DstPath != "/audit/dir/b.txt" || moves[0].UserId != user.ID {
+		t.Errorf("unexpected move event: %+v", moves[0])
+	}
+	failed := false
+	removes := waitEvents(t, model.AuditQuery{Username: "auditor", Success: &failed}, 1)
+	if removes[0].Event != model.AuditRemove || removes[0].Error != "denied" {
+		t.Errorf("unexpected failed event: %+v", removes[0])
+	}
+	logins := waitEvents(t, model.AuditQuery{Event: model.AuditLoginFailed, Protocol: model.ProtocolFTP}, 1)
+	if logins[0].Username != "intruder" || logins[0].Success {
+		t.Errorf("unexpected login event: %+v", logins[0])
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	events := []*model.AuditEvent{
+		{Time: now.AddDate(0, 0, -10), Event: model.AuditUpload, Username: "pruned", Success: true},
+		{Time: now.AddDate(0, 0, -3), Event: model.AuditUpload, Username: "pruned", Success: true},
+		{Time: now, Event: model.AuditUpload, Username: "pruned", Success: true},
+	}
+	if err := db.CreateAuditEvents(events); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Prune(0); err != nil || n != 0 {
+		t.Errorf("Prune(0) = %d, %v, want nothing deleted", n, err)
+	}
+	if n, err := Prune(7); err != nil || n != 1 {
+		t.Errorf("Prune(7) = %d, %v, want 1", n, err)
+	}
+	waitEvents(t, model.AuditQuery{Username: "pruned"}, 2)
+}
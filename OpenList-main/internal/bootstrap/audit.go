@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+)
+
+func InitAudit() {
+	apply := func() {
+		audit.SetOptions(setting.GetBool(conf.AuditLog), setting.GetInt(conf.AuditLogRetentionDays, 90))
+	}
+	apply()
+	op.RegisterSettingChangingCallback(apply)
+	audit.Init()
+}
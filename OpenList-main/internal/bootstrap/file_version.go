@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitFileVersions deletes the versions older than the max version age of their storages
+func InitFileVersions() {
+	go func() {
+		for {
+			pruned, err := op.PruneExpiredVersions(context.Background())
+			if err != nil {
+				log.Errorf("failed prune expired file versions: %+v", err)
+			} else if pruned > 0 {
+				log.Infof("pruned %d expired file versions", pruned)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_32_0"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_41_0"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_all"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v4_2_0"
 )
 
 type VersionPatches struct {
@@ -39,4 +40,10 @@ var UpgradePatches = []VersionPatches{
 			v3_all.RenameAlistV3Driver,
 		},
 	},
+	{
+		Version: "v4.2.0",
+		Patches: []func(){
+			v4_2_0.MergeMediaFavorites,
+		},
+	},
 }
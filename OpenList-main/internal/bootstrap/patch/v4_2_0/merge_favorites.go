@@ -0,0 +1,121 @@
+package v4_2_0
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// legacyFavoriteFolder and legacyFavorite are the rows of the former
+// video/audio/image favorite tables, which all shared the same columns
+type legacyFavoriteFolder struct {
+	ID          uint
+	UserId      uint
+	Name        string
+	Description string
+	Order       int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type legacyFavorite struct {
+	ID           uint
+	UserId       uint
+	FolderId     uint
+	StorageId    uint
+	OriginalPath string
+	FileName     string
+	Note         string
+	Fingerprint  string
+	CreatedAt    time.Time
+}
+
+// MergeMediaFavorites moves the rows of the video, audio and image favorite
+// tables into the generic favorite tables and drops the old tables afterwards,
+// so it is a no-op once it has succeeded.
+func MergeMediaFavorites() {
+	prefix := conf.Conf.Database.TablePrefix
+	legacyTables := []struct {
+		mediaType     string
+		folderTable   string
+		favoriteTable string
+	}{
+		{model.FavoriteMediaVideo, prefix + "video_favorite_folders", prefix + "video_favorites"},
+		{model.FavoriteMediaAudio, prefix + "audio_favorite_folders", prefix + "audio_favorites"},
+		// image favorites used fixed table names without prefix
+		{model.FavoriteMediaImage, "image_favorite_folders", "image_favorites"},
+	}
+	for _, t := range legacyTables {
+		migrator := db.GetDb().Migrator()
+		if !migrator.HasTable(t.folderTable) {
+			continue
+		}
+		var folderCount, favoriteCount int
+		err := db.GetDb().Transaction(func(tx *gorm.DB) error {
+			var folders []legacyFavoriteFolder
+			if err := tx.Table(t.folderTable).Find(&folders).Error; err != nil {
+				return err
+			}
+			folderIds := make(map[uint]uint, len(folders))
+			for _, f := range folders {
+				folder := model.FavoriteFolder{
+					UserId:      f.UserId,
+					MediaType:   t.mediaType,
+					Name:        f.Name,
+					Description: f.Description,
+					Order:       f.Order,
+					CreatedAt:   f.CreatedAt,
+					UpdatedAt:   f.UpdatedAt,
+				}
+				if err := tx.Create(&folder).Error; err != nil {
+					return err
+				}
+				folderIds[f.ID] = folder.ID
+			}
+			folderCount = len(folders)
+			if tx.Migrator().HasTable(t.favoriteTable) {
+				var favorites []legacyFavorite
+				if err := tx.Table(t.favoriteTable).Find(&favorites).Error; err != nil {
+					return err
+				}
+				for _, f := range favorites {
+					folderId, ok := folderIds[f.FolderId]
+					if !ok {
+						utils.Log.Warnf("[MergeMediaFavorites] skip %s favorite [%d]%s of missing folder %d",
+							t.mediaType, f.ID, f.OriginalPath, f.FolderId)
+						continue
+					}
+					favorite := model.Favorite{
+						UserId:       f.UserId,
+						FolderId:     folderId,
+						MediaType:    t.mediaType,
+						StorageId:    f.StorageId,
+						OriginalPath: f.OriginalPath,
+						FileName:     f.FileName,
+						Note:         f.Note,
+						Fingerprint:  f.Fingerprint,
+						CreatedAt:    f.CreatedAt,
+					}
+					if err := tx.Create(&favorite).Error; err != nil {
+						return err
+					}
+					favoriteCount++
+				}
+				if err := tx.Migrator().DropTable(t.favoriteTable); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(t.folderTable)
+		})
+		if err != nil {
+			utils.Log.Errorf("[MergeMediaFavorites] failed to merge %s favorites: %s", t.mediaType, err.Error())
+			continue
+		}
+		utils.Log.Infof("[MergeMediaFavorites] merged %d %s favorite folders with %d favorites",
+			folderCount, t.mediaType, favoriteCount)
+	}
+}
@@ -0,0 +1,66 @@
+package v4_2_0
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMergeMediaFavorites(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file:merge_favorites_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	prefix := conf.Conf.Database.TablePrefix
+	for _, table := range []string{prefix + "video_favorite_folders", "image_favorite_folders"} {
+		if err := dB.Table(table).AutoMigrate(&legacyFavoriteFolder{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, table := range []string{prefix + "video_favorites", "image_favorites"} {
+		if err := dB.Table(table).AutoMigrate(&legacyFavorite{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dB.Table(prefix + "video_favorite_folders").Create(&legacyFavoriteFolder{ID: 7, UserId: 1, Name: "movies"})
+	dB.Table(prefix + "video_favorites").Create(&[]legacyFavorite{
+		{UserId: 1, FolderId: 7, OriginalPath: "/a.mp4", FileName: "a.mp4"},
+		// its folder is gone, it is skipped
+		{UserId: 1, FolderId: 8, OriginalPath: "/b.mp4", FileName: "b.mp4"},
+	})
+	dB.Table("image_favorite_folders").Create(&legacyFavoriteFolder{ID: 7, UserId: 2, Name: "photos"})
+	dB.Table("image_favorites").Create(&legacyFavorite{UserId: 2, FolderId: 7, OriginalPath: "/c.jpg", FileName: "c.jpg"})
+
+	MergeMediaFavorites()
+
+	var folders []model.FavoriteFolder
+	dB.Order("id").Find(&folders)
+	if len(folders) != 2 || folders[0].MediaType != model.FavoriteMediaVideo || folders[1].MediaType != model.FavoriteMediaImage {
+		t.Fatalf("merged folders = %+v", folders)
+	}
+	var favorites []model.Favorite
+	dB.Order("id").Find(&favorites)
+	if len(favorites) != 2 ||
+		favorites[0].OriginalPath != "/a.mp4" || favorites[0].FolderId != folders[0].ID ||
+		favorites[1].OriginalPath != "/c.jpg" || favorites[1].FolderId != folders[1].ID {
+		t.Fatalf("merged favorites = %+v", favorites)
+	}
+	for _, table := range []string{prefix + "video_favorite_folders", prefix + "video_favorites", "image_favorite_folders", "image_favorites"} {
+		if dB.Migrator().HasTable(table) {
+			t.Errorf("legacy table %s is not dropped", table)
+		}
+	}
+	// running it again changes nothing
+	MergeMediaFavorites()
+	var count int64
+	dB.Model(&model.Favorite{}).Count(&count)
+	if count != 2 {
+		t.Errorf("favorites after merging again = %d, want 2", count)
+	}
+}
@@ -49,4 +49,16 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	fs.SyncTaskManager = tache.NewManager[*fs.SyncTask](tache.WithWorks(setting.GetInt(conf.TaskSyncThreadsNum, conf.Conf.Tasks.Sync.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant), db.UpdateTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.SyncTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskSyncThreadsNum, conf.Conf.Tasks.Sync.Workers)))
+	})
+	fs.ArchiveCompressTaskManager = tache.NewManager[*fs.ArchiveCompressTask](tache.WithWorks(setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant), db.UpdateTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Compress.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.ArchiveCompressTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers)))
+	})
+	fs.DedupeTaskManager = tache.NewManager[*fs.DedupeTask](tache.WithWorks(setting.GetInt(conf.TaskDedupeThreadsNum, conf.Conf.Tasks.Dedupe.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("dedupe", conf.Conf.Tasks.Dedupe.TaskPersistant), db.UpdateTaskDataFunc("dedupe", conf.Conf.Tasks.Dedupe.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Dedupe.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.DedupeTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDedupeThreadsNum, conf.Conf.Tasks.Dedupe.Workers)))
+	})
 }
@@ -0,0 +1,26 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitTrash purges the objects kept in the recycle bins longer than the retention days
+func InitTrash() {
+	go func() {
+		for {
+			purged, err := op.PurgeExpiredTrash(context.Background(), setting.GetInt(conf.RecycleBinRetentionDays, 30))
+			if err != nil {
+				log.Errorf("failed purge expired trash: %+v", err)
+			} else if purged > 0 {
+				log.Infof("purged %d expired objects from recycle bins", purged)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
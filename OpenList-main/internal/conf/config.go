@@ -73,6 +73,9 @@ type TasksConfig struct {
 	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
+	Compress           TaskConfig `json:"compress" envPrefix:"COMPRESS_"`
+	Dedupe             TaskConfig `json:"dedupe" envPrefix:"DEDUPE_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -216,6 +219,21 @@ func DefaultConfig(dataDir string) *Config {
 				Workers:  5,
 				MaxRetry: 2,
 			},
+			Sync: TaskConfig{
+				Workers:  2,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
+			Compress: TaskConfig{
+				Workers:  2,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
+			Dedupe: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
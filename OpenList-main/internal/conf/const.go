@@ -57,12 +57,16 @@ const (
 	ShareForceProxy         = "share_force_proxy"
 	ShareSummaryContent     = "share_summary_content"
 	IgnoreSystemFiles       = "ignore_system_files"
+	AuditLog                = "audit_log"
+	AuditLogRetentionDays   = "audit_log_retention_days"
+	RecycleBinRetentionDays = "recycle_bin_retention_days"
 
 	// index
 	SearchIndex     = "search_index"
 	AutoUpdateIndex = "auto_update_index"
 	IgnorePaths     = "ignore_paths"
 	MaxIndexDepth   = "max_index_depth"
+	IndexAudioTags  = "index_audio_tags"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -100,6 +104,7 @@ const (
 	SSOLoginEnabled      = "sso_login_enabled"
 	SSOLoginPlatform     = "sso_login_platform"
 	SSOOIDCUsernameKey   = "sso_oidc_username_key"
+	SSOOIDCGroupsKey     = "sso_oidc_groups_key"
 	SSOOrganizationName  = "sso_organization_name"
 	SSOApplicationName   = "sso_application_name"
 	SSOEndpointName      = "sso_endpoint_name"
@@ -120,6 +125,7 @@ const (
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
 	LdapLoginTips         = "ldap_login_tips"
+	LdapGroupAttribute    = "ldap_group_attribute"
 
 	// s3
 	S3Buckets         = "s3_buckets"
@@ -151,6 +157,9 @@ const (
 	TaskMoveThreadsNum                    = "move_task_threads_num"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
+	TaskSyncThreadsNum                    = "sync_task_threads_num"
+	TaskCompressThreadsNum                = "compress_task_threads_num"
+	TaskDedupeThreadsNum                  = "dedupe_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
@@ -184,4 +193,6 @@ const (
 	UserAgentKey
 	PathKey
 	SharingIDKey
+	ProtocolKey
+	APITokenKey
 )
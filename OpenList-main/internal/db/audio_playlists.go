@@ -0,0 +1,180 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// AudioPlaylist operations
+
+func ListAudioPlaylistsByUser(userId uint) ([]model.AudioPlaylist, error) {
+	var playlists []model.AudioPlaylist
+	if err := db.Where("user_id = ?", userId).Order("updated_at DESC").Find(&playlists).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list audio playlists")
+	}
+	return playlists, nil
+}
+
+func GetAudioPlaylistById(id uint, userId uint) (*model.AudioPlaylist, error) {
+	var playlist model.AudioPlaylist
+	if err := db.Where("id = ? AND user_id = ?", id, userId).First(&playlist).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get audio playlist")
+	}
+	return &playlist, nil
+}
+
+// CreateAudioPlaylist creates the playlist together with its initial items
+func CreateAudioPlaylist(playlist *model.AudioPlaylist, items []model.AudioPlaylistItem) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(playlist).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for i := range items {
+			items[i].UserId = playlist.UserId
+			items[i].PlaylistId = playlist.ID
+			items[i].Position = i
+		}
+		return tx.Create(&items).Error
+	}))
+}
+
+func UpdateAudioPlaylist(playlist *model.AudioPlaylist) error {
+	return errors.WithStack(db.Save(playlist).Error)
+}
+
+func DeleteAudioPlaylistById(id uint, userId uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Delete all items in this playlist first
+		if err := tx.Where("playlist_id = ? AND user_id = ?", id, userId).Delete(&model.AudioPlaylistItem{}).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete items in playlist")
+		}
+		result := tx.Where("id = ? AND user_id = ?", id, userId).Delete(&model.AudioPlaylist{})
+		if result.Error != nil {
+			return errors.Wrapf(result.Error, "failed to delete audio playlist")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("audio playlist not found or not owned by user")
+		}
+		return nil
+	})
+}
+
+// AudioPlaylistItem operations
+
+func ListAudioPlaylistItems(playlistId uint, userId uint) ([]model.AudioPlaylistItem, error) {
+	var items []model.AudioPlaylistItem
+	if err := db.Where("playlist_id = ? AND user_id = ?", playlistId, userId).Order("position ASC, id ASC").Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list audio playlist items")
+	}
+	return items, nil
+}
+
+// AppendAudioPlaylistItems appends items to the end of the playlist
+func AppendAudioPlaylistItems(playlist *model.AudioPlaylist, items []model.AudioPlaylistItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.AudioPlaylistItem{}).Where("playlist_id = ?", playlist.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].UserId = playlist.UserId
+			items[i].PlaylistId = playlist.ID
+			items[i].Position = int(count) + i
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		// bump updated_at so that other devices notice the change
+		return tx.Save(playlist).Error
+	}))
+}
+
+// RemoveAudioPlaylistItems removes the given items, compacts the positions
+// and keeps the current index pointing at the same item when possible
+func RemoveAudioPlaylistItems(playlist *model.AudioPlaylist, itemIds []uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var items []model.AudioPlaylistItem
+		if err := tx.Where("playlist_id = ?", playlist.ID).Order("position ASC, id ASC").Find(&items).Error; err != nil {
+			return err
+		}
+		removed := make(map[uint]struct{}, len(itemIds))
+		for _, id := range itemIds {
+			removed[id] = struct{}{}
+		}
+		var currentId uint
+		if playlist.CurrentIndex >= 0 && playlist.CurrentIndex < len(items) {
+			currentId = items[playlist.CurrentIndex].ID
+		}
+		if err := tx.Where("playlist_id = ? AND id IN ?", playlist.ID, itemIds).Delete(&model.AudioPlaylistItem{}).Error; err != nil {
+			return err
+		}
+		kept := items[:0]
+		for _, item := range items {
+			if _, ok := removed[item.ID]; !ok {
+				kept = append(kept, item)
+			}
+		}
+		newIndex := -1
+		for i, item := range kept {
+			if item.ID == currentId {
+				newIndex = i
+			}
+			if item.Position != i {
+				if err := tx.Model(&model.AudioPlaylistItem{}).Where("id = ?", item.ID).Update("position", i).Error; err != nil {
+					return err
+				}
+			}
+		}
+		// the current item was removed, stay at the same slot if it still exists
+		if newIndex == -1 && currentId != 0 && len(kept) > 0 {
+			newIndex = min(playlist.CurrentIndex, len(kept)-1)
+		}
+		playlist.CurrentIndex = newIndex
+		return tx.Save(playlist).Error
+	}))
+}
+
+// ReorderAudioPlaylistItems sets the order of the playlist to the given item ids,
+// which must contain every item of the playlist exactly once
+func ReorderAudioPlaylistItems(playlist *model.AudioPlaylist, itemIds []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var items []model.AudioPlaylistItem
+		if err := tx.Where("playlist_id = ?", playlist.ID).Order("position ASC, id ASC").Find(&items).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if len(items) != len(itemIds) {
+			return errors.New("item ids do not match the playlist items")
+		}
+		positions := make(map[uint]int, len(items))
+		for i, item := range items {
+			positions[item.ID] = i
+		}
+		var currentId uint
+		if playlist.CurrentIndex >= 0 && playlist.CurrentIndex < len(items) {
+			currentId = items[playlist.CurrentIndex].ID
+		}
+		for i, id := range itemIds {
+			old, ok := positions[id]
+			if !ok {
+				return errors.Errorf("item %d does not belong to the playlist", id)
+			}
+			delete(positions, id)
+			if items[old].Position != i {
+				if err := tx.Model(&model.AudioPlaylistItem{}).Where("id = ?", id).Update("position", i).Error; err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			if id == currentId {
+				playlist.CurrentIndex = i
+			}
+		}
+		return errors.WithStack(tx.Save(playlist).Error)
+	})
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func playlistFiles(t *testing.T, playlist *model.AudioPlaylist) []string {
+	t.Helper()
+	items, err := ListAudioPlaylistItems(playlist.ID, playlist.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make([]string, len(items))
+	for i, item := range items {
+		if item.Position != i {
+			t.Errorf("%s is at position %d, want %d", item.FileName, item.Position, i)
+		}
+		files[i] = item.FileName
+	}
+	return files
+}
+
+func newPlaylistItems(names ...string) []model.AudioPlaylistItem {
+	items := make([]model.AudioPlaylistItem, len(names))
+	for i, name := range names {
+		items[i] = model.AudioPlaylistItem{OriginalPath: "/music/" + name, FileName: name}
+	}
+	return items
+}
+
+func TestAudioPlaylistItems(t *testing.T) {
+	playlist := &model.AudioPlaylist{UserId: 1, Name: "mix", CurrentIndex: 1}
+	if err := CreateAudioPlaylist(playlist, newPlaylistItems("a.mp3", "b.mp3", "c.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendAudioPlaylistItems(playlist, newPlaylistItems("d.mp3", "e.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if files := playlistFiles(t, playlist); !slices.Equal(files, []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3", "e.mp3"}) {
+		t.Fatalf("after appending: %v", files)
+	}
+
+	items, err := ListAudioPlaylistItems(playlist.ID, playlist.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b.mp3 is the current item, c.mp3 is removed from the middle
+	if err := RemoveAudioPlaylistItems(playlist, []uint{items[2].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if files := playlistFiles(t, playlist); !slices.Equal(files, []string{"a.mp3", "b.mp3", "d.mp3", "e.mp3"}) {
+		t.Errorf("after removing from the middle: %v", files)
+	}
+	if playlist.CurrentIndex != 1 {
+		t.Errorf("the current index moved to %d", playlist.CurrentIndex)
+	}
+
+	ids := []uint{items[4].ID, items[1].ID, items[0].ID, items[3].ID}
+	if err := ReorderAudioPlaylistItems(playlist, ids); err != nil {
+		t.Fatal(err)
+	}
+	if files := playlistFiles(t, playlist); !slices.Equal(files, []string{"e.mp3", "b.mp3", "a.mp3", "d.mp3"}) {
+		t.Errorf("after reordering: %v", files)
+	}
+	for _, ids := range [][]uint{
+		{items[4].ID, items[1].ID, items[0].ID},
+		{items[4].ID, items[1].ID, items[0].ID, items[0].ID},
+		{items[4].ID, items[1].ID, items[0].ID, items[2].ID},
+	} {
+		if err := ReorderAudioPlaylistItems(playlist, ids); err == nil {
+			t.Errorf("reordering to %v which is not a permutation of the items succeeded", ids)
+		}
+	}
+	if files := playlistFiles(t, playlist); !slices.Equal(files, []string{"e.mp3", "b.mp3", "a.mp3", "d.mp3"}) {
+		t.Errorf("after the rejected reorders: %v", files)
+	}
+}
+
+func TestAudioPlaylistOfAnotherUser(t *testing.T) {
+	playlist := &model.AudioPlaylist{UserId: 2, Name: "own", CurrentIndex: -1}
+	if err := CreateAudioPlaylist(playlist, newPlaylistItems("a.mp3", "b.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	other := &model.AudioPlaylist{UserId: 3, Name: "other", CurrentIndex: -1}
+	if err := CreateAudioPlaylist(other, newPlaylistItems("x.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetAudioPlaylistById(playlist.ID, other.UserId); err == nil {
+		t.Error("got the playlist of another user")
+	}
+	if items, err := ListAudioPlaylistItems(playlist.ID, other.UserId); err != nil || len(items) != 0 {
+		t.Errorf("listed the items of the playlist of another user: %v, %v", items, err)
+	}
+	if err := DeleteAudioPlaylistById(playlist.ID, other.UserId); err == nil {
+		t.Error("deleted the playlist of another user")
+	}
+
+	otherItems, err := ListAudioPlaylistItems(other.ID, other.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := ListAudioPlaylistItems(playlist.ID, playlist.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReorderAudioPlaylistItems(playlist, []uint{otherItems[0].ID, items[0].ID}); err == nil {
+		t.Error("reordered with an item of the playlist of another user")
+	}
+	if err := RemoveAudioPlaylistItems(playlist, []uint{otherItems[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if files := playlistFiles(t, other); !slices.Equal(files, []string{"x.mp3"}) {
+		t.Errorf("removed an item of the playlist of another user: %v", files)
+	}
+	if files := playlistFiles(t, playlist); !slices.Equal(files, []string{"a.mp3", "b.mp3"}) {
+		t.Errorf("the playlist changed: %v", files)
+	}
+}
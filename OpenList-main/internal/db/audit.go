@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAuditEvents(events []*model.AuditEvent) error {
+	return errors.WithStack(db.CreateInBatches(events, 100).Error)
+}
+
+// GetAuditEvents returns the events matching q, latest first
+func GetAuditEvents(q *model.AuditQuery) (events []model.AuditEvent, count int64, err error) {
+	eventDB := db.Model(&model.AuditEvent{})
+	if q.Event != "" {
+		eventDB = eventDB.Where(fmt.Sprintf("%s = ?", columnName("event")), q.Event)
+	}
+	if q.Username != "" {
+		eventDB = eventDB.Where(fmt.Sprintf("%s = ?", columnName("username")), q.Username)
+	}
+	if q.IP != "" {
+		eventDB = eventDB.Where(fmt.Sprintf("%s = ?", columnName("ip")), q.IP)
+	}
+	if q.Protocol != "" {
+		eventDB = eventDB.Where(fmt.Sprintf("%s = ?", columnName("protocol")), q.Protocol)
+	}
+	if q.Path != "" {
+		eventDB = whereSubPath(eventDB, columnName("path"), q.Path)
+	}
+	if q.Success != nil {
+		eventDB = eventDB.Where(fmt.Sprintf("%s = ?", columnName("success")), *q.Success)
+	}
+	if q.Since > 0 {
+		eventDB = eventDB.Where(fmt.Sprintf("%s >= ?", columnName("time")), time.Unix(q.Since, 0))
+	}
+	if q.Until > 0 {
+		eventDB = eventDB.Where(fmt.Sprintf("%s < ?", columnName("time")), time.Unix(q.Until, 0))
+	}
+	if err := eventDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit events count")
+	}
+	if err := eventDB.Order(columnName("id") + " DESC").Offset((q.Page - 1) * q.PerPage).Limit(q.PerPage).Find(&events).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit events")
+	}
+	return events, count, nil
+}
+
+// DeleteAuditEventsBefore deletes the events older than t and returns how many were deleted
+func DeleteAuditEventsBefore(t time.Time) (int64, error) {
+	res := db.Where(fmt.Sprintf("%s < ?", columnName("time")), t).Delete(&model.AuditEvent{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
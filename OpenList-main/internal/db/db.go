@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.MediaMark), new(model.VideoFavoriteFolder), new(model.VideoFavorite), new(model.AudioFavoriteFolder), new(model.AudioFavorite), new(model.ImageFavoriteFolder), new(model.ImageFavorite))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.MediaMark), new(model.PlaybackProgress), new(model.FavoriteFolder), new(model.Favorite), new(model.AudioPlaylist), new(model.AudioPlaylistItem), new(model.ScheduledJob), new(model.ScheduledJobRun), new(model.UserTraffic), new(model.AuditEvent), new(model.APIToken), new(model.Group), new(model.TrashItem), new(model.FileVersion))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
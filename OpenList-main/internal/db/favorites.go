@@ -0,0 +1,161 @@
+package db
+
+import (
+	"fmt"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// FavoriteFolder operations
+
+// ListFavoriteFoldersByUser lists the folders of the user, mediaType "" means all media types
+func ListFavoriteFoldersByUser(userId uint, mediaType string) ([]model.FavoriteFolder, error) {
+	var folders []model.FavoriteFolder
+	tx := db.Where("user_id = ?", userId)
+	if mediaType != "" {
+		tx = tx.Where("media_type = ?", mediaType)
+	}
+	if err := tx.Order(fmt.Sprintf("%s ASC, created_at DESC", columnName("order"))).Find(&folders).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list favorite folders")
+	}
+	return folders, nil
+}
+
+func GetFavoriteFolderById(id uint, userId uint) (*model.FavoriteFolder, error) {
+	var folder model.FavoriteFolder
+	if err := db.Where("id = ? AND user_id = ?", id, userId).First(&folder).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get favorite folder")
+	}
+	return &folder, nil
+}
+
+func CreateFavoriteFolder(folder *model.FavoriteFolder) error {
+	return errors.WithStack(db.Create(folder).Error)
+}
+
+func UpdateFavoriteFolder(folder *model.FavoriteFolder) error {
+	return errors.WithStack(db.Save(folder).Error)
+}
+
+func DeleteFavoriteFolderById(id uint, userId uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Delete all favorites in this folder first
+		if err := tx.Where("folder_id = ? AND user_id = ?", id, userId).Delete(&model.Favorite{}).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete favorites in folder")
+		}
+		result := tx.Where("id = ? AND user_id = ?", id, userId).Delete(&model.FavoriteFolder{})
+		if result.Error != nil {
+			return errors.Wrapf(result.Error, "failed to delete favorite folder")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("favorite folder not found or not owned by user")
+		}
+		return nil
+	})
+}
+
+// Favorite operations
+
+func ListFavoritesByFolder(folderId uint, userId uint) ([]model.Favorite, error) {
+	var favorites []model.Favorite
+	if err := db.Where("folder_id = ? AND user_id = ?", folderId, userId).Order("created_at DESC").Find(&favorites).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list favorites")
+	}
+	return favorites, nil
+}
+
+// ListAllFavoritesByUser lists all favorites of the user, mediaType "" means all media types
+func ListAllFavoritesByUser(userId uint, mediaType string) ([]model.Favorite, error) {
+	var favorites []model.Favorite
+	tx := db.Where("user_id = ?", userId)
+	if mediaType != "" {
+		tx = tx.Where("media_type = ?", mediaType)
+	}
+	if err := tx.Order("created_at DESC").Find(&favorites).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list all favorites")
+	}
+	return favorites, nil
+}
+
+func GetFavoriteById(id uint, userId uint) (*model.Favorite, error) {
+	var favorite model.Favorite
+	if err := db.Where("id = ? AND user_id = ?", id, userId).First(&favorite).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get favorite")
+	}
+	return &favorite, nil
+}
+
+func CheckFavoriteExists(userId uint, folderId uint, originalPath string) (bool, error) {
+	var count int64
+	if err := db.Model(&model.Favorite{}).Where("user_id = ? AND folder_id = ? AND original_path = ?", userId, folderId, originalPath).Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed to check favorite existence")
+	}
+	return count > 0, nil
+}
+
+func CreateFavorite(favorite *model.Favorite) error {
+	return errors.WithStack(db.Create(favorite).Error)
+}
+
+func UpdateFavorite(favorite *model.Favorite) error {
+	return errors.WithStack(db.Save(favorite).Error)
+}
+
+func DeleteFavoriteById(id uint, userId uint) error {
+	result := db.Where("id = ? AND user_id = ?", id, userId).Delete(&model.Favorite{})
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to delete favorite")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("favorite not found or not owned by user")
+	}
+	return nil
+}
+
+// GetFavoriteOwners returns the users who have favorites, with their groups
+func GetFavoriteOwners() ([]model.User, error) {
+	var users []model.User
+	if err := db.Where("id IN (?)", db.Model(&model.Favorite{}).Distinct("user_id")).Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get favorite owners")
+	}
+	for i := range users {
+		if err := loadUserGroups(&users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// MoveFavoritePaths rewrites the favorites of the user at or under srcPath to dstPath,
+// both paths are relative to the user's base path
+func MoveFavoritePaths(userId uint, srcPath, dstPath string, storageId uint) error {
+	err := whereSubPath(db.Model(&model.Favorite{}).Where("user_id = ?", userId), "original_path", srcPath).
+		Updates(map[string]interface{}{
+			"original_path": movedPathExpr("original_path", srcPath, dstPath),
+			"storage_id":    storageId,
+		}).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to update favorite paths")
+	}
+	// only the moved object itself may have a new name
+	return errors.WithStack(db.Model(&model.Favorite{}).Where("user_id = ? AND original_path = ?", userId, dstPath).
+		Update("file_name", stdpath.Base(dstPath)).Error)
+}
+
+// UpdateFavoriteFingerprint updates the fingerprint of the favorites of the user at the path
+func UpdateFavoriteFingerprint(userId uint, path, oldFingerprint, newFingerprint string) error {
+	return errors.WithStack(db.Model(&model.Favorite{}).
+		Where("user_id = ? AND original_path = ? AND fingerprint = ?", userId, path, oldFingerprint).
+		Update("fingerprint", newFingerprint).Error)
+}
+
+// UpdateFavoriteLocation points the favorite to a new path after it has been resolved again
+func UpdateFavoriteLocation(favorite *model.Favorite, originalPath string, storageId uint) error {
+	favorite.OriginalPath = originalPath
+	favorite.FileName = stdpath.Base(originalPath)
+	favorite.StorageId = storageId
+	return errors.WithStack(db.Save(favorite).Error)
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateFileVersion(v *model.FileVersion) error {
+	return errors.WithStack(db.Create(v).Error)
+}
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	var v model.FileVersion
+	if err := db.First(&v, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file version")
+	}
+	return &v, nil
+}
+
+// GetFileVersions returns the versions of the file, the latest first
+func GetFileVersions(storageId uint, actualPath string) (versions []model.FileVersion, err error) {
+	err = db.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("storage_id"), columnName("actual_path")), storageId, actualPath).
+		Order(columnName("id") + " DESC").Find(&versions).Error
+	return versions, errors.WithStack(err)
+}
+
+// GetFileVersionsBefore returns the versions of the storage created before t
+func GetFileVersionsBefore(storageId uint, t time.Time) (versions []model.FileVersion, err error) {
+	err = db.Where(fmt.Sprintf("%s = ? AND %s < ?", columnName("storage_id"), columnName("created_at")), storageId, t).
+		Find(&versions).Error
+	return versions, errors.WithStack(err)
+}
+
+func DeleteFileVersionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.FileVersion{}, id).Error)
+}
+
+func DeleteFileVersionsByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageId).Delete(&model.FileVersion{}).Error)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err := groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err := groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+func GetAllGroups() (groups []model.Group, err error) {
+	if err := db.Order(columnName("id")).Find(&groups).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, nil
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Group{}, id).Error)
+}
+
+// loadUserGroups fills the groups of the user in the order of its group ids,
+// ids of deleted groups are skipped
+func loadUserGroups(u *model.User) error {
+	u.Groups = nil
+	if len(u.GroupIds) == 0 {
+		return nil
+	}
+	var groups []model.Group
+	if err := db.Find(&groups, u.GroupIds).Error; err != nil {
+		return errors.Wrapf(err, "failed find groups of user")
+	}
+	byId := make(map[uint]model.Group, len(groups))
+	for _, g := range groups {
+		byId[g.ID] = g
+	}
+	for _, id := range u.GroupIds {
+		if g, ok := byId[id]; ok {
+			u.Groups = append(u.Groups, g)
+		}
+	}
+	return nil
+}
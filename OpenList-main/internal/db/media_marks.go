@@ -48,3 +48,21 @@ func ListAllMediaMarksByUser(userId uint) ([]model.MediaMark, error) {
 	}
 	return marks, nil
 }
+
+// MoveMediaMarkPaths rewrites the media marks of srcStorageId at or under srcPath to dstPath,
+// both paths are full paths including the mount path
+func MoveMediaMarkPaths(srcStorageId uint, srcPath, dstPath string, dstStorageId uint) error {
+	err := whereSubPath(db.Model(&model.MediaMark{}).Where("storage_id = ?", srcStorageId), "original_path", srcPath).
+		Updates(map[string]interface{}{
+			"original_path": movedPathExpr("original_path", srcPath, dstPath),
+			"storage_id":    dstStorageId,
+		}).Error
+	return errors.Wrapf(err, "failed to update media mark paths")
+}
+
+// UpdateMediaMarkFingerprint updates the fingerprint of the media marks at the path of the storage
+func UpdateMediaMarkFingerprint(storageId uint, path, oldFingerprint, newFingerprint string) error {
+	return errors.WithStack(db.Model(&model.MediaMark{}).
+		Where("storage_id = ? AND original_path = ? AND fingerprint = ?", storageId, path, oldFingerprint).
+		Update("fingerprint", newFingerprint).Error)
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetPlaybackProgress(userId uint, fingerprint string) (*model.PlaybackProgress, error) {
+	var progress model.PlaybackProgress
+	if err := db.Where("user_id = ? AND fingerprint = ?", userId, fingerprint).First(&progress).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to get playback progress")
+	}
+	return &progress, nil
+}
+
+func SavePlaybackProgress(progress *model.PlaybackProgress) error {
+	return errors.WithStack(db.Save(progress).Error)
+}
+
+// ListUnfinishedPlaybackProgress returns the most recently updated progresses
+// which are started but not finished, skipping the first offset ones
+func ListUnfinishedPlaybackProgress(userId uint, offset, limit int) ([]model.PlaybackProgress, error) {
+	var progresses []model.PlaybackProgress
+	if err := db.Where("user_id = ? AND finished = ? AND position > 0", userId, false).
+		Order("updated_at DESC, id DESC").Offset(offset).Limit(limit).Find(&progresses).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list unfinished playback progress")
+	}
+	return progresses, nil
+}
+
+// MovePlaybackProgressPaths rewrites the playback progresses at or under srcPath to dstPath,
+// both paths are full paths including the mount path
+func MovePlaybackProgressPaths(srcPath, dstPath string, storageId uint) error {
+	err := whereSubPath(db.Model(&model.PlaybackProgress{}), "original_path", srcPath).
+		Updates(map[string]interface{}{
+			"original_path": movedPathExpr("original_path", srcPath, dstPath),
+			"storage_id":    storageId,
+		}).Error
+	return errors.Wrapf(err, "failed to update playback progress paths")
+}
+
+// UpdatePlaybackProgressFingerprint moves the progresses at the path to the new fingerprint,
+// the progress already saved for the new fingerprint wins
+func UpdatePlaybackProgressFingerprint(path, oldFingerprint, newFingerprint string) error {
+	var progresses []model.PlaybackProgress
+	if err := db.Where("original_path = ? AND fingerprint = ?", path, oldFingerprint).Find(&progresses).Error; err != nil {
+		return errors.Wrapf(err, "failed to find playback progresses")
+	}
+	for _, progress := range progresses {
+		var count int64
+		if err := db.Model(&model.PlaybackProgress{}).Where("user_id = ? AND fingerprint = ?", progress.UserId, newFingerprint).
+			Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		var err error
+		if count > 0 {
+			err = db.Delete(&progress).Error
+		} else {
+			err = db.Model(&progress).Update("fingerprint", newFingerprint).Error
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to update playback progress fingerprint")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetScheduledJobs(pageIndex, pageSize int) (jobs []model.ScheduledJob, count int64, err error) {
+	jobDB := db.Model(&model.ScheduledJob{})
+	if err := jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled jobs count")
+	}
+	if err := jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledScheduledJobs() ([]model.ScheduledJob, error) {
+	var jobs []model.ScheduledJob
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("disabled")), false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled scheduled jobs")
+	}
+	return jobs, nil
+}
+
+func GetScheduledJobById(id uint) (*model.ScheduledJob, error) {
+	var job model.ScheduledJob
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get scheduled job")
+	}
+	return &job, nil
+}
+
+func CreateScheduledJob(job *model.ScheduledJob) error {
+	return errors.WithStack(db.Create(job).Error)
+}
+
+func UpdateScheduledJob(job *model.ScheduledJob) error {
+	return errors.WithStack(db.Save(job).Error)
+}
+
+// SetScheduledJobLastRun only updates the last run time, so that it doesn't overwrite a concurrent update of the job
+func SetScheduledJobLastRun(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.ScheduledJob{ID: id}).Update("last_run_at", t).Error)
+}
+
+// DeleteScheduledJobById deletes the job together with its run history
+func DeleteScheduledJobById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", id).Delete(&model.ScheduledJobRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.ScheduledJob{}, id).Error
+	}))
+}
+
+func CreateScheduledJobRun(run *model.ScheduledJobRun) error {
+	return errors.WithStack(db.Create(run).Error)
+}
+
+func UpdateScheduledJobRun(run *model.ScheduledJobRun) error {
+	return errors.WithStack(db.Save(run).Error)
+}
+
+// GetScheduledJobRuns returns the runs of a job, latest first
+func GetScheduledJobRuns(jobId uint, pageIndex, pageSize int) (runs []model.ScheduledJobRun, count int64, err error) {
+	runDB := db.Model(&model.ScheduledJobRun{}).Where("job_id = ?", jobId)
+	if err := runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled job runs count")
+	}
+	if err := runDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled job runs")
+	}
+	return runs, count, nil
+}
+
+// PruneScheduledJobRuns keeps the latest keep runs of a job
+func PruneScheduledJobRuns(jobId uint, keep int) error {
+	var ids []uint
+	err := db.Model(&model.ScheduledJobRun{}).Where("job_id = ?", jobId).
+		Order(columnName("id")+" DESC").Offset(keep-1).Limit(1).Pluck("id", &ids).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed find oldest kept scheduled job run")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.Where("job_id = ? AND id < ?", jobId, ids[0]).Delete(&model.ScheduledJobRun{}).Error)
+}
+
+// FailRunningScheduledJobRuns marks the runs that were interrupted by a restart as failed
+func FailRunningScheduledJobRuns(reason string) error {
+	return errors.WithStack(db.Model(&model.ScheduledJobRun{}).
+		Where(fmt.Sprintf("%s = ?", columnName("status")), model.ScheduledJobRunRunning).
+		Updates(map[string]any{"status": model.ScheduledJobRunFailed, "error": reason}).Error)
+}
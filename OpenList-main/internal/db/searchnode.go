@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	stdpath "path"
+	"regexp"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -35,7 +36,8 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
+	// parents are stored without the trailing slash
+	dir, name := stdpath.Dir(path), stdpath.Base(path)
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
 		dir, name).Delete(&model.SearchNode{}).Error
@@ -56,7 +58,8 @@ func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
 
 func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
 	var searchDB *gorm.DB
-	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
+	// searching by the filters only needs no full text index
+	if !useFullText || conf.Conf.Database.Type == "sqlite3" || strings.TrimSpace(req.Keywords) == "" {
 		keywordsClause := db.Where("1 = 1")
 		for _, keyword := range strings.Fields(req.Keywords) {
 			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
@@ -77,15 +80,234 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = whereSearchFilters(searchDB, req)
+	order := fmt.Sprintf("%s %s", columnName(req.OrderBy), req.OrderDirection)
+	if req.OrderBy != model.SearchOrderByName {
+		order += fmt.Sprintf(", %s %s", columnName("name"), req.OrderDirection)
+	}
+	searchDB = searchDB.Order(order)
 
+	if nameMatchedInMemory(req) {
+		return searchNodesMatchedInMemory(searchDB, req)
+	}
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+// globToLike converts a glob without character classes to a pattern of LIKE escaped with !,
+// as \ is not escaped the same way by all the databases
+func globToLike(glob string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range glob {
+		special := r == '%' || r == '_' || r == '!'
+		switch {
+		case escaped:
+			escaped = false
+			if special {
+				sb.WriteRune('!')
+			}
+			sb.WriteRune(r)
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			sb.WriteRune('%')
+		case r == '?':
+			sb.WriteRune('_')
+		case special:
+			sb.WriteRune('!')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// nameMatchedInMemory tells if the name filter can't be done by the databases,
+// regexps are not supported by all of them and LIKE has no character classes
+func nameMatchedInMemory(req model.SearchReq) bool {
+	return req.Name != "" && (req.NameRegexp || strings.ContainsRune(req.Name, '['))
+}
+
+// nameLikePrefilter returns a pattern of LIKE matching at least all the names matched by the request
+// and if it is case sensitive, so that the names matched in memory are prefiltered by the databases
+func nameLikePrefilter(req model.SearchReq) (string, bool) {
+	if req.NameRegexp {
+		re, err := regexp.Compile(req.Name)
+		if err != nil {
+			return "", false
+		}
+		// a literal every match starts with, the match may start anywhere in the name
+		prefix, _ := re.LiteralPrefix()
+		if prefix == "" {
+			return "", false
+		}
+		return "%" + likeEscaper.Replace(prefix) + "%", true
+	}
+	// a character class matches a single character
+	var sb strings.Builder
+	inClass, escaped := false, false
+	for _, r := range req.Name {
+		switch {
+		case escaped:
+			escaped = false
+			if !inClass {
+				sb.WriteRune('\\')
+				sb.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case inClass:
+			inClass = r != ']'
+		case r == '[':
+			inClass = true
+			sb.WriteRune('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return strings.ToLower(globToLike(sb.String())), false
+}
+
+// searchNodesMatchedInMemory reads the rows in the order of the databases, matching their names one by one
+// and only keeping the ones of the requested page
+func searchNodesMatchedInMemory(tx *gorm.DB, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	rows, err := tx.Rows()
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get search items")
+	}
+	defer rows.Close()
+	start := int64(req.Page-1) * int64(req.PerPage)
+	files := []model.SearchNode{}
+	var count int64
+	for rows.Next() {
+		var node model.SearchNode
+		if err := db.ScanRows(rows, &node); err != nil {
+			return nil, 0, err
+		}
+		if !req.MatchName(node.Name) {
+			continue
+		}
+		if count >= start && len(files) < req.PerPage {
+			files = append(files, node)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get search items")
+	}
+	return files, count, nil
+}
+
+func whereSearchFilters(tx *gorm.DB, req model.SearchReq) *gorm.DB {
+	if req.MinSize > 0 {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("size")), req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("size")), req.MaxSize)
+	}
+	if req.ModifiedAfter != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("modified")), req.ModifiedAfter.UTC())
+	}
+	if req.ModifiedBefore != nil {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("modified")), req.ModifiedBefore.UTC())
+	}
+	if len(req.Exts) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("ext")), req.Exts)
+	}
+	if len(req.MediaTypes) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("media_type")), req.MediaTypes)
+	}
+	if req.Name != "" && !nameMatchedInMemory(req) {
+		tx = tx.Where(fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '!'", columnName("name")), strings.ToLower(globToLike(req.Name)))
+	} else if req.Name != "" {
+		if like, caseSensitive := nameLikePrefilter(req); caseSensitive {
+			tx = tx.Where(fmt.Sprintf("%s LIKE ? ESCAPE '!'", columnName("name")), like)
+		} else if like != "" {
+			tx = tx.Where(fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '!'", columnName("name")), like)
+		}
+	}
+	return tx
+}
+
+func whereLibrary(req model.LibraryReq) *gorm.DB {
+	tx := db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
+		Where(fmt.Sprintf("%s = ?", columnName("is_dir")), false).
+		Where(db.Where(fmt.Sprintf("%s <> ''", columnName("artist"))).Or(fmt.Sprintf("%s <> ''", columnName("album"))))
+	for _, filter := range [][2]string{
+		{"artist", req.Artist},
+		{"album_artist", req.AlbumArtist},
+		{"album", req.Album},
+		{"genre", req.Genre},
+	} {
+		if filter[1] != "" {
+			tx = tx.Where(fmt.Sprintf("%s = ?", columnName(filter[0])), filter[1])
+		}
+	}
+	if req.Year != 0 {
+		tx = tx.Where(fmt.Sprintf("%s = ?", columnName("year")), req.Year)
+	}
+	return tx
+}
+
+func LibraryArtists(req model.LibraryReq) ([]model.LibraryArtist, int64, error) {
+	artist := columnName("artist")
+	var count int64
+	if err := whereLibrary(req).Where(fmt.Sprintf("%s <> ''", artist)).
+		Distinct(artist).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library artists count")
+	}
+	var artists []model.LibraryArtist
+	if err := whereLibrary(req).Where(fmt.Sprintf("%s <> ''", artist)).
+		Select(fmt.Sprintf("%s AS name, COUNT(DISTINCT NULLIF(%s, '')) AS album_count, COUNT(*) AS track_count", artist, columnName("album"))).
+		Group(artist).Order(artist).
+		Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+		Scan(&artists).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library artists")
+	}
+	return artists, count, nil
+}
+
+func LibraryAlbums(req model.LibraryReq) ([]model.LibraryAlbum, int64, error) {
+	album, albumArtist := columnName("album"), columnName("album_artist")
+	groups := whereLibrary(req).Where(fmt.Sprintf("%s <> ''", album)).Group(album).Group(albumArtist)
+	var count int64
+	if err := db.Table("(?) AS albums", groups.Session(&gorm.Session{}).Select(album+", "+albumArtist)).
+		Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library albums count")
+	}
+	var albums []model.LibraryAlbum
+	if err := groups.Select(fmt.Sprintf("%s AS name, %s AS artist, MAX(%s) AS year, MAX(%s) AS genre, MIN(%s) AS parent, COUNT(*) AS track_count",
+		album, albumArtist, columnName("year"), columnName("genre"), columnName("parent"))).
+		Order(album).Order(albumArtist).
+		Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+		Scan(&albums).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library albums")
+	}
+	return albums, count, nil
+}
+
+func LibraryTracks(req model.LibraryReq) ([]model.SearchNode, int64, error) {
+	var count int64
+	if err := whereLibrary(req).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library tracks count")
+	}
+	var tracks []model.SearchNode
+	if err := whereLibrary(req).
+		Order(columnName("album_artist")).Order(columnName("album")).
+		Order(columnName("track")).Order(columnName("name")).
+		Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+		Find(&tracks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get library tracks")
+	}
+	return tracks, count, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:db_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	Init(dB)
+}
+
+func TestNameLikePrefilter(t *testing.T) {
+	tests := []struct {
+		name, like    string
+		regexp, cased bool
+	}{
+		{name: "IMG_[0-9]*.jpg", like: "img!__%.jpg"},
+		{name: `a\[b]_[!\]]?`, like: "a[b]!___"},
+		{name: "report-\\d+", regexp: true, like: "%report-%", cased: true},
+		{name: "100%_done", regexp: true, like: "%100!%!_done%", cased: true},
+		{name: "^Show", regexp: true, like: "%Show%", cased: true},
+		{name: "show|film", regexp: true},
+		{name: "(?i)show", regexp: true},
+	}
+	for _, tt := range tests {
+		like, cased := nameLikePrefilter(model.SearchReq{Name: tt.name, NameRegexp: tt.regexp})
+		if like != tt.like || cased != tt.cased {
+			t.Errorf("nameLikePrefilter(%q) = %q, %v, want %q, %v", tt.name, like, cased, tt.like, tt.cased)
+		}
+	}
+}
+
+func TestSearchNodeMatchedInMemory(t *testing.T) {
+	nodes := []model.SearchNode{
+		{Parent: "/regexp", Name: "report-1.txt"},
+		{Parent: "/regexp", Name: "report-22.txt"},
+		{Parent: "/regexp", Name: "report-x.txt"},
+		{Parent: "/regexp", Name: "report-333.txt"},
+		{Parent: "/regexp", Name: "summary-4.txt"},
+	}
+	if err := BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	req := model.SearchReq{
+		Parent:     "/regexp",
+		Name:       `report-\d+`,
+		NameRegexp: true,
+		PageReq:    model.PageReq{Page: 2, PerPage: 2},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	files, count, err := SearchNode(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || len(files) != 1 || files[0].Name != "report-333.txt" {
+		t.Errorf("SearchNode() = %+v, %d", files, count)
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+// GetTrashItems returns the items matching q, the latest removed first
+func GetTrashItems(q *model.TrashQuery) (items []model.TrashItem, count int64, err error) {
+	itemDB := db.Model(&model.TrashItem{})
+	if q.UserId != 0 {
+		itemDB = itemDB.Where(fmt.Sprintf("%s = ?", columnName("user_id")), q.UserId)
+	}
+	if q.Path != "" {
+		itemDB = whereSubPath(itemDB, columnName("path"), q.Path)
+	}
+	if err := itemDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err := itemDB.Order(columnName("id") + " DESC").Offset((q.Page - 1) * q.PerPage).Limit(q.PerPage).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+// GetTrashItemsBefore returns the items removed before t
+func GetTrashItemsBefore(t time.Time) (items []model.TrashItem, err error) {
+	err = db.Where(fmt.Sprintf("%s < ?", columnName("removed_at")), t).Find(&items).Error
+	return items, errors.WithStack(err)
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
+
+func DeleteTrashItemsByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageId).Delete(&model.TrashItem{}).Error)
+}
@@ -14,7 +14,7 @@ func GetUserByRole(role int) (*model.User, error) {
 	if err := db.Where(user).Take(&user).Error; err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return &user, loadUserGroups(&user)
 }
 
 func GetUserByName(username string) (*model.User, error) {
@@ -22,7 +22,7 @@ func GetUserByName(username string) (*model.User, error) {
 	if err := db.Where(user).First(&user).Error; err != nil {
 		return nil, errors.Wrapf(err, "failed find user")
 	}
-	return &user, nil
+	return &user, loadUserGroups(&user)
 }
 
 func GetUserBySSOID(ssoID string) (*model.User, error) {
@@ -30,7 +30,7 @@ func GetUserBySSOID(ssoID string) (*model.User, error) {
 	if err := db.Where(user).First(&user).Error; err != nil {
 		return nil, errors.Wrapf(err, "The single sign on platform is not bound to any users")
 	}
-	return &user, nil
+	return &user, loadUserGroups(&user)
 }
 
 func GetUserById(id uint) (*model.User, error) {
@@ -38,7 +38,7 @@ func GetUserById(id uint) (*model.User, error) {
 	if err := db.First(&u, id).Error; err != nil {
 		return nil, errors.Wrapf(err, "failed get old user")
 	}
-	return &u, nil
+	return &u, loadUserGroups(&u)
 }
 
 func CreateUser(u *model.User) error {
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddUserTraffic adds the bytes to the traffic of the user in the month
+func AddUserTraffic(userId uint, month string, uploaded, downloaded int64) error {
+	t := model.UserTraffic{UserId: userId, Month: month, Uploaded: uploaded, Downloaded: downloaded}
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"uploaded":   gorm.Expr(columnName("uploaded")+" + ?", uploaded),
+			"downloaded": gorm.Expr(columnName("downloaded")+" + ?", downloaded),
+		}),
+	}).Create(&t).Error)
+}
+
+// GetUserTraffic returns the traffic of the user in the month, zero if there is none
+func GetUserTraffic(userId uint, month string) (*model.UserTraffic, error) {
+	t := model.UserTraffic{UserId: userId, Month: month}
+	if err := db.Where("user_id = ? AND month = ?", userId, month).Limit(1).Find(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user traffic")
+	}
+	return &t, nil
+}
+
+// GetUserUploaded returns the bytes the user uploaded in total
+func GetUserUploaded(userId uint) (int64, error) {
+	var total int64
+	err := db.Model(&model.UserTraffic{}).Where("user_id = ?", userId).
+		Select("COALESCE(SUM(" + columnName("uploaded") + "), 0)").Scan(&total).Error
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed sum user uploaded")
+	}
+	return total, nil
+}
+
+func DeleteUserTraffic(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.UserTraffic{}).Error)
+}
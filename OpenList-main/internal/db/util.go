@@ -2,9 +2,13 @@ package db
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func columnName(name string) string {
@@ -17,3 +21,21 @@ func columnName(name string) string {
 func addStorageOrder(db *gorm.DB) *gorm.DB {
 	return db.Order(fmt.Sprintf("%s, %s", columnName("order"), columnName("id")))
 }
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// whereSubPath matches the rows whose column is path or a sub path of it
+func whereSubPath(db *gorm.DB, column, path string) *gorm.DB {
+	pattern := likeEscaper.Replace(utils.PathAddSeparatorSuffix(path)) + "%"
+	return db.Where(fmt.Sprintf("(%s = ? OR %s LIKE ? ESCAPE '!')", column, column), path, pattern)
+}
+
+// movedPathExpr is the column of the rows matched by whereSubPath with srcPath
+// after srcPath has been moved to dstPath
+func movedPathExpr(column, srcPath, dstPath string) clause.Expr {
+	rest := fmt.Sprintf("SUBSTR(%s, %d)", column, utf8.RuneCountInString(srcPath)+1)
+	if conf.Conf.Database.Type == "mysql" {
+		return gorm.Expr(fmt.Sprintf("CONCAT(?, %s)", rest), dstPath)
+	}
+	return gorm.Expr(fmt.Sprintf("? || %s", rest), dstPath)
+}
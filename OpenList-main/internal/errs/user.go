@@ -7,4 +7,10 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+
+	InvalidAPIToken = errors.New("api token is invalid")
+	APITokenExpired = errors.New("api token is expired")
+
+	UploadQuotaExceeded = errors.New("upload quota exceeded")
+	DownloadCapExceeded = errors.New("monthly download traffic cap exceeded")
 )
@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:fs_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestWrappersCheckACL(t *testing.T) {
+	for _, meta := range []model.Meta{
+		{Path: "/acl/noread", ASub: true, ACL: []model.ACLRule{{User: "bob", Deny: true, Actions: []string{model.ACLRead}}}},
+		{Path: "/acl/nowrite", ASub: true, ACL: []model.ACLRule{{User: "bob", Deny: true, Actions: []string{model.ACLWrite}}}},
+		{Path: "/acl/nodelete", ASub: true, ACL: []model.ACLRule{{User: "bob", Deny: true, Actions: []string{model.ACLDelete}}}},
+	} {
+		if err := op.CreateMeta(&meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Username: "bob"})
+	for name, call := range map[string]func() error{
+		"sync from unreadable": func() error {
+			_, err := Sync(ctx, "/acl/noread", "/acl/free", model.SyncArgs{})
+			return err
+		},
+		"sync to unwritable": func() error {
+			_, err := Sync(ctx, "/acl/free", "/acl/nowrite", model.SyncArgs{})
+			return err
+		},
+		"two way sync to unwritable source": func() error {
+			_, err := Sync(ctx, "/acl/nowrite", "/acl/free", model.SyncArgs{TwoWay: true})
+			return err
+		},
+		"deleting sync in undeletable": func() error {
+			_, err := Sync(ctx, "/acl/free", "/acl/nodelete", model.SyncArgs{Delete: true})
+			return err
+		},
+		"sync plan of unreadable": func() error {
+			_, err := SyncPlan(ctx, "/acl/free", "/acl/noread", model.SyncArgs{})
+			return err
+		},
+		"remove empty dirs of undeletable": func() error {
+			return RemoveEmptyDirectory(ctx, "/acl/nodelete")
+		},
+		"remove empty dirs of unreadable": func() error {
+			return RemoveEmptyDirectory(ctx, "/acl/noread")
+		},
+		"decompress unreadable": func() error {
+			_, err := ArchiveDecompress(ctx, "/acl/noread/a.zip", "/acl/free", model.ArchiveDecompressArgs{})
+			return err
+		},
+		"decompress to unwritable": func() error {
+			_, err := ArchiveDecompress(ctx, "/acl/free/a.zip", "/acl/nowrite", model.ArchiveDecompressArgs{})
+			return err
+		},
+		"put url to unwritable": func() error {
+			return PutURL(ctx, "/acl/nowrite", "a.txt", "http://example.com/a.txt")
+		},
+	} {
+		if err := call(); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("%s: got %v, want permission denied", name, err)
+		}
+	}
+}
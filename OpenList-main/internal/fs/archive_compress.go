@@ -0,0 +1,286 @@
+package fs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/KirCute/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+)
+
+// ArchiveCompressTask puts objects of the source directory into an archive created in the destination directory
+type ArchiveCompressTask struct {
+	TaskData
+	model.ArchiveCompressArgs
+}
+
+func (t *ArchiveCompressTask) GetName() string {
+	return fmt.Sprintf("compress %d objects of [%s](%s) to [%s](%s)", len(t.Names), t.SrcStorageMp, t.SrcActualPath,
+		t.DstStorageMp, stdpath.Join(t.DstActualPath, t.ArchiveName))
+}
+
+func (t *ArchiveCompressTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
+	if t.DstStorage == nil {
+		if dstStorage, _, err := op.GetStorageAndActualPath(t.DstStorageMp); err == nil {
+			t.DstStorage = dstStorage
+		} else {
+			return err
+		}
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Status = "walking"
+	entries, err := t.walk()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, e := range entries {
+		if !e.obj.IsDir() {
+			total += e.obj.GetSize()
+		}
+	}
+	t.SetTotalBytes(total)
+	t.Status = "compressing"
+	var file *stream.FileStream
+	if t.Format == model.ArchiveFormat7zStore {
+		if file, err = t.writeTempFile(entries, total); err != nil {
+			return err
+		}
+	} else {
+		// the archive is written to a pipe while uploading, its size is unknown so op.Put caches it
+		// beyond the buffer limit to a temp file rather than the memory
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(t.write(pw, entries, total, model.UpdateProgressWithRange(t.SetProgress, 0, 50)))
+		}()
+		file = &stream.FileStream{
+			Ctx:      t.Ctx(),
+			Obj:      &model.Object{Name: t.ArchiveName, Size: -1, Modified: time.Now()},
+			Reader:   pr,
+			Mimetype: utils.GetMimeType(t.ArchiveName),
+		}
+		file.Add(pr)
+	}
+	return op.Put(t.Ctx(), t.DstStorage, t.DstActualPath, file, model.UpdateProgressWithRange(t.SetProgress, 50, 100))
+}
+
+// writeTempFile writes the archive to a temp file before uploading it,
+// for the formats whose headers are updated once the files are written
+func (t *ArchiveCompressTask) writeTempFile(entries []archiveEntry, total int64) (*stream.FileStream, error) {
+	f, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	closer := utils.CloseFunc(func() error {
+		return stderrors.Join(f.Close(), os.Remove(f.Name()))
+	})
+	err = t.write(f, entries, total, model.UpdateProgressWithRange(t.SetProgress, 0, 50))
+	var size int64
+	if err == nil {
+		size, err = f.Seek(0, io.SeekEnd)
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = closer.Close()
+		return nil, err
+	}
+	file := &stream.FileStream{
+		Ctx:      t.Ctx(),
+		Obj:      &model.Object{Name: t.ArchiveName, Size: size, Modified: time.Now()},
+		Reader:   f,
+		Mimetype: utils.GetMimeType(t.ArchiveName),
+	}
+	file.Add(closer)
+	return file, nil
+}
+
+type archiveEntry struct {
+	// path is the mount path of the object, name is its path in the archive
+	path string
+	name string
+	obj  model.Obj
+}
+
+func (t *ArchiveCompressTask) walk() ([]archiveEntry, error) {
+	srcDir := stdpath.Join(t.SrcStorageMp, t.SrcActualPath)
+	var entries []archiveEntry
+	for _, name := range t.Names {
+		p := stdpath.Join(srcDir, name)
+		obj, err := get(t.Ctx(), p, &GetArgs{NoLog: true})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get [%s]", p)
+		}
+		err = WalkFS(t.Ctx(), -1, p, obj, func(reqPath string, info model.Obj) error {
+			entries = append(entries, archiveEntry{
+				path: reqPath,
+				name: strings.TrimPrefix(reqPath, utils.PathAddSeparatorSuffix(srcDir)),
+				obj:  info,
+			})
+			return t.Ctx().Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// archiveWriter writes the entries of an archive one after another
+type archiveWriter interface {
+	create(e archiveEntry) (io.Writer, error)
+	Close() error
+}
+
+type zipArchiveWriter struct {
+	*zip.Writer
+	password string
+}
+
+func (w *zipArchiveWriter) create(e archiveEntry) (io.Writer, error) {
+	fh := &zip.FileHeader{
+		Name:   e.name,
+		Method: zip.Deflate,
+		// the names are in utf-8
+		Flags: 0x800,
+	}
+	fh.SetModTime(e.obj.ModTime())
+	if e.obj.IsDir() {
+		fh.Name += "/"
+		fh.Method = zip.Store
+	} else if w.password != "" {
+		fh.SetPassword(w.password)
+		fh.SetEncryptionMethod(zip.AES256Encryption)
+	}
+	return w.CreateHeader(fh)
+}
+
+type tarGzArchiveWriter struct {
+	*tar.Writer
+	gw *gzip.Writer
+}
+
+func (w *tarGzArchiveWriter) create(e archiveEntry) (io.Writer, error) {
+	hdr := &tar.Header{
+		Name:    e.name,
+		ModTime: e.obj.ModTime(),
+		Mode:    0o644,
+		Size:    e.obj.GetSize(),
+		Format:  tar.FormatPAX,
+	}
+	if e.obj.IsDir() {
+		hdr.Name += "/"
+		hdr.Typeflag = tar.TypeDir
+		hdr.Mode = 0o755
+		hdr.Size = 0
+	}
+	return w.Writer, w.WriteHeader(hdr)
+}
+
+func (w *tarGzArchiveWriter) Close() error {
+	return stderrors.Join(w.Writer.Close(), w.gw.Close())
+}
+
+func (t *ArchiveCompressTask) write(w io.Writer, entries []archiveEntry, total int64, up model.UpdateProgress) error {
+	var aw archiveWriter
+	switch t.Format {
+	case model.ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		aw = &tarGzArchiveWriter{Writer: tar.NewWriter(gw), gw: gw}
+	case model.ArchiveFormat7zStore:
+		ws, ok := w.(io.WriteSeeker)
+		if !ok {
+			return errors.New("7z archives can only be written to a file")
+		}
+		aw = &sevenZipArchiveWriter{w: ws}
+	default:
+		aw = &zipArchiveWriter{Writer: zip.NewWriter(w), password: t.Password}
+	}
+	var done int64
+	for i, e := range entries {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		t.Status = fmt.Sprintf("compressing %s (%d/%d)", e.name, i+1, len(entries))
+		ew, err := aw.create(e)
+		if err != nil {
+			return errors.WithMessagef(err, "failed add [%s] to archive", e.name)
+		}
+		if e.obj.IsDir() {
+			continue
+		}
+		ss, err := openFile(t.Ctx(), e.path)
+		if err != nil {
+			return err
+		}
+		size := e.obj.GetSize()
+		_, err = utils.CopyWithBuffer(ew, &stream.ReaderUpdatingProgress{
+			Reader: &stream.SimpleReaderWithSize{Reader: ss, Size: size},
+			UpdateProgress: func(p float64) {
+				if total > 0 {
+					up((float64(done) + p*float64(size)/100) * 100 / float64(total))
+				}
+			},
+		})
+		_ = ss.Close()
+		if err != nil {
+			return errors.WithMessagef(err, "failed compress [%s]", e.name)
+		}
+		done += size
+	}
+	return aw.Close()
+}
+
+func archiveCompress(ctx context.Context, srcDirPath, dstDirPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	srcStorage, srcDirActualPath, err := op.GetStorageAndActualPath(srcDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	t := &ArchiveCompressTask{
+		TaskData: TaskData{
+			SrcStorage:    srcStorage,
+			DstStorage:    dstStorage,
+			SrcActualPath: srcDirActualPath,
+			DstActualPath: dstDirActualPath,
+			SrcStorageMp:  srcStorage.GetStorage().MountPath,
+			DstStorageMp:  dstStorage.GetStorage().MountPath,
+		},
+		ArchiveCompressArgs: args,
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		t.Base.SetCtx(ctx)
+		return nil, t.Run()
+	}
+	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	t.ApiUrl = common.GetApiUrl(ctx)
+	ArchiveCompressTaskManager.Add(t)
+	return t, nil
+}
+
+var ArchiveCompressTaskManager *tache.Manager[*ArchiveCompressTask]
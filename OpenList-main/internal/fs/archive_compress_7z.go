@@ -0,0 +1,242 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"slices"
+	"time"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// ids of the properties of the 7z headers
+const (
+	sevenZipEnd             = 0x00
+	sevenZipHeader          = 0x01
+	sevenZipMainStreamsInfo = 0x04
+	sevenZipFilesInfo       = 0x05
+	sevenZipPackInfo        = 0x06
+	sevenZipUnpackInfo      = 0x07
+	sevenZipSubStreamsInfo  = 0x08
+	sevenZipSize            = 0x09
+	sevenZipCRC             = 0x0a
+	sevenZipFolder          = 0x0b
+	sevenZipCodersUnpack    = 0x0c
+	sevenZipNumUnpackStream = 0x0d
+	sevenZipEmptyStream     = 0x0e
+	sevenZipEmptyFile       = 0x0f
+	sevenZipName            = 0x11
+	sevenZipMTime           = 0x14
+	sevenZipWinAttributes   = 0x15
+)
+
+const sevenZipStartHeaderSize = 32
+
+var sevenZipSignature = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0, 4}
+
+type sevenZipFile struct {
+	name    string
+	dir     bool
+	modTime time.Time
+	size    int64
+	crc     hash.Hash32
+}
+
+// sevenZipArchiveWriter writes a 7z archive storing the files as they are, in a single folder
+// copied without compression. The start header points at the end header written last,
+// so the archive is written to a seekable file.
+type sevenZipArchiveWriter struct {
+	w     io.WriteSeeker
+	files []*sevenZipFile
+}
+
+type sevenZipFileWriter struct {
+	w    io.Writer
+	file *sevenZipFile
+}
+
+func (w *sevenZipFileWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.file.size += int64(n)
+	w.file.crc.Write(p[:n])
+	return n, err
+}
+
+func (w *sevenZipArchiveWriter) create(e archiveEntry) (io.Writer, error) {
+	if len(w.files) == 0 {
+		// the start header is written on close
+		if _, err := w.w.Write(make([]byte, sevenZipStartHeaderSize)); err != nil {
+			return nil, err
+		}
+	}
+	f := &sevenZipFile{name: e.name, dir: e.obj.IsDir(), modTime: e.obj.ModTime(), crc: crc32.NewIEEE()}
+	w.files = append(w.files, f)
+	return &sevenZipFileWriter{w: w.w, file: f}, nil
+}
+
+func (w *sevenZipArchiveWriter) Close() error {
+	if len(w.files) == 0 {
+		if _, err := w.w.Write(make([]byte, sevenZipStartHeaderSize)); err != nil {
+			return err
+		}
+	}
+	var packed int64
+	for _, f := range w.files {
+		packed += f.size
+	}
+	header := w.header(packed)
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	start := make([]byte, 20)
+	binary.LittleEndian.PutUint64(start, uint64(packed))
+	binary.LittleEndian.PutUint64(start[8:], uint64(len(header)))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(header))
+	if _, err := w.w.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := w.w.Write(binary.LittleEndian.AppendUint32(append([]byte(nil), sevenZipSignature...), crc32.ChecksumIEEE(start)))
+	if err == nil {
+		_, err = w.w.Write(start)
+	}
+	return err
+}
+
+// header encodes the end header, the data of the files follows the start header in their order
+func (w *sevenZipArchiveWriter) header(packed int64) []byte {
+	var streams []*sevenZipFile
+	empty := make([]bool, len(w.files))
+	var emptyFiles []bool
+	for i, f := range w.files {
+		if f.size > 0 {
+			streams = append(streams, f)
+			continue
+		}
+		empty[i] = true
+		emptyFiles = append(emptyFiles, !f.dir)
+	}
+
+	h := &bytes.Buffer{}
+	h.WriteByte(sevenZipHeader)
+	if len(streams) > 0 {
+		h.WriteByte(sevenZipMainStreamsInfo)
+		h.WriteByte(sevenZipPackInfo)
+		writeSevenZipNumber(h, 0)
+		writeSevenZipNumber(h, 1)
+		h.WriteByte(sevenZipSize)
+		writeSevenZipNumber(h, uint64(packed))
+		h.WriteByte(sevenZipEnd)
+
+		h.WriteByte(sevenZipUnpackInfo)
+		h.WriteByte(sevenZipFolder)
+		writeSevenZipNumber(h, 1)
+		h.WriteByte(0)
+		// a single coder with the 1 byte id of copy
+		writeSevenZipNumber(h, 1)
+		h.Write([]byte{0x01, 0x00})
+		h.WriteByte(sevenZipCodersUnpack)
+		writeSevenZipNumber(h, uint64(packed))
+		h.WriteByte(sevenZipEnd)
+
+		h.WriteByte(sevenZipSubStreamsInfo)
+		h.WriteByte(sevenZipNumUnpackStream)
+		writeSevenZipNumber(h, uint64(len(streams)))
+		if len(streams) > 1 {
+			// the size of the last stream is the rest of the folder
+			h.WriteByte(sevenZipSize)
+			for _, f := range streams[:len(streams)-1] {
+				writeSevenZipNumber(h, uint64(f.size))
+			}
+		}
+		h.WriteByte(sevenZipCRC)
+		h.WriteByte(1)
+		for _, f := range streams {
+			_ = binary.Write(h, binary.LittleEndian, f.crc.Sum32())
+		}
+		h.WriteByte(sevenZipEnd)
+		h.WriteByte(sevenZipEnd)
+	}
+
+	h.WriteByte(sevenZipFilesInfo)
+	writeSevenZipNumber(h, uint64(len(w.files)))
+	if len(streams) < len(w.files) {
+		writeSevenZipProperty(h, sevenZipEmptyStream, sevenZipBits(empty))
+		if slices.Contains(emptyFiles, true) {
+			writeSevenZipProperty(h, sevenZipEmptyFile, sevenZipBits(emptyFiles))
+		}
+	}
+	names := &bytes.Buffer{}
+	names.WriteByte(0)
+	times := &bytes.Buffer{}
+	times.Write([]byte{1, 0})
+	attributes := &bytes.Buffer{}
+	attributes.Write([]byte{1, 0})
+	for _, f := range w.files {
+		for _, c := range utf16.Encode([]rune(f.name)) {
+			_ = binary.Write(names, binary.LittleEndian, c)
+		}
+		names.Write([]byte{0, 0})
+		_ = binary.Write(times, binary.LittleEndian, sevenZipFileTime(f.modTime))
+		var attribute uint32 = 0x20
+		if f.dir {
+			attribute = 0x10
+		}
+		_ = binary.Write(attributes, binary.LittleEndian, attribute)
+	}
+	writeSevenZipProperty(h, sevenZipName, names.Bytes())
+	writeSevenZipProperty(h, sevenZipMTime, times.Bytes())
+	writeSevenZipProperty(h, sevenZipWinAttributes, attributes.Bytes())
+	h.WriteByte(sevenZipEnd)
+	h.WriteByte(sevenZipEnd)
+	return h.Bytes()
+}
+
+// writeSevenZipNumber writes v in the variable length encoding of 7z,
+// the leading one bits of the first byte count the bytes following it
+func writeSevenZipNumber(w *bytes.Buffer, v uint64) {
+	first, mask := byte(0), byte(0x80)
+	i := 0
+	for ; i < 8; i++ {
+		if v < 1<<(7*(i+1)) {
+			first |= byte(v >> (8 * i))
+			break
+		}
+		first |= mask
+		mask >>= 1
+	}
+	w.WriteByte(first)
+	for ; i > 0; i-- {
+		w.WriteByte(byte(v))
+		v >>= 8
+	}
+}
+
+func writeSevenZipProperty(w *bytes.Buffer, id byte, data []byte) {
+	w.WriteByte(id)
+	writeSevenZipNumber(w, uint64(len(data)))
+	w.Write(data)
+}
+
+// sevenZipBits packs the bits from the most significant one of each byte
+func sevenZipBits(bits []bool) []byte {
+	res := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			res[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return res
+}
+
+// sevenZipFileTime is t in 100 nanoseconds since 1601
+func sevenZipFileTime(t time.Time) uint64 {
+	ft := t.Unix()*10000000 + int64(t.Nanosecond())/100 + 116444736000000000
+	if ft < 0 {
+		return 0
+	}
+	return uint64(ft)
+}
@@ -0,0 +1,166 @@
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KirCute/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/bodgit/sevenzip"
+)
+
+func writeTestArchive(t *testing.T, aw archiveWriter) {
+	now := time.Now()
+	entries := []archiveEntry{
+		{name: "dir", obj: &model.Object{IsFolder: true, Modified: now}},
+		{name: "dir/文件.txt", obj: &model.Object{Size: 5, Modified: now}},
+	}
+	for _, e := range entries {
+		w, err := aw.create(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.obj.IsDir() {
+			if _, err = w.Write([]byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZipArchiveWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestArchive(t, &zipArchiveWriter{Writer: zip.NewWriter(&buf), password: "secret"})
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 2 || r.File[0].Name != "dir/" || r.File[1].Name != "dir/文件.txt" {
+		t.Fatalf("unexpected files in zip: %v", r.File)
+	}
+	f := r.File[1]
+	if !f.IsEncrypted() {
+		t.Fatal("file in zip is not encrypted")
+	}
+	f.SetPassword("secret")
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if data, err := io.ReadAll(rc); err != nil || string(data) != "hello" {
+		t.Errorf("read %q, %v from zip, want %q", data, err, "hello")
+	}
+}
+
+func TestTarGzArchiveWriter(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	writeTestArchive(t, &tarGzArchiveWriter{Writer: tar.NewWriter(gw), gw: gw})
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Typeflag == tar.TypeReg {
+			if data, err := io.ReadAll(tr); err != nil || string(data) != "hello" {
+				t.Errorf("read %q, %v from tar, want %q", data, err, "hello")
+			}
+		}
+	}
+	if len(names) != 2 || names[0] != "dir/" || names[1] != "dir/文件.txt" {
+		t.Errorf("unexpected files in tar: %v", names)
+	}
+}
+
+func TestSevenZipArchiveWriter(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.7z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	aw := &sevenZipArchiveWriter{w: f}
+	writeTestArchive(t, aw)
+	r, err := sevenzip.OpenReader(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != 2 || !r.File[0].FileInfo().IsDir() || r.File[0].Name != "dir/" || r.File[1].Name != "dir/文件.txt" {
+		t.Fatalf("unexpected files in 7z: %v", r.File)
+	}
+	rc, err := r.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if data, err := io.ReadAll(rc); err != nil || string(data) != "hello" {
+		t.Errorf("read %q, %v from 7z, want %q", data, err, "hello")
+	}
+}
+
+func TestSevenZipArchiveWriterStreams(t *testing.T) {
+	files := []struct {
+		name, content string
+	}{
+		{"a.txt", "first"},
+		{"empty.txt", ""},
+		{"b.bin", string(bytes.Repeat([]byte{0, 1, 2}, 100000))},
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.7z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	aw := &sevenZipArchiveWriter{w: f}
+	for _, file := range files {
+		w, err := aw.create(archiveEntry{name: file.name, obj: &model.Object{Size: int64(len(file.content)), Modified: time.Now()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = io.WriteString(w, file.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := sevenzip.OpenReader(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != len(files) {
+		t.Fatalf("unexpected files in 7z: %v", r.File)
+	}
+	for i, file := range files {
+		rc, err := r.File[i].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if r.File[i].Name != file.name || err != nil || string(data) != file.content {
+			t.Errorf("read %s of %d bytes, %v from 7z, want %s of %d bytes", r.File[i].Name, len(data), err, file.name, len(file.content))
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// partialHashSize is the size of the head and of the tail hashed to compare files partially
+const partialHashSize = 64 * 1024
+
+// DedupeTask finds the files with the same content under the paths, the result is kept in Report
+type DedupeTask struct {
+	task.TaskExtension
+	Status string `json:"-"`
+	model.DedupeArgs
+	Report []model.DuplicateGroup `json:"report"`
+}
+
+func (t *DedupeTask) GetName() string {
+	return fmt.Sprintf("find duplicates in %s", strings.Join(t.Paths, ", "))
+}
+
+func (t *DedupeTask) GetStatus() string {
+	return t.Status
+}
+
+type dedupeFile struct {
+	path string
+	obj  model.Obj
+}
+
+func (t *DedupeTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Status = "walking"
+	bySize, err := t.walk()
+	if err != nil {
+		return err
+	}
+	// only the files sharing their size with others can be duplicates
+	var candidates [][]dedupeFile
+	var total, done int64
+	for size, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files)
+			total += size * int64(len(files))
+		}
+	}
+	t.SetTotalBytes(total)
+	var report []model.DuplicateGroup
+	for i, files := range candidates {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		size := files[0].obj.GetSize()
+		t.Status = fmt.Sprintf("comparing %d files of %d bytes (%d/%d)", len(files), size, i+1, len(candidates))
+		report = append(report, t.confirm(files)...)
+		done += size * int64(len(files))
+		t.SetProgress(float64(done) * 100 / float64(total))
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Wasted() != report[j].Wasted() {
+			return report[i].Wasted() > report[j].Wasted()
+		}
+		return report[i].Files[0].Path < report[j].Files[0].Path
+	})
+	t.Report = report
+	t.SetProgress(100)
+	t.Status = fmt.Sprintf("%d groups of duplicates found", len(report))
+	return nil
+}
+
+// walk returns the files under the paths by their size, a file under several of the paths is only counted once
+func (t *DedupeTask) walk() (map[int64][]dedupeFile, error) {
+	minSize := max(t.MinSize, 1)
+	seen := make(map[string]struct{})
+	bySize := make(map[int64][]dedupeFile)
+	for _, p := range t.Paths {
+		obj, err := get(t.Ctx(), p, &GetArgs{NoLog: true})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get [%s]", p)
+		}
+		err = WalkFS(t.Ctx(), -1, p, obj, func(reqPath string, info model.Obj) error {
+			if info.IsDir() || info.GetSize() < minSize {
+				return t.Ctx().Err()
+			}
+			if _, ok := seen[reqPath]; !ok {
+				seen[reqPath] = struct{}{}
+				bySize[info.GetSize()] = append(bySize[info.GetSize()], dedupeFile{path: reqPath, obj: info})
+			}
+			return t.Ctx().Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bySize, nil
+}
+
+// confirm groups the files of the same size by their content, the files failed to be read are left out
+func (t *DedupeTask) confirm(files []dedupeFile) []model.DuplicateGroup {
+	ht := commonHashType(files)
+	byHash := make(map[string][]model.DuplicateFile)
+	var hashes []string
+	for _, f := range files {
+		var hash string
+		var err error
+		switch {
+		case ht != nil:
+			hash = ht.Name + ":" + strings.ToLower(f.obj.GetHash().GetHash(ht))
+		case t.PartialHash:
+			hash, err = partialHash(t.Ctx(), f.path, f.obj.GetSize())
+			hash = model.PartialHashPrefix + hash
+		default:
+			hash = strings.ToLower(f.obj.GetHash().GetHash(utils.MD5))
+			if hash == "" {
+				hash, err = fullHash(t.Ctx(), f.path)
+			}
+			hash = utils.MD5.Name + ":" + hash
+		}
+		if err != nil {
+			log.Warnf("failed hash %s, skip it: %+v", f.path, err)
+			continue
+		}
+		if _, ok := byHash[hash]; !ok {
+			hashes = append(hashes, hash)
+		}
+		byHash[hash] = append(byHash[hash], model.DuplicateFile{Path: f.path, Modified: f.obj.ModTime()})
+	}
+	var groups []model.DuplicateGroup
+	for _, hash := range hashes {
+		if len(byHash[hash]) > 1 {
+			groups = append(groups, model.DuplicateGroup{Size: files[0].obj.GetSize(), Hash: hash, Files: byHash[hash]})
+		}
+	}
+	return groups
+}
+
+// commonHashType returns a type of hash all the files are given by their storages, if any
+func commonHashType(files []dedupeFile) *utils.HashType {
+	for ht, hash := range files[0].obj.GetHash().All() {
+		if hash == "" {
+			continue
+		}
+		common := true
+		for _, f := range files[1:] {
+			if f.obj.GetHash().GetHash(ht) == "" {
+				common = false
+				break
+			}
+		}
+		if common {
+			return ht
+		}
+	}
+	return nil
+}
+
+// fullHash downloads the whole file to compute its md5
+func fullHash(ctx context.Context, path string) (string, error) {
+	ss, err := openFile(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer ss.Close()
+	_, hash, err := stream.CacheFullAndHash(ss, nil, utils.MD5)
+	return hash, err
+}
+
+// partialHash computes the sha1 of the head and the tail of the file
+func partialHash(ctx context.Context, path string, size int64) (string, error) {
+	ss, err := openFile(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer ss.Close()
+	h := utils.SHA1.NewFunc()
+	ranges := []http_range.Range{{Length: min(size, partialHashSize)}}
+	if size > partialHashSize {
+		start := max(size-partialHashSize, partialHashSize)
+		ranges = append(ranges, http_range.Range{Start: start, Length: size - start})
+	}
+	for _, r := range ranges {
+		rd, err := ss.RangeRead(r)
+		if err != nil {
+			return "", err
+		}
+		if _, err = utils.CopyWithBuffer(h, io.LimitReader(rd, r.Length)); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func dedupe(ctx context.Context, args model.DedupeArgs) (task.TaskExtensionInfo, error) {
+	t := &DedupeTask{DedupeArgs: args}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		t.Base.SetCtx(ctx)
+		return nil, t.Run()
+	}
+	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	t.ApiUrl = common.GetApiUrl(ctx)
+	DedupeTaskManager.Add(t)
+	return t, nil
+}
+
+// ResolvableGroup returns the group of the report the files of paths can be resolved with the kept one in,
+// the files only compared partially are never resolved as they may still differ
+func ResolvableGroup(report []model.DuplicateGroup, keep string, paths []string) (*model.DuplicateGroup, error) {
+	var group *model.DuplicateGroup
+	for i := range report {
+		if report[i].Contains(keep) {
+			group = &report[i]
+			break
+		}
+	}
+	if group == nil {
+		return nil, errors.New("the kept file is not a duplicate")
+	}
+	if group.Partial() {
+		return nil, errors.New("the files are only compared partially, find the duplicates again without partial hash to resolve them")
+	}
+	for _, p := range paths {
+		if p == keep || !group.Contains(p) {
+			return nil, errors.Errorf("%s is not a duplicate of %s", p, keep)
+		}
+	}
+	return group, nil
+}
+
+// replaceWith overwrites the file of dstPath with the one of srcPath
+func replaceWith(ctx context.Context, srcPath, dstPath string) error {
+	ss, err := openFile(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	ss.Obj = &model.ObjWrapName{Name: stdpath.Base(dstPath), Obj: ss.Obj}
+	return putDirectly(ctx, stdpath.Dir(dstPath), ss)
+}
+
+var DedupeTaskManager *tache.Manager[*DedupeTask]
@@ -0,0 +1,67 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestDedupeConfirm(t *testing.T) {
+	file := func(path string, ht *utils.HashType, hash string) dedupeFile {
+		return dedupeFile{path: path, obj: &model.Object{Size: 10, HashInfo: utils.NewHashInfo(ht, hash)}}
+	}
+	tests := []struct {
+		files []dedupeFile
+		want  [][]string
+	}{
+		// the common hash given by the storages
+		{[]dedupeFile{file("/a", utils.SHA1, "AA"), file("/b", utils.SHA1, "aa"), file("/c", utils.SHA1, "bb")}, [][]string{{"/a", "/b"}}},
+		// no common hash, the md5 of the storages is used, /b has none and can not be read so it is left out
+		{[]dedupeFile{file("/a", utils.MD5, "aa"), file("/b", utils.SHA1, "aa"), file("/c", utils.MD5, "aa")}, [][]string{{"/a", "/c"}}},
+		{[]dedupeFile{file("/a", utils.MD5, "aa"), file("/b", utils.MD5, "bb")}, nil},
+	}
+	task := &DedupeTask{}
+	for _, tt := range tests {
+		groups := task.confirm(tt.files)
+		if len(groups) != len(tt.want) {
+			t.Errorf("confirm() = %+v, want %v", groups, tt.want)
+			continue
+		}
+		for i, g := range groups {
+			if len(g.Files) != len(tt.want[i]) {
+				t.Errorf("confirm() = %+v, want %v", groups, tt.want)
+				continue
+			}
+			for j, f := range g.Files {
+				if f.Path != tt.want[i][j] {
+					t.Errorf("confirm() = %+v, want %v", groups, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func TestResolvableGroup(t *testing.T) {
+	report := []model.DuplicateGroup{
+		{Hash: "md5:aa", Files: []model.DuplicateFile{{Path: "/a"}, {Path: "/b"}}},
+		{Hash: model.PartialHashPrefix + "bb", Files: []model.DuplicateFile{{Path: "/c"}, {Path: "/d"}}},
+	}
+	if _, err := ResolvableGroup(report, "/a", []string{"/b"}); err != nil {
+		t.Errorf("resolving a confirmed group: %v", err)
+	}
+	for _, tt := range []struct {
+		keep  string
+		paths []string
+	}{
+		{"/x", []string{"/a"}},
+		{"/a", []string{"/a"}},
+		{"/a", []string{"/c"}},
+		// only compared partially
+		{"/c", []string{"/d"}},
+	} {
+		if _, err := ResolvableGroup(report, tt.keep, tt.paths); err == nil {
+			t.Errorf("ResolvableGroup(%s, %v) = nil, want an error", tt.keep, tt.paths)
+		}
+	}
+}
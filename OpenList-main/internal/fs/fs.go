@@ -3,9 +3,12 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -25,6 +28,9 @@ type ListArgs struct {
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, err
+	}
 	res, err := list(ctx, path, args)
 	if err != nil {
 		if !args.NoLog {
@@ -41,6 +47,10 @@ type GetArgs struct {
 }
 
 func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
+	err := checkACL(ctx, model.ACLRead, path)
+	if err != nil {
+		return nil, err
+	}
 	res, err := get(ctx, path, args)
 	if err != nil {
 		if !args.NoLog {
@@ -52,6 +62,9 @@ func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 }
 
 func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, nil, err
+	}
 	res, file, err := link(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
@@ -61,58 +74,135 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 }
 
 func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
-	err := makeDir(ctx, path, lazyCache...)
+	err := checkACL(ctx, model.ACLWrite, path)
+	if err == nil {
+		err = makeDir(ctx, path, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit.Record(ctx, model.AuditMkdir, path, "", err)
 	return err
 }
 
 func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	req, err := transfer(ctx, move, srcPath, dstDirPath, lazyCache...)
+	var req task.TaskExtensionInfo
+	err := checkACL(ctx, model.ACLDelete, srcPath)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, dstDirPath)
+	}
+	if err == nil {
+		req, err = transfer(ctx, move, srcPath, dstDirPath, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	audit.Record(ctx, model.AuditMove, srcPath, dstDirPath, err)
 	return req, err
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	res, err := transfer(ctx, copy, srcObjPath, dstDirPath, lazyCache...)
+	var res task.TaskExtensionInfo
+	err := checkACL(ctx, model.ACLRead, srcObjPath)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, dstDirPath)
+	}
+	if err == nil {
+		res, err = transfer(ctx, copy, srcObjPath, dstDirPath, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Record(ctx, model.AuditCopy, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
+// Sync makes dstDirPath a copy of srcDirPath in a task, only the changed files are copied
+func Sync(ctx context.Context, srcDirPath, dstDirPath string, args model.SyncArgs) (task.TaskExtensionInfo, error) {
+	err := checkSyncACL(ctx, srcDirPath, dstDirPath, args)
+	var t task.TaskExtensionInfo
+	if err == nil {
+		t, err = syncDirs(ctx, srcDirPath, dstDirPath, args)
+	}
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcDirPath, dstDirPath, err)
+	}
+	return t, err
+}
+
+// SyncPlan returns what Sync would do without changing anything
+func SyncPlan(ctx context.Context, srcDirPath, dstDirPath string, args model.SyncArgs) ([]model.SyncAction, error) {
+	err := checkACL(ctx, model.ACLRead, srcDirPath, dstDirPath)
+	var plan []model.SyncAction
+	if err == nil {
+		plan, err = syncPlan(ctx, srcDirPath, dstDirPath, args)
+	}
+	if err != nil {
+		log.Errorf("failed plan sync %s to %s: %+v", srcDirPath, dstDirPath, err)
+	}
+	return plan, err
+}
+
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
-	err := rename(ctx, srcPath, dstName, lazyCache...)
+	err := checkACL(ctx, model.ACLWrite, srcPath)
+	if err == nil {
+		err = rename(ctx, srcPath, dstName, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	audit.Record(ctx, model.AuditRename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), err)
 	return err
 }
 
 func Remove(ctx context.Context, path string) error {
-	err := remove(ctx, path)
+	err := checkACL(ctx, model.ACLDelete, path)
+	if err == nil {
+		err = remove(ctx, path)
+	}
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
+	audit.Record(ctx, model.AuditRemove, path, "", err)
+	return err
+}
+
+func RemoveEmptyDirectory(ctx context.Context, srcDir string) error {
+	err := checkACL(ctx, model.ACLRead, srcDir)
+	if err == nil {
+		err = checkACL(ctx, model.ACLDelete, srcDir)
+	}
+	if err == nil {
+		err = removeEmptyDirectory(ctx, srcDir)
+	}
+	if err != nil {
+		log.Errorf("failed remove empty directories of %s: %+v", srcDir, err)
+	}
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
-	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
+	err := checkACL(ctx, model.ACLWrite, stdpath.Join(dstDirPath, file.GetName()))
+	if err == nil {
+		err = putDirectly(ctx, dstDirPath, file, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, model.AuditUpload, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return err
 }
 
 func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
-	t, err := putAsTask(ctx, dstDirPath, file)
+	var t task.TaskExtensionInfo
+	err := checkACL(ctx, model.ACLWrite, stdpath.Join(dstDirPath, file.GetName()))
+	if err == nil {
+		t, err = putAsTask(ctx, dstDirPath, file)
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, model.AuditUpload, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return t, err
 }
 
@@ -133,13 +223,69 @@ func ArchiveList(ctx context.Context, path string, args model.ArchiveListArgs) (
 }
 
 func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args model.ArchiveDecompressArgs, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	t, err := archiveDecompress(ctx, srcObjPath, dstDirPath, args, lazyCache...)
+	err := checkACL(ctx, model.ACLRead, srcObjPath)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, dstDirPath)
+	}
+	var t task.TaskExtensionInfo
+	if err == nil {
+		t, err = archiveDecompress(ctx, srcObjPath, dstDirPath, args, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
 	}
 	return t, err
 }
 
+// ArchiveCompress creates an archive of the named objects of srcDirPath in dstDirPath
+func ArchiveCompress(ctx context.Context, srcDirPath, dstDirPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	paths := make([]string, 0, len(args.Names))
+	for _, name := range args.Names {
+		paths = append(paths, stdpath.Join(srcDirPath, name))
+	}
+	err := checkACL(ctx, model.ACLRead, paths...)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, stdpath.Join(dstDirPath, args.ArchiveName))
+	}
+	var t task.TaskExtensionInfo
+	if err == nil {
+		t, err = archiveCompress(ctx, srcDirPath, dstDirPath, args)
+	}
+	if err != nil {
+		log.Errorf("failed compress %s to %s: %+v", srcDirPath, dstDirPath, err)
+	}
+	return t, err
+}
+
+// Dedupe finds the files with the same content under the paths
+func Dedupe(ctx context.Context, args model.DedupeArgs) (task.TaskExtensionInfo, error) {
+	err := checkACL(ctx, model.ACLRead, args.Paths...)
+	var t task.TaskExtensionInfo
+	if err == nil {
+		t, err = dedupe(ctx, args)
+	}
+	if err != nil {
+		log.Errorf("failed find duplicates in %v: %+v", args.Paths, err)
+	}
+	return t, err
+}
+
+// ReplaceWith overwrites the file of dstPath with the one of srcPath
+func ReplaceWith(ctx context.Context, srcPath, dstPath string) error {
+	err := checkACL(ctx, model.ACLRead, srcPath)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, dstPath)
+	}
+	if err == nil {
+		err = replaceWith(ctx, srcPath, dstPath)
+	}
+	if err != nil {
+		log.Errorf("failed replace %s with %s: %+v", dstPath, srcPath, err)
+	}
+	audit.Record(ctx, model.AuditUpload, dstPath, "", err)
+	return err
+}
+
 func ArchiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
 	l, obj, err := archiveDriverExtract(ctx, path, args)
 	if err != nil {
@@ -184,6 +330,9 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
+	if err := checkACL(ctx, model.ACLWrite, stdpath.Join(path, dstName)); err != nil {
+		return err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -206,3 +355,30 @@ func GetDirectUploadInfo(ctx context.Context, tool, path, dstName string, fileSi
 	}
 	return info, err
 }
+
+// checkSyncACL checks the actions the sync may do on both sides
+func checkSyncACL(ctx context.Context, srcDirPath, dstDirPath string, args model.SyncArgs) error {
+	err := checkACL(ctx, model.ACLRead, srcDirPath, dstDirPath)
+	if err == nil {
+		err = checkACL(ctx, model.ACLWrite, dstDirPath)
+	}
+	if err == nil && args.TwoWay {
+		err = checkACL(ctx, model.ACLWrite, srcDirPath)
+	}
+	if err == nil && args.Delete {
+		err = checkACL(ctx, model.ACLDelete, dstDirPath)
+	}
+	return err
+}
+
+// checkACL fails if the access rules of a meta deny the action on any of the paths
+// to the user of ctx
+func checkACL(ctx context.Context, action string, paths ...string) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	for _, p := range paths {
+		if allowed, decided := op.CheckACL(user, p, action); decided && !allowed {
+			return errors.WithStack(errs.PermissionDenied)
+		}
+	}
+	return nil
+}
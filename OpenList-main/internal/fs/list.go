@@ -2,6 +2,8 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
+	"slices"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -16,6 +18,12 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
 	user, _ := ctx.Value(conf.UserKey).(*model.User)
 	virtualFiles := op.GetStorageVirtualFilesWithDetailsByPath(ctx, path, !args.WithStorageDetails, args.Refresh)
+	if user != nil {
+		// hide the storages out of the groups of the user
+		virtualFiles = slices.DeleteFunc(virtualFiles, func(obj model.Obj) bool {
+			return !user.CanAccessStorageOf(stdpath.Join(path, obj.GetName()))
+		})
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil && len(virtualFiles) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
@@ -43,6 +51,18 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		om.InitHideReg(meta.Hide)
 	}
 	objs := om.Merge(_objs, virtualFiles...)
+	// the recycle bin and the versions are managed by their own api
+	versioning := storage != nil && storage.GetStorage().EnableVersions
+	objs = slices.DeleteFunc(objs, func(obj model.Obj) bool {
+		return model.IsTrashName(obj.GetName()) || (versioning && obj.GetName() == model.VersionsDirName)
+	})
+	if user != nil && !user.IsAdmin() {
+		// drop the children denied by the access rules of their metas
+		objs = slices.DeleteFunc(objs, func(obj model.Obj) bool {
+			allowed, decided := op.CheckACL(user, stdpath.Join(path, obj.GetName()), model.ACLRead)
+			return decided && !allowed
+		})
+	}
 	return objs, nil
 }
 
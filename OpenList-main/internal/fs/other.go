@@ -2,11 +2,13 @@ package fs
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic"
 	"github.com/pkg/errors"
 )
 
@@ -34,6 +36,77 @@ func remove(ctx context.Context, path string) error {
 	return op.Remove(ctx, storage, actualPath)
 }
 
+// removeEmptyDirectory removes the empty sub directories of srcDir, including the ones
+// that only become empty once their empty children are removed
+func removeEmptyDirectory(ctx context.Context, srcDir string) error {
+	rootFiles, err := list(ctx, srcDir, &ListArgs{})
+	if err != nil {
+		return err
+	}
+
+	// record the file path
+	filePathMap := make(map[model.Obj]string)
+	// record the parent file
+	fileParentMap := make(map[model.Obj]model.Obj)
+	// removing files
+	removingFiles := generic.NewQueue[model.Obj]()
+	// removed files
+	removedFiles := make(map[string]bool)
+	for _, file := range rootFiles {
+		if !file.IsDir() {
+			continue
+		}
+		removingFiles.Push(file)
+		filePathMap[file] = srcDir
+	}
+
+	for !removingFiles.IsEmpty() {
+
+		removingFile := removingFiles.Pop()
+		removingFilePath := fmt.Sprintf("%s/%s", filePathMap[removingFile], removingFile.GetName())
+
+		if removedFiles[removingFilePath] {
+			continue
+		}
+		// the folders the user can't list or remove are kept, and so are their parents
+		if checkACL(ctx, model.ACLRead, removingFilePath) != nil || checkACL(ctx, model.ACLDelete, removingFilePath) != nil {
+			continue
+		}
+
+		subFiles, err := list(ctx, removingFilePath, &ListArgs{Refresh: true})
+		if err != nil {
+			return err
+		}
+
+		if len(subFiles) == 0 {
+			// remove empty directory
+			err = remove(ctx, removingFilePath)
+			removedFiles[removingFilePath] = true
+			if err != nil {
+				return err
+			}
+			// recheck parent folder
+			parentFile, exist := fileParentMap[removingFile]
+			if exist {
+				removingFiles.Push(parentFile)
+			}
+
+		} else {
+			// recursive remove
+			for _, subFile := range subFiles {
+				if !subFile.IsDir() {
+					continue
+				}
+				removingFiles.Push(subFile)
+				filePathMap[subFile] = removingFilePath
+				fileParentMap[subFile] = removingFile
+			}
+		}
+
+	}
+	return nil
+}
+
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	storage, actualPath, err := op.GetStorageAndActualPath(args.Path)
 	if err != nil {
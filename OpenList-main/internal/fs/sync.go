@@ -0,0 +1,320 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+)
+
+// modTimeWindow is the precision of the modified time, as many storages truncate it to seconds
+const modTimeWindow = 2 * time.Second
+
+// SyncTask makes the destination directory a copy of the source directory, only the changed files are copied
+type SyncTask struct {
+	TaskData
+	model.SyncArgs
+	Plan []model.SyncAction `json:"-"`
+}
+
+func (t *SyncTask) GetName() string {
+	mode := "one-way"
+	if t.TwoWay {
+		mode = "two-way"
+	}
+	return fmt.Sprintf("sync (%s) [%s](%s) to [%s](%s)", mode, t.SrcStorageMp, t.SrcActualPath, t.DstStorageMp, t.DstActualPath)
+}
+
+func (t *SyncTask) srcPath() string {
+	return stdpath.Join(t.SrcStorageMp, t.SrcActualPath)
+}
+
+func (t *SyncTask) dstPath() string {
+	return stdpath.Join(t.DstStorageMp, t.DstActualPath)
+}
+
+func (t *SyncTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Status = "comparing"
+	plan, err := syncPlan(t.Ctx(), t.srcPath(), t.dstPath(), t.SyncArgs)
+	if err != nil {
+		return err
+	}
+	t.Plan = plan
+	var total, done int64
+	for _, a := range plan {
+		if a.Action == model.SyncCopy {
+			total += a.Size
+		}
+	}
+	t.SetTotalBytes(total)
+	var conflicts int
+	for i, a := range plan {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		t.Status = fmt.Sprintf("%s %s (%d/%d)", a.Action, a.Path, i+1, len(plan))
+		from, to := t.srcPath(), t.dstPath()
+		if a.Reverse {
+			from, to = to, from
+		}
+		switch a.Action {
+		case model.SyncMkdir:
+			err = makeDir(t.Ctx(), stdpath.Join(to, a.Path), true)
+		case model.SyncDelete:
+			err = remove(t.Ctx(), stdpath.Join(to, a.Path))
+		case model.SyncCopy:
+			err = syncFile(t.Ctx(), stdpath.Join(from, a.Path), stdpath.Dir(stdpath.Join(to, a.Path)), func(p float64) {
+				if total > 0 {
+					t.SetProgress((float64(done) + p*float64(a.Size)/100) * 100 / float64(total))
+				}
+			})
+			done += a.Size
+		case model.SyncConflict:
+			conflicts++
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed %s [%s]", a.Action, a.Path)
+		}
+		if total > 0 {
+			t.SetProgress(float64(done) * 100 / float64(total))
+		}
+	}
+	t.Status = fmt.Sprintf("synced, %d actions, %d conflicts skipped", len(plan)-conflicts, conflicts)
+	return nil
+}
+
+// syncFile copies a single file to dstDirPath, overwriting the existing one
+func syncFile(ctx context.Context, srcPath, dstDirPath string, up model.UpdateProgress) error {
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	ss, err := openFile(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	return op.Put(ctx, dstStorage, dstDirActualPath, ss, up, true)
+}
+
+// openFile returns a stream of the file at the mount path, the caller closes it
+func openFile(ctx context.Context, path string) (*stream.SeekableStream, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] file", path)
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return nil, errors.WithMessagef(err, "failed get [%s] stream", path)
+	}
+	return ss, nil
+}
+
+// walkTree returns the objects under root by their path relative to root, a missing root is empty
+func walkTree(ctx context.Context, root string) (map[string]model.Obj, error) {
+	tree := make(map[string]model.Obj)
+	rootObj, err := get(ctx, root, &GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return tree, nil
+		}
+		return nil, err
+	}
+	if !rootObj.IsDir() {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	prefix := strings.TrimSuffix(root, "/")
+	err = WalkFS(ctx, -1, root, rootObj, func(reqPath string, info model.Obj) error {
+		if reqPath != root {
+			tree[strings.TrimPrefix(reqPath, prefix)] = info
+		}
+		return ctx.Err()
+	})
+	return tree, err
+}
+
+// syncPlan compares the trees of srcPath and dstPath
+func syncPlan(ctx context.Context, srcPath, dstPath string, args model.SyncArgs) ([]model.SyncAction, error) {
+	src, err := walkTree(ctx, srcPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed walk src [%s]", srcPath)
+	}
+	dst, err := walkTree(ctx, dstPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed walk dst [%s]", dstPath)
+	}
+	plan := planSync(src, dst, args)
+	// WalkFS skips the directories it fails to list, make sure the parents of the
+	// deleted objects were really listed rather than missing from the source
+	checked := make(map[string]struct{})
+	for _, a := range plan {
+		if a.Action != model.SyncDelete {
+			continue
+		}
+		parent := stdpath.Join(srcPath, stdpath.Dir(a.Path))
+		if _, ok := checked[parent]; ok {
+			continue
+		}
+		checked[parent] = struct{}{}
+		if _, err = list(ctx, parent, &ListArgs{NoLog: true}); err != nil && !errs.IsObjectNotFound(err) {
+			return nil, errors.WithMessagef(err, "failed list src [%s]", parent)
+		}
+	}
+	return plan, nil
+}
+
+// fileChanged tells if src and dst are different files, and whether src is the newer one.
+// The hashes decide when both sides have one of the same type, then the sizes do.
+// Files of the same size are only considered changed if src is newer, as uploading
+// usually resets the modified time of dst, this way the files copied by the previous
+// sync are not copied back in two-way sync.
+func fileChanged(src, dst model.Obj) (changed bool, srcNewer bool) {
+	diff := src.ModTime().Sub(dst.ModTime())
+	srcNewer = diff >= 0
+	dstHash := dst.GetHash()
+	for ht, h := range src.GetHash().All() {
+		if d := dstHash.GetHash(ht); h != "" && d != "" {
+			return !strings.EqualFold(h, d), srcNewer
+		}
+	}
+	if src.GetSize() != dst.GetSize() {
+		return true, srcNewer
+	}
+	return diff > modTimeWindow, srcNewer
+}
+
+func underAny(p string, dirs map[string]struct{}) bool {
+	for d := stdpath.Dir(p); d != "/" && d != "."; d = stdpath.Dir(d) {
+		if _, ok := dirs[d]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// planSync returns the actions making dst match src, sorted so that a directory is made before its content
+func planSync(src, dst map[string]model.Obj, args model.SyncArgs) []model.SyncAction {
+	paths := make([]string, 0, len(src)+len(dst))
+	for p := range src {
+		paths = append(paths, p)
+	}
+	for p := range dst {
+		if _, ok := src[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	// the content of deleted and conflicting directories is skipped
+	skipped := make(map[string]struct{})
+	var plan []model.SyncAction
+	for _, p := range paths {
+		if underAny(p, skipped) {
+			continue
+		}
+		s, inSrc := src[p]
+		d, inDst := dst[p]
+		switch {
+		case inSrc && inDst:
+			if s.IsDir() != d.IsDir() {
+				plan = append(plan, model.SyncAction{Action: model.SyncConflict, Path: p, Reason: "file and directory of the same name"})
+				skipped[p] = struct{}{}
+				continue
+			}
+			if s.IsDir() {
+				continue
+			}
+			changed, srcNewer := fileChanged(s, d)
+			if !changed {
+				continue
+			}
+			if args.TwoWay && !srcNewer {
+				plan = append(plan, model.SyncAction{Action: model.SyncCopy, Path: p, Reverse: true, Size: d.GetSize(), Reason: "newer in destination"})
+			} else {
+				plan = append(plan, model.SyncAction{Action: model.SyncCopy, Path: p, Size: s.GetSize(), Reason: "changed"})
+			}
+		case inSrc:
+			if s.IsDir() {
+				plan = append(plan, model.SyncAction{Action: model.SyncMkdir, Path: p, Reason: "missing in destination"})
+			} else {
+				plan = append(plan, model.SyncAction{Action: model.SyncCopy, Path: p, Size: s.GetSize(), Reason: "missing in destination"})
+			}
+		case args.TwoWay:
+			if d.IsDir() {
+				plan = append(plan, model.SyncAction{Action: model.SyncMkdir, Path: p, Reverse: true, Reason: "missing in source"})
+			} else {
+				plan = append(plan, model.SyncAction{Action: model.SyncCopy, Path: p, Reverse: true, Size: d.GetSize(), Reason: "missing in source"})
+			}
+		case args.Delete:
+			plan = append(plan, model.SyncAction{Action: model.SyncDelete, Path: p, Size: d.GetSize(), Reason: "missing in source"})
+			if d.IsDir() {
+				skipped[p] = struct{}{}
+			}
+		}
+	}
+	return plan
+}
+
+func syncDirs(ctx context.Context, srcDirPath, dstDirPath string, args model.SyncArgs) (task.TaskExtensionInfo, error) {
+	if args.TwoWay && args.Delete {
+		return nil, errors.New("delete is not supported in two-way sync")
+	}
+	srcStorage, srcDirActualPath, err := op.GetStorageAndActualPath(srcDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	t := &SyncTask{
+		TaskData: TaskData{
+			SrcStorage:    srcStorage,
+			DstStorage:    dstStorage,
+			SrcActualPath: srcDirActualPath,
+			DstActualPath: dstDirActualPath,
+			SrcStorageMp:  srcStorage.GetStorage().MountPath,
+			DstStorageMp:  dstStorage.GetStorage().MountPath,
+		},
+		SyncArgs: args,
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		t.Base.SetCtx(ctx)
+		return nil, t.Run()
+	}
+	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	t.ApiUrl = common.GetApiUrl(ctx)
+	SyncTaskManager.Add(t)
+	return t, nil
+}
+
+var SyncTaskManager *tache.Manager[*SyncTask]
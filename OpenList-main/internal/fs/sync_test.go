@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestPlanSync(t *testing.T) {
+	now := time.Now()
+	file := func(size int64, modified time.Time, md5 string) model.Obj {
+		return &model.Object{Size: size, Modified: modified, HashInfo: utils.NewHashInfo(utils.MD5, md5)}
+	}
+	dir := &model.Object{IsFolder: true}
+	src := map[string]model.Obj{
+		"/same":         file(1, now, ""),
+		"/resized":      file(2, now, ""),
+		"/hash":         file(3, now.Add(-time.Hour), "aa"),
+		"/older":        file(4, now.Add(-time.Hour), ""),
+		"/new":          file(5, now, ""),
+		"/dir":          dir,
+		"/dir/a":        file(6, now, ""),
+		"/conflict":     dir,
+		"/conflict/a":   file(7, now, ""),
+		"/newer_in_src": file(8, now.Add(time.Hour), ""),
+	}
+	dst := map[string]model.Obj{
+		"/same":         file(1, now, ""),
+		"/resized":      file(1, now, ""),
+		"/hash":         file(3, now, "bb"),
+		"/older":        file(4, now, ""),
+		"/conflict":     file(7, now, ""),
+		"/newer_in_src": file(8, now, ""),
+		"/extra":        dir,
+		"/extra/a":      file(9, now, ""),
+	}
+	format := func(plan []model.SyncAction) []string {
+		var res []string
+		for _, a := range plan {
+			s := a.Action + " " + a.Path
+			if a.Reverse {
+				s += " reverse"
+			}
+			res = append(res, s)
+		}
+		return res
+	}
+	tests := []struct {
+		args model.SyncArgs
+		want []string
+	}{
+		{model.SyncArgs{}, []string{"conflict /conflict", "mkdir /dir", "copy /dir/a", "copy /hash", "copy /new", "copy /newer_in_src", "copy /resized"}},
+		{model.SyncArgs{Delete: true}, []string{"conflict /conflict", "mkdir /dir", "copy /dir/a", "delete /extra", "copy /hash", "copy /new", "copy /newer_in_src", "copy /resized"}},
+		{model.SyncArgs{TwoWay: true}, []string{"conflict /conflict", "mkdir /dir", "copy /dir/a", "mkdir /extra reverse", "copy /extra/a reverse",
+			"copy /hash reverse", "copy /new", "copy /newer_in_src", "copy /resized"}},
+	}
+	for _, tt := range tests {
+		got := format(planSync(src, dst, tt.args))
+		if len(got) != len(tt.want) {
+			t.Errorf("planSync(%+v) = %v, want %v", tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("planSync(%+v) = %v, want %v", tt.args, got, tt.want)
+				break
+			}
+		}
+	}
+}
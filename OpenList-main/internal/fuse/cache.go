@@ -0,0 +1,77 @@
+package fuse
+
+import (
+	"container/list"
+	"sync"
+)
+
+// blockKey identifies a block of a version of a file, so that modified files never hit stale blocks
+type blockKey struct {
+	path     string
+	modified int64
+	size     int64
+	index    int64
+}
+
+type blockEntry struct {
+	key  blockKey
+	data []byte
+}
+
+// blockCache is a LRU cache of the blocks read from the storages, bounded by the count of blocks
+type blockCache struct {
+	mu       sync.Mutex
+	capacity int
+	lru      *list.List
+	blocks   map[blockKey]*list.Element
+}
+
+func newBlockCache(capacity int) *blockCache {
+	return &blockCache{
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   make(map[blockKey]*list.Element),
+	}
+}
+
+func (c *blockCache) get(key blockKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.blocks[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*blockEntry).data, true
+}
+
+func (c *blockCache) put(key blockKey, data []byte) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.blocks[key]; ok {
+		e.Value.(*blockEntry).data = data
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.blocks[key] = c.lru.PushFront(&blockEntry{key: key, data: data})
+	for c.lru.Len() > c.capacity {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.blocks, e.Value.(*blockEntry).key)
+	}
+}
+
+// invalidate drops the blocks of the file at path
+func (c *blockCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.blocks {
+		if key.path == path {
+			c.lru.Remove(e)
+			delete(c.blocks, key)
+		}
+	}
+}
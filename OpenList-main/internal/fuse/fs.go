@@ -1,170 +1,553 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
-
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+const (
+	blockSize = 1 << 20
+	// DefaultCacheBlocks is the default capacity of the block cache, in blocks of 1MB
+	DefaultCacheBlocks = 64
+)
+
+// Fs exposes the openlist tree under RootFolder as a FUSE filesystem,
+// every operation is done on behalf of the user of ctx
 type Fs struct {
 	RootFolder string
+	ReadOnly   bool
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	ctx      context.Context
+	user     *model.User
+	uid, gid uint32
+
+	blocks *blockCache
+	blockG singleflight.Group[[]byte]
+
+	mu      sync.Mutex
+	handles map[uint64]*handle
+	nextFh  uint64
+}
+
+func NewFs(ctx context.Context, rootFolder string, readOnly bool, cacheBlocks int) *Fs {
+	return &Fs{
+		RootFolder: rootFolder,
+		ReadOnly:   readOnly,
+		ctx:        ctx,
+		user:       ctx.Value(conf.UserKey).(*model.User),
+		uid:        uint32(os.Getuid()),
+		gid:        uint32(os.Getgid()),
+		blocks:     newBlockCache(cacheBlocks),
+		handles:    make(map[uint64]*handle),
+	}
+}
+
+// errno converts the errors of internal/fs to the negated errno expected by FUSE
+func errno(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errs.ObjectNotFound), errors.Is(err, errs.StorageNotFound):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	case errors.Is(err, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(err, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(err, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errors.Is(err, errs.NotSupport), errors.Is(err, errs.NotImplement), errors.Is(err, errs.UploadNotSupported):
+		return -fuse.ENOSYS
+	}
+	log.Errorf("fuse: %+v", err)
+	return -fuse.EIO
+}
+
+func (f *Fs) path(path string) string {
+	return stdpath.Join(f.RootFolder, path)
+}
+
+func (f *Fs) can(allowed bool) int {
+	if f.ReadOnly {
+		return -fuse.EROFS
+	}
+	if !allowed {
+		return -fuse.EACCES
+	}
+	return 0
+}
+
+func (f *Fs) fillStat(obj model.Obj, stat *fuse.Stat_t) {
+	*stat = fuse.Stat_t{}
+	modified := fuse.NewTimespec(obj.ModTime())
+	stat.Mtim, stat.Ctim, stat.Atim = modified, modified, modified
+	if created := obj.CreateTime(); !created.IsZero() {
+		stat.Birthtim = fuse.NewTimespec(created)
+	} else {
+		stat.Birthtim = modified
+	}
+	stat.Uid, stat.Gid = f.uid, f.gid
+	stat.Blksize = blockSize
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+		return
+	}
+	stat.Mode = fuse.S_IFREG | 0o644
+	stat.Nlink = 1
+	stat.Size = obj.GetSize()
+	stat.Blocks = (stat.Size + 511) / 512
+}
+
+func (f *Fs) newHandle(h *handle) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = h
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) *handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+// stagedHandles returns the writable handles of the files in dir, so that files being
+// written show up before they are uploaded
+func (f *Fs) stagedHandles(dir string) map[string]*handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := make(map[string]*handle)
+	for _, h := range f.handles {
+		if h.writable() && stdpath.Dir(h.path) == dir {
+			res[stdpath.Base(h.path)] = h
+		}
+	}
+	return res
+}
+
+func (f *Fs) renameHandles(oldPath, newPath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.handles {
+		h.mu.Lock()
+		if h.path == oldPath {
+			h.path = newPath
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for fh, h := range f.handles {
+		if err := h.upload(f.ctx); err != nil {
+			log.Errorf("fuse: failed upload %s: %+v", h.path, err)
+		}
+		h.close()
+		delete(f.handles, fh)
+	}
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	// the storages have no common notion of free space, report a large filesystem
+	const blocks = 1 << 40 / blockSize
+	*stat = fuse.Statfs_t{
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Blocks:  blocks,
+		Bfree:   blocks,
+		Bavail:  blocks,
+		Files:   1 << 30,
+		Ffree:   1 << 30,
+		Favail:  1 << 30,
+		Namemax: 255,
+	}
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	if e := f.can(f.user.CanWrite()); e != 0 {
+		return e
+	}
+	return errno(fs.MakeDir(f.ctx, f.path(path)))
+}
+
+func (f *Fs) Unlink(path string) int {
+	if e := f.can(f.user.CanRemove()); e != 0 {
+		return e
+	}
+	reqPath := f.path(path)
+	f.blocks.invalidate(reqPath)
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	if e := f.can(f.user.CanRemove()); e != 0 {
+		return e
+	}
+	reqPath := f.path(path)
+	// fs.Remove is recursive, rmdir must only remove empty directories
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	srcPath, dstPath := f.path(oldpath), f.path(newpath)
+	if srcPath == dstPath {
+		return 0
+	}
+	srcDir, srcName := stdpath.Split(srcPath)
+	dstDir, dstName := stdpath.Split(dstPath)
+	sameDir := srcDir == dstDir
+	if e := f.can((sameDir || f.user.CanMove()) && (srcName == dstName || f.user.CanRename())); e != 0 {
+		return e
+	}
+	srcStorage, _, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return errno(err)
+	}
+	dstStorage, _, err := op.GetStorageAndActualPath(dstPath)
+	if err != nil {
+		return errno(err)
+	}
+	if srcStorage.GetStorage().MountPath != dstStorage.GetStorage().MountPath {
+		// let the caller fall back to copy and delete
+		return -fuse.EXDEV
+	}
+	// rename replaces the destination, which editors rely on when saving.
+	// It is moved aside first and only removed once the source has taken its place
+	replaced, e := f.setAside(dstDir, dstName)
+	if e != 0 {
+		return e
+	}
+	switch {
+	case sameDir:
+		err = fs.Rename(f.ctx, srcPath, dstName)
+	case srcName == dstName:
+		_, err = fs.Move(f.ctx, srcPath, dstDir)
+	default:
+		if err = fs.Rename(f.ctx, srcPath, dstName, true); err == nil {
+			_, err = fs.Move(f.ctx, stdpath.Join(srcDir, dstName), dstDir)
+		}
+	}
+	if err != nil {
+		if replaced != "" {
+			if e := fs.Rename(f.ctx, replaced, dstName); e != nil {
+				log.Errorf("fuse: failed restore %s from %s: %+v", dstPath, replaced, e)
+			}
+		}
+		return errno(err)
+	}
+	if replaced != "" {
+		if err = fs.Remove(f.ctx, replaced); err != nil {
+			log.Warnf("fuse: failed remove the replaced %s: %+v", replaced, err)
+		}
+		f.blocks.invalidate(dstPath)
+	}
+	f.blocks.invalidate(srcPath)
+	f.renameHandles(srcPath, dstPath)
+	return 0
+}
+
+// setAside renames the existing destination of a rename to a hidden temporary name,
+// the returned path is empty if there is no destination
+func (f *Fs) setAside(dstDir, dstName string) (string, int) {
+	dstPath := stdpath.Join(dstDir, dstName)
+	dst, err := fs.Get(f.ctx, dstPath, &fs.GetArgs{NoLog: true})
+	if errs.IsObjectNotFound(err) {
+		return "", 0
+	}
+	if err != nil {
+		return "", errno(err)
+	}
+	if e := f.can(f.user.CanRemove()); e != 0 {
+		return "", e
+	}
+	if dst.IsDir() {
+		// only an empty directory can be replaced
+		objs, err := fs.List(f.ctx, dstPath, &fs.ListArgs{NoLog: true})
+		if err != nil {
+			return "", errno(err)
+		}
+		if len(objs) > 0 {
+			return "", -fuse.ENOTEMPTY
+		}
+	}
+	tmpName := fmt.Sprintf(".%s.%d.replaced", dstName, time.Now().UnixNano())
+	if err = fs.Rename(f.ctx, dstPath, tmpName); err != nil {
+		return "", errno(err)
+	}
+	return stdpath.Join(dstDir, tmpName), 0
+}
+
+// Chmod, Chown and Utimens are accepted but ignored, the storages have no such attributes
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return f.can(true)
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return f.can(true)
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return f.can(true)
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	if e := f.can(f.user.CanWrite()); e != 0 {
+		return e, ^uint64(0)
+	}
+	h := &handle{path: f.path(path)}
+	if err := h.stage(f.ctx, true); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, f.newHandle(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	reqPath := f.path(path)
+	obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h := &handle{path: reqPath, obj: obj}
+	if flags&fuse.O_ACCMODE != fuse.O_RDONLY {
+		if e := f.can(f.user.CanWrite()); e != 0 {
+			return e, ^uint64(0)
+		}
+		if err = h.stage(f.ctx, flags&fuse.O_TRUNC != 0); err != nil {
+			return errno(err), ^uint64(0)
+		}
+	}
+	return 0, f.newHandle(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	reqPath := f.path(path)
+	h := f.getHandle(fh)
+	if h == nil {
+		h = f.stagedHandles(stdpath.Dir(reqPath))[stdpath.Base(reqPath)]
+	}
+	if h != nil && h.writable() {
+		f.fillStat(&model.Object{Name: stdpath.Base(reqPath), Size: h.size(), Modified: time.Now()}, stat)
+		return 0
+	}
+	obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	f.fillStat(obj, stat)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if e := f.can(f.user.CanWrite()); e != 0 {
+		return e
+	}
+	h := f.getHandle(fh)
+	temporary := h == nil || !h.writable()
+	if temporary {
+		// truncate(2) without an open file, stage it for the time of the call
+		reqPath := f.path(path)
+		obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return errno(err)
+		}
+		h = &handle{path: reqPath, obj: obj}
+		if err = h.stage(f.ctx, size == 0); err != nil {
+			return errno(err)
+		}
+		defer h.close()
+	}
+	h.mu.Lock()
+	err := h.tmp.Truncate(size)
+	h.dirty = true
+	h.mu.Unlock()
+	if err != nil {
+		return errno(err)
+	}
+	if temporary {
+		f.blocks.invalidate(h.path)
+		return errno(h.upload(f.ctx))
+	}
+	return 0
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	if h.writable() {
+		n, err := h.tmp.ReadAt(buff, ofst)
+		if err != nil && n == 0 && !errors.Is(err, io.EOF) {
+			return errno(err)
+		}
+		return n
+	}
+	size := h.obj.GetSize()
+	n := 0
+	for n < len(buff) && ofst+int64(n) < size {
+		pos := ofst + int64(n)
+		index := pos / blockSize
+		block, err := f.readBlock(h, index)
+		if err != nil {
+			if n > 0 {
+				return n
+			}
+			return errno(err)
+		}
+		copied := copy(buff[n:], block[pos-index*blockSize:])
+		if copied == 0 {
+			break
+		}
+		n += copied
+	}
+	return n
+}
+
+func (f *Fs) readBlock(h *handle, index int64) ([]byte, error) {
+	key := blockKey{path: h.path, modified: h.obj.ModTime().UnixNano(), size: h.obj.GetSize(), index: index}
+	if block, ok := f.blocks.get(key); ok {
+		return block, nil
+	}
+	block, err, _ := f.blockG.Do(fmt.Sprintf("%s:%d:%d:%d", key.path, key.modified, key.size, key.index), func() ([]byte, error) {
+		start := index * blockSize
+		length := min(blockSize, key.size-start)
+		buf := bytes.NewBuffer(make([]byte, 0, length))
+		if err := h.readRange(f.ctx, start, length, buf); err != nil {
+			return nil, err
+		}
+		f.blocks.put(key, buf.Bytes())
+		return buf.Bytes(), nil
+	})
+	return block, err
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil || !h.writable() {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.tmp.WriteAt(buff, ofst)
+	if n > 0 {
+		h.dirty = true
+	}
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	if err := h.upload(f.ctx); err != nil {
+		return errno(err)
+	}
+	f.blocks.invalidate(h.path)
+	return 0
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	res := 0
+	if err := h.upload(f.ctx); err != nil {
+		res = errno(err)
+	}
+	f.mu.Lock()
+	delete(f.handles, fh)
+	f.mu.Unlock()
+	h.close()
+	return res
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	obj, err := fs.Get(f.ctx, f.path(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	reqPath := f.path(path)
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	staged := f.stagedHandles(reqPath)
+	for _, obj := range objs {
+		delete(staged, obj.GetName())
+		var stat fuse.Stat_t
+		f.fillStat(obj, &stat)
+		if !fill(obj.GetName(), &stat, 0) {
+			return 0
+		}
+	}
+	for name, h := range staged {
+		var stat fuse.Stat_t
+		f.fillStat(&model.Object{Name: name, Size: h.size(), Modified: time.Now()}, &stat)
+		if !fill(name, &stat, 0) {
+			return 0
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
@@ -0,0 +1,90 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/winfsp/cgofuse/fuse"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:fuse_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestRenameReplace(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/rename",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	entries := func() int {
+		files, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(files)
+	}
+	newFs := func(permission int32) *Fs {
+		user := &model.User{Username: "fuse", BasePath: "/", Permission: permission}
+		return NewFs(context.WithValue(context.Background(), conf.UserKey, user), "/rename", false, 1)
+	}
+	canReplace := newFs(1<<3 | 1<<4 | 1<<5 | 1<<7)
+	cannotRemove := newFs(1<<3 | 1<<4 | 1<<5)
+
+	write("a.txt", "new")
+	write("b.txt", "old")
+	if e := cannotRemove.Rename("/a.txt", "/b.txt"); e != -fuse.EACCES {
+		t.Errorf("replacing without remove permission = %d, want EACCES", e)
+	}
+	if read("a.txt") != "new" || read("b.txt") != "old" {
+		t.Errorf("files are changed by a denied rename")
+	}
+	if e := canReplace.Rename("/a.txt", "/b.txt"); e != 0 {
+		t.Fatalf("replacing = %d", e)
+	}
+	if read("b.txt") != "new" || entries() != 1 {
+		t.Errorf("b.txt = %q with %d entries, want the content of a.txt only", read("b.txt"), entries())
+	}
+
+	if err = os.Mkdir(filepath.Join(root, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write("dir/c.txt", "c")
+	if e := canReplace.Rename("/b.txt", "/dir"); e != -fuse.ENOTEMPTY {
+		t.Errorf("replacing a non-empty directory = %d, want ENOTEMPTY", e)
+	}
+	if read("b.txt") != "new" || read("dir/c.txt") != "c" {
+		t.Errorf("files are changed by a failed rename")
+	}
+}
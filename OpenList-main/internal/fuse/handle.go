@@ -0,0 +1,169 @@
+package fuse
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// handle is an open file. Reads of unmodified files go through range requests of the link,
+// writes are staged to a temp file which is uploaded when the file is flushed.
+type handle struct {
+	mu   sync.Mutex
+	path string
+	// obj is the file when it was opened, nil for created files
+	obj  model.Obj
+	link *model.Link
+	rr   model.RangeReaderIF
+	// tmp is only set for writable handles
+	tmp   *os.File
+	dirty bool
+}
+
+func (h *handle) writable() bool {
+	return h.tmp != nil
+}
+
+func (h *handle) rangeReader(ctx context.Context) (model.RangeReaderIF, int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rr != nil {
+		return h.rr, h.obj.GetSize(), nil
+	}
+	link, obj, err := fs.Link(ctx, h.path, model.LinkArgs{})
+	if err != nil {
+		return nil, 0, err
+	}
+	rr, err := stream.GetRangeReaderFromLink(obj.GetSize(), link)
+	if err != nil {
+		_ = link.Close()
+		return nil, 0, err
+	}
+	h.link, h.rr = link, rr
+	if h.obj == nil {
+		h.obj = obj
+	}
+	return rr, obj.GetSize(), nil
+}
+
+func (h *handle) resetLink() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.link != nil {
+		_ = h.link.Close()
+	}
+	h.link, h.rr = nil, nil
+}
+
+// readRange reads length bytes at start, the link is renewed once when it fails as it may have expired
+func (h *handle) readRange(ctx context.Context, start, length int64, w io.Writer) error {
+	var err error
+	for retry := 0; retry < 2; retry++ {
+		var rr model.RangeReaderIF
+		rr, _, err = h.rangeReader(ctx)
+		if err != nil {
+			return err
+		}
+		var rc io.ReadCloser
+		rc, err = rr.RangeRead(ctx, http_range.Range{Start: start, Length: length})
+		if err == nil {
+			var n int64
+			n, err = utils.CopyWithBuffer(w, io.LimitReader(rc, length))
+			_ = rc.Close()
+			if err == nil && n < length {
+				err = errors.WithStack(io.ErrUnexpectedEOF)
+			}
+			if err == nil {
+				return nil
+			}
+		}
+		h.resetLink()
+	}
+	return err
+}
+
+// stage creates the temp file of a writable handle, with the current content unless truncated
+func (h *handle) stage(ctx context.Context, truncate bool) error {
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !truncate && h.obj != nil && h.obj.GetSize() > 0 {
+		if err = h.readRange(ctx, 0, h.obj.GetSize(), tmp); err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+	}
+	h.tmp = tmp
+	// created and truncated files are uploaded even if nothing is written
+	h.dirty = truncate || h.obj == nil
+	return nil
+}
+
+func (h *handle) size() int64 {
+	if h.writable() {
+		if info, err := h.tmp.Stat(); err == nil {
+			return info.Size()
+		}
+	}
+	if h.obj != nil {
+		return h.obj.GetSize()
+	}
+	return 0
+}
+
+// upload puts the staged content if it was modified
+func (h *handle) upload(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.writable() || !h.dirty {
+		return nil
+	}
+	size, err := h.tmp.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = h.tmp.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	dir, name := stdpath.Split(h.path)
+	s := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+		Reader:   h.tmp,
+	}
+	if err = fs.PutDirectly(ctx, dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	h.obj = s.Obj
+	return nil
+}
+
+func (h *handle) close() {
+	h.resetLink()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tmp != nil {
+		_ = h.tmp.Close()
+		_ = os.Remove(h.tmp.Name())
+		h.tmp = nil
+	}
+}
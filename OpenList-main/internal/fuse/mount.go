@@ -1,9 +1,47 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+	"fmt"
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+type MountOptions struct {
+	ReadOnly bool
+	// CacheBlocks is the capacity of the read cache, in blocks of 1MB
+	CacheBlocks int
+	// Options are passed to the FUSE library as is, such as allow_other
+	Options []string
+}
+
+// Mount mounts mountSrc at mountDst on behalf of the user of ctx, and blocks until
+// ctx is done or the filesystem is unmounted externally
+func Mount(ctx context.Context, mountSrc, mountDst string, opts MountOptions) error {
+	fs := NewFs(ctx, mountSrc, opts.ReadOnly, opts.CacheBlocks)
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	host.SetCapReaddirPlus(true)
+	fuseOpts := []string{"-o", "fsname=openlist"}
+	if opts.ReadOnly {
+		fuseOpts = append(fuseOpts, "-o", "ro")
+	}
+	for _, o := range opts.Options {
+		fuseOpts = append(fuseOpts, "-o", o)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			host.Unmount()
+		case <-done:
+		}
+	}()
+	if !host.Mount(mountDst, fuseOpts) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to mount %s at %s", mountSrc, mountDst)
+	}
+	return nil
 }
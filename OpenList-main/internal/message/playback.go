@@ -0,0 +1,310 @@
+package message
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// message types of the playback hub
+const (
+	PlaybackSessions = "sessions" // server -> client, all sessions of the user
+	PlaybackUpdate   = "update"   // both directions, a session has changed
+	PlaybackRemove   = "remove"   // both directions, a session has ended
+	PlaybackSleep    = "sleep"    // server -> client, the sleep deadline of a session has passed
+)
+
+// sessions that were not updated for this long are dropped, clients using the
+// REST fallback cannot tell us when they go away
+const playbackSessionTTL = 12 * time.Hour
+
+// PlaybackSession is the "now playing" state of one device of a user
+type PlaybackSession struct {
+	DeviceId    string  `json:"device_id"`
+	DeviceName  string  `json:"device_name"`
+	Path        string  `json:"path"`
+	Name        string  `json:"name"`
+	Fingerprint string  `json:"fingerprint"`
+	MediaType   string  `json:"media_type"`
+	PlaylistId  uint    `json:"playlist_id"`
+	Position    float64 `json:"position"`
+	Duration    float64 `json:"duration"`
+	Playing     bool    `json:"playing"`
+	// SleepAt is the unix time in milliseconds at which playback stops, 0 if no sleep timer is set
+	SleepAt   int64     `json:"sleep_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PlaybackSessionUpdate changes the session of a device, nil fields are left untouched
+type PlaybackSessionUpdate struct {
+	DeviceId    string   `json:"device_id" binding:"required"`
+	DeviceName  *string  `json:"device_name"`
+	Path        *string  `json:"path"`
+	Name        *string  `json:"name"`
+	Fingerprint *string  `json:"fingerprint"`
+	MediaType   *string  `json:"media_type"`
+	PlaylistId  *uint    `json:"playlist_id"`
+	Position    *float64 `json:"position"`
+	Duration    *float64 `json:"duration"`
+	Playing     *bool    `json:"playing"`
+	// SleepAt sets the sleep deadline in unix milliseconds, 0 cancels it
+	SleepAt *int64 `json:"sleep_at"`
+}
+
+func (u *PlaybackSessionUpdate) apply(s *PlaybackSession) {
+	if u.DeviceName != nil {
+		s.DeviceName = *u.DeviceName
+	}
+	if u.Path != nil {
+		s.Path = *u.Path
+	}
+	if u.Name != nil {
+		s.Name = *u.Name
+	}
+	if u.Fingerprint != nil {
+		s.Fingerprint = *u.Fingerprint
+	}
+	if u.MediaType != nil {
+		s.MediaType = *u.MediaType
+	}
+	if u.PlaylistId != nil {
+		s.PlaylistId = *u.PlaylistId
+	}
+	if u.Position != nil {
+		s.Position = *u.Position
+	}
+	if u.Duration != nil {
+		s.Duration = *u.Duration
+	}
+	if u.Playing != nil {
+		s.Playing = *u.Playing
+	}
+	if u.SleepAt != nil {
+		s.SleepAt = max(*u.SleepAt, 0)
+	}
+	s.UpdatedAt = time.Now()
+}
+
+type playbackClient struct {
+	userId   uint
+	deviceId string
+	send     chan Message
+}
+
+type playbackUser struct {
+	sessions map[string]*PlaybackSession
+	timers   map[string]*time.Timer
+	clients  map[*playbackClient]struct{}
+}
+
+// PlaybackHub keeps the playback sessions of every user and relays their changes
+// to the other connected clients of the same user
+type PlaybackHub struct {
+	mu    sync.Mutex
+	users map[uint]*playbackUser
+}
+
+func NewPlaybackHub() *PlaybackHub {
+	return &PlaybackHub{users: make(map[uint]*playbackUser)}
+}
+
+var PlaybackInstance = NewPlaybackHub()
+
+func (h *PlaybackHub) getUser(userId uint) *playbackUser {
+	u, ok := h.users[userId]
+	if !ok {
+		u = &playbackUser{
+			sessions: make(map[string]*PlaybackSession),
+			timers:   make(map[string]*time.Timer),
+			clients:  make(map[*playbackClient]struct{}),
+		}
+		h.users[userId] = u
+	}
+	return u
+}
+
+// cleanUser drops expired sessions and forgets the user once nothing is left
+func (h *PlaybackHub) cleanUser(userId uint) {
+	u, ok := h.users[userId]
+	if !ok {
+		return
+	}
+	for id, s := range u.sessions {
+		if time.Since(s.UpdatedAt) > playbackSessionTTL && u.timers[id] == nil {
+			delete(u.sessions, id)
+		}
+	}
+	if len(u.sessions) == 0 && len(u.clients) == 0 {
+		delete(h.users, userId)
+	}
+}
+
+// broadcast sends the message to the clients of the user except the given one, must hold h.mu
+func (h *PlaybackHub) broadcast(userId uint, msg Message, except *playbackClient) {
+	u, ok := h.users[userId]
+	if !ok {
+		return
+	}
+	for c := range u.clients {
+		if c == except {
+			continue
+		}
+		select {
+		case c.send <- msg:
+		default:
+			log.Debugf("drop playback message %s for slow client of user %d", msg.Type, userId)
+		}
+	}
+}
+
+// Sessions returns the sessions of the user, the most recently updated first
+func (h *PlaybackHub) Sessions(userId uint) []PlaybackSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cleanUser(userId)
+	res := make([]PlaybackSession, 0)
+	if u, ok := h.users[userId]; ok {
+		for _, s := range u.sessions {
+			res = append(res, *s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].UpdatedAt.After(res[j].UpdatedAt) })
+	return res
+}
+
+// Update changes the session of a device and notifies the other clients of the user
+func (h *PlaybackHub) Update(userId uint, update PlaybackSessionUpdate) (PlaybackSession, error) {
+	return h.update(userId, update, nil)
+}
+
+func (h *PlaybackHub) update(userId uint, update PlaybackSessionUpdate, from *playbackClient) (PlaybackSession, error) {
+	if update.DeviceId == "" {
+		return PlaybackSession{}, errors.New("device id is required")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	u := h.getUser(userId)
+	s, ok := u.sessions[update.DeviceId]
+	if !ok {
+		s = &PlaybackSession{DeviceId: update.DeviceId}
+		u.sessions[update.DeviceId] = s
+	}
+	update.apply(s)
+	if update.SleepAt != nil {
+		h.setSleepTimer(userId, u, s)
+	}
+	h.broadcast(userId, Message{Type: PlaybackUpdate, Content: *s}, from)
+	return *s, nil
+}
+
+// setSleepTimer (re)arms the sleep timer of the session, must hold h.mu
+func (h *PlaybackHub) setSleepTimer(userId uint, u *playbackUser, s *PlaybackSession) {
+	deviceId := s.DeviceId
+	if t, ok := u.timers[deviceId]; ok {
+		t.Stop()
+		delete(u.timers, deviceId)
+	}
+	if s.SleepAt == 0 {
+		return
+	}
+	sleepAt := s.SleepAt
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(time.UnixMilli(sleepAt)), func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		u, ok := h.users[userId]
+		if !ok || u.timers[deviceId] != timer {
+			return
+		}
+		delete(u.timers, deviceId)
+		s, ok := u.sessions[deviceId]
+		if !ok || s.SleepAt != sleepAt {
+			return
+		}
+		s.Playing = false
+		s.SleepAt = 0
+		s.UpdatedAt = time.Now()
+		// every client is told, including the device itself in case it missed its own deadline
+		h.broadcast(userId, Message{Type: PlaybackSleep, Content: *s}, nil)
+	})
+	u.timers[deviceId] = timer
+}
+
+// Remove ends the session of a device and notifies the other clients of the user
+func (h *PlaybackHub) Remove(userId uint, deviceId string) {
+	h.remove(userId, deviceId, nil)
+}
+
+func (h *PlaybackHub) remove(userId uint, deviceId string, from *playbackClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	u, ok := h.users[userId]
+	if !ok {
+		return
+	}
+	if t, ok := u.timers[deviceId]; ok {
+		t.Stop()
+		delete(u.timers, deviceId)
+	}
+	if _, ok := u.sessions[deviceId]; !ok {
+		return
+	}
+	delete(u.sessions, deviceId)
+	h.broadcast(userId, Message{Type: PlaybackRemove, Content: gin.H{"device_id": deviceId}}, from)
+	h.cleanUser(userId)
+}
+
+func (h *PlaybackHub) register(c *playbackClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.getUser(c.userId).clients[c] = struct{}{}
+}
+
+func (h *PlaybackHub) unregister(c *playbackClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if u, ok := h.users[c.userId]; ok {
+		delete(u.clients, c)
+		h.cleanUser(c.userId)
+	}
+}
+
+// REST fallback for clients that cannot keep a websocket open
+
+func (h *PlaybackHub) SessionsHandle(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	common.SuccessResp(c, h.Sessions(user.ID))
+}
+
+func (h *PlaybackHub) UpdateHandle(c *gin.Context) {
+	var req PlaybackSessionUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	session, err := h.Update(user.ID, req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, session)
+}
+
+func (h *PlaybackHub) RemoveHandle(c *gin.Context) {
+	deviceId := c.Query("device_id")
+	if deviceId == "" {
+		common.ErrorStrResp(c, "device id is required", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	h.Remove(user.ID, deviceId)
+	common.SuccessResp(c)
+}
@@ -0,0 +1,57 @@
+package message
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlaybackHub(t *testing.T) {
+	h := NewPlaybackHub()
+	phone := &playbackClient{userId: 1, deviceId: "phone", send: make(chan Message, 4)}
+	desktop := &playbackClient{userId: 1, deviceId: "desktop", send: make(chan Message, 4)}
+	other := &playbackClient{userId: 2, deviceId: "other", send: make(chan Message, 4)}
+	h.register(phone)
+	h.register(desktop)
+	h.register(other)
+
+	name, position, playing := "song.mp3", 42.5, true
+	sleepAt := time.Now().Add(50 * time.Millisecond).UnixMilli()
+	if _, err := h.update(1, PlaybackSessionUpdate{
+		DeviceId: "phone",
+		Name:     &name,
+		Position: &position,
+		Playing:  &playing,
+		SleepAt:  &sleepAt,
+	}, phone); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-desktop.send
+	if msg.Type != PlaybackUpdate || msg.Content.(PlaybackSession).Position != position {
+		t.Errorf("desktop got %+v, want the update of the phone", msg)
+	}
+	if len(phone.send) != 0 || len(other.send) != 0 {
+		t.Errorf("the sender and other users must not receive the update")
+	}
+
+	select {
+	case msg = <-phone.send:
+	case <-time.After(time.Second):
+		t.Fatal("sleep timer did not fire")
+	}
+	if s := msg.Content.(PlaybackSession); msg.Type != PlaybackSleep || s.Playing || s.SleepAt != 0 {
+		t.Errorf("phone got %+v, want a stopped session", msg)
+	}
+	if sessions := h.Sessions(1); len(sessions) != 1 || sessions[0].Playing {
+		t.Errorf("Sessions() = %+v, want one stopped session", sessions)
+	}
+
+	h.remove(1, "phone", phone)
+	<-desktop.send // sleep
+	if msg = <-desktop.send; msg.Type != PlaybackRemove {
+		t.Errorf("desktop got %+v, want the removal of the phone", msg)
+	}
+	if sessions := h.Sessions(1); len(sessions) != 0 {
+		t.Errorf("Sessions() = %+v, want none", sessions)
+	}
+}
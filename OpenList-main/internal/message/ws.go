@@ -1,3 +1,157 @@
 package message
 
-// TODO websocket implementation
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TODO websocket implementation of Messenger
+
+// PlaybackError is sent to a websocket client whose message could not be handled
+const PlaybackError = "error"
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingPeriod     = wsPongWait * 9 / 10
+	wsMaxMessageSize = 64 << 10
+	wsSendBuffer     = 16
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the connection is authorized by the token, not by cookies, so any origin may connect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type wsRequest struct {
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content"`
+}
+
+// WsHandle upgrades the request to a websocket that receives the playback sessions of the user.
+// The client sends update and remove messages for its own device, identified by the device_id query.
+func (h *PlaybackHub) WsHandle(c *gin.Context) {
+	deviceId := c.Query("device_id")
+	if deviceId == "" {
+		common.ErrorStrResp(c, "device id is required", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warnf("failed upgrade playback websocket: %+v", err)
+		return
+	}
+	client := &playbackClient{
+		userId:   user.ID,
+		deviceId: deviceId,
+		send:     make(chan Message, wsSendBuffer),
+	}
+	h.register(client)
+	client.send <- Message{Type: PlaybackSessions, Content: h.Sessions(user.ID)}
+	go client.writePump(conn)
+	client.readPump(h, conn)
+}
+
+func (c *playbackClient) readPump(h *PlaybackHub, conn *websocket.Conn) {
+	defer func() {
+		h.unregister(c)
+		// no broadcast can reach the client after unregister
+		close(c.send)
+	}()
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		var req wsRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Debugf("playback websocket of user %d closed: %+v", c.userId, err)
+			}
+			return
+		}
+		if err := c.handle(h, req); err != nil {
+			c.reply(Message{Type: PlaybackError, Content: err.Error()})
+		}
+	}
+}
+
+func (c *playbackClient) handle(h *PlaybackHub, req wsRequest) error {
+	switch req.Type {
+	case PlaybackUpdate:
+		var update PlaybackSessionUpdate
+		if err := json.Unmarshal(req.Content, &update); err != nil {
+			return err
+		}
+		if update.DeviceId == "" {
+			update.DeviceId = c.deviceId
+		}
+		_, err := h.update(c.userId, update, c)
+		return err
+	case PlaybackRemove:
+		var args struct {
+			DeviceId string `json:"device_id"`
+		}
+		if len(req.Content) > 0 {
+			if err := json.Unmarshal(req.Content, &args); err != nil {
+				return err
+			}
+		}
+		if args.DeviceId == "" {
+			args.DeviceId = c.deviceId
+		}
+		h.remove(c.userId, args.DeviceId, c)
+		return nil
+	case PlaybackSessions:
+		c.reply(Message{Type: PlaybackSessions, Content: h.Sessions(c.userId)})
+		return nil
+	default:
+		return errors.Errorf("unknown message type: %s", req.Type)
+	}
+}
+
+func (c *playbackClient) reply(msg Message) {
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
+
+func (c *playbackClient) writePump(conn *websocket.Conn) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = conn.Close()
+	}()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// APITokenPrefix starts every api token, so that they are told apart from the jwt tokens
+const APITokenPrefix = "olt_"
+
+// APIToken is a personal credential for scripts. It acts as its user, restricted to
+// PathPrefix and Permission, and never has the admin role.
+type APIToken struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserId    uint   `json:"-" gorm:"index"`
+	Name      string `json:"name"`
+	TokenHash string `json:"-" gorm:"uniqueIndex;size:64"`
+	// TokenPrefix is the beginning of the token, to recognize it in the list
+	TokenPrefix string `json:"token_prefix"`
+	// PathPrefix is relative to the base path of the user
+	PathPrefix string `json:"path_prefix"`
+	// Permission has the bits of User.Permission, and must be a subset of them
+	Permission int32      `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.IsZero() && t.ExpiresAt.Before(time.Now())
+}
+
+// Scope returns a copy of user restricted by the token
+func (t *APIToken) Scope(user *User) (*User, error) {
+	basePath, err := user.JoinPath(t.PathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	scoped := *user
+	scoped.BasePath = basePath
+	// the permissions of the user may be reduced after the token is created
+	scoped.Permission = user.EffectivePermission() & t.Permission
+	// the groups only keep restricting the storages
+	scoped.Groups = make([]Group, len(user.Groups))
+	for i, g := range user.Groups {
+		g.Permission, g.BasePath = 0, ""
+		scoped.Groups[i] = g
+	}
+	if scoped.IsAdmin() {
+		scoped.Role = GENERAL
+	}
+	return &scoped, nil
+}
+
+// S3AccessKeyId is the access key id of the token for the s3 server
+func (t *APIToken) S3AccessKeyId() string {
+	return fmt.Sprintf("%s%d", APITokenPrefix, t.ID)
+}
+
+func HashAPIToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
@@ -79,6 +79,23 @@ type ArchiveDecompressArgs struct {
 	PutIntoNewDir bool
 }
 
+// formats of the archives created by compress tasks
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+	// ArchiveFormat7zStore is a 7z archive storing the files without compression
+	ArchiveFormat7zStore = "7z"
+)
+
+type ArchiveCompressArgs struct {
+	// Names are the objects of the source directory put into the archive
+	Names       []string `json:"names"`
+	ArchiveName string   `json:"archive_name"`
+	Format      string   `json:"format"`
+	// Password encrypts the files of zip archives with AES-256
+	Password string `json:"archive_pass"`
+}
+
 type SharingListArgs struct {
 	Refresh bool
 	Pwd     string
@@ -120,3 +137,10 @@ func (r *RangeReadCloser) RangeRead(ctx context.Context, httpRange http_range.Ra
 	r.Add(rc)
 	return rc, err
 }
+
+type SyncArgs struct {
+	// TwoWay also copies the files that are new or newer in the destination back to the source
+	TwoWay bool `json:"two_way"`
+	// Delete removes the destination files that are not in the source, one-way only
+	Delete bool `json:"delete"`
+}
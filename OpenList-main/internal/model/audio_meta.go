@@ -0,0 +1,20 @@
+package model
+
+// AudioMeta is the embedded tags of an audio file
+type AudioMeta struct {
+	Format      string  `json:"format"`
+	Title       string  `json:"title"`
+	Artist      string  `json:"artist"`
+	Album       string  `json:"album"`
+	AlbumArtist string  `json:"album_artist"`
+	Genre       string  `json:"genre"`
+	Year        int     `json:"year"`
+	Track       int     `json:"track"`
+	TrackTotal  int     `json:"track_total"`
+	Disc        int     `json:"disc"`
+	DiscTotal   int     `json:"disc_total"`
+	Duration    float64 `json:"duration"`
+	HasCover    bool    `json:"has_cover"`
+	CoverType   string  `json:"cover_type,omitempty"`
+	Cover       []byte  `json:"-"` // only read by op.GetAudioCover, never cached
+}
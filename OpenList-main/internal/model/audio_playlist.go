@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AudioPlayModeList   = "list"
+	AudioPlayModeRandom = "random"
+	AudioPlayModeSingle = "single"
+)
+
+// AudioPlaylist represents a server-side play queue of the global audio player
+type AudioPlaylist struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserId       uint      `json:"user_id" gorm:"index"`
+	Name         string    `json:"name" gorm:"not null"`
+	PlayMode     string    `json:"play_mode"`     // list, random or single
+	CurrentIndex int       `json:"current_index"` // -1 means nothing selected
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AudioPlaylistItem represents an audio in a playlist
+type AudioPlaylistItem struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserId       uint      `json:"user_id" gorm:"index"`
+	PlaylistId   uint      `json:"playlist_id" gorm:"index"`
+	Position     int       `json:"position"` // 0-based position in the playlist
+	StorageId    uint      `json:"storage_id"`
+	OriginalPath string    `json:"original_path" gorm:"not null"` // full path to audio file
+	FileName     string    `json:"file_name" gorm:"not null"`     // audio filename
+	Fingerprint  string    `json:"fingerprint" gorm:"index"`      // for linking with media marks
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func IsValidAudioPlayMode(mode string) bool {
+	switch mode {
+	case AudioPlayModeList, AudioPlayModeRandom, AudioPlayModeSingle:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// audit event types
+const (
+	AuditLogin         = "login"
+	AuditLoginFailed   = "login_failed"
+	AuditMkdir         = "mkdir"
+	AuditRename        = "rename"
+	AuditMove          = "move"
+	AuditCopy          = "copy"
+	AuditRemove        = "remove"
+	AuditUpload        = "upload"
+	AuditShareCreate   = "share_create"
+	AuditShareAccess   = "share_access"
+	AuditStorageCreate = "storage_create"
+	AuditStorageUpdate = "storage_update"
+	AuditStorageDelete = "storage_delete"
+	AuditSettingUpdate = "setting_update"
+	AuditSettingDelete = "setting_delete"
+	AuditUserCreate    = "user_create"
+	AuditUserUpdate    = "user_update"
+	AuditUserDelete    = "user_delete"
+)
+
+// protocols of the requests
+const (
+	ProtocolHTTP   = "http"
+	ProtocolWebDAV = "webdav"
+	ProtocolFTP    = "ftp"
+	ProtocolSFTP   = "sftp"
+	ProtocolS3     = "s3"
+)
+
+// AuditEvent records who did what. Path is the object of file operations, the mount path of
+// storages, the keys of settings, the username of users or the id of sharings.
+type AuditEvent struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	Event    string    `json:"event" gorm:"index"`
+	UserId   uint      `json:"user_id" gorm:"index"`
+	Username string    `json:"username"`
+	IP       string    `json:"ip"`
+	Protocol string    `json:"protocol"`
+	Path     string    `json:"path" gorm:"type:text"`
+	DstPath  string    `json:"dst_path" gorm:"type:text"` // rename, move and copy only
+	Success  bool      `json:"success"`
+	Error    string    `json:"error" gorm:"type:text"`
+}
+
+type AuditQuery struct {
+	PageReq
+	Event    string `json:"event" form:"event"`
+	Username string `json:"username" form:"username"`
+	IP       string `json:"ip" form:"ip"`
+	Protocol string `json:"protocol" form:"protocol"`
+	// Path matches the events on it or under it
+	Path    string `json:"path" form:"path"`
+	Success *bool  `json:"success" form:"success"`
+	// Since and Until are unix timestamps in seconds, 0 means no limit
+	Since int64 `json:"since" form:"since"`
+	Until int64 `json:"until" form:"until"`
+}
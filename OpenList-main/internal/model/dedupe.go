@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// dedupe resolving actions
+const (
+	DedupeDelete  = "delete"
+	DedupeReplace = "replace"
+)
+
+type DedupeArgs struct {
+	Paths []string `json:"paths"`
+	// MinSize skips the smaller files, empty files are always skipped
+	MinSize int64 `json:"min_size"`
+	// PartialHash compares the head and the tail of the files the storages give no common hash of,
+	// rather than downloading them as a whole, the groups found so can not be resolved
+	PartialHash bool `json:"partial_hash"`
+}
+
+type DuplicateFile struct {
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+}
+
+// PartialHashPrefix marks the hashes computed from the head and the tail of the files only
+const PartialHashPrefix = "partial-sha1:"
+
+// DuplicateGroup is a set of files with the same content, Hash tells how it has been confirmed
+type DuplicateGroup struct {
+	Size  int64           `json:"size"`
+	Hash  string          `json:"hash"`
+	Files []DuplicateFile `json:"files"`
+}
+
+// Wasted is the size that would be freed by keeping only one of the files
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// Partial tells if the files have only been compared partially, they may still differ
+func (g DuplicateGroup) Partial() bool {
+	return strings.HasPrefix(g.Hash, PartialHashPrefix)
+}
+
+func (g DuplicateGroup) Contains(path string) bool {
+	for _, f := range g.Files {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	FavoriteMediaVideo    = "video"
+	FavoriteMediaAudio    = "audio"
+	FavoriteMediaImage    = "image"
+	FavoriteMediaDocument = "document"
+	FavoriteMediaAny      = "any"
+)
+
+// FavoriteFolder represents a folder that contains favorite files of one media type
+type FavoriteFolder struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserId      uint      `json:"user_id" gorm:"index"`
+	MediaType   string    `json:"media_type" gorm:"size:16;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	Order       int       `json:"order"` // for sorting folders
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Favorite represents a file in a favorite folder
+type Favorite struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserId       uint      `json:"user_id" gorm:"index"`
+	FolderId     uint      `json:"folder_id" gorm:"index"`
+	MediaType    string    `json:"media_type" gorm:"size:16;index"` // same as the folder's
+	StorageId    uint      `json:"storage_id"`
+	OriginalPath string    `json:"original_path" gorm:"not null"` // full path to the file
+	FileName     string    `json:"file_name" gorm:"not null"`
+	Note         string    `json:"note" gorm:"type:text"`    // optional user note
+	Fingerprint  string    `json:"fingerprint" gorm:"index"` // for linking with media marks
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func IsValidFavoriteMediaType(mediaType string) bool {
+	switch mediaType {
+	case FavoriteMediaVideo, FavoriteMediaAudio, FavoriteMediaImage, FavoriteMediaDocument, FavoriteMediaAny:
+		return true
+	}
+	return false
+}
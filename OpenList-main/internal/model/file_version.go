@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// VersionsDirName is the folder next to a file that the overwritten versions of it are
+// moved into, as .versions/<name>/<timestamp>
+const VersionsDirName = ".versions"
+
+// FileVersion is an overwritten version of a file. Path is the mount path of the file,
+// ActualPath and VersionPath are paths in the storage.
+type FileVersion struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StorageId   uint      `json:"-" gorm:"index:idx_file_version"`
+	ActualPath  string    `json:"-" gorm:"index:idx_file_version"`
+	Path        string    `json:"path"`
+	VersionPath string    `json:"-"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	UserId      uint      `json:"user_id"`
+	Username    string    `json:"username"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
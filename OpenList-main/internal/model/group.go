@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// Group gives its members permissions, a base path and the storages they can access
+type Group struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"unique" binding:"required"`
+	// Permission has the same bits as User.Permission
+	Permission int32 `json:"permission"`
+	// BasePath applies to the members whose own base path is the root
+	BasePath string `json:"base_path"`
+	// AllowedStorages are mount paths separated by new lines, empty means all storages
+	AllowedStorages string `json:"allowed_storages" gorm:"type:text"`
+	// ExternalGroups are the names of LDAP or SSO groups mapped to this group, separated by new lines
+	ExternalGroups string `json:"external_groups" gorm:"type:text"`
+}
+
+func splitLines(s string) []string {
+	var res []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+func (g *Group) GetAllowedStorages() []string {
+	return utils.MustSliceConvert(splitLines(g.AllowedStorages), utils.FixAndCleanPath)
+}
+
+func (g *Group) GetExternalGroups() []string {
+	return splitLines(g.ExternalGroups)
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestUserGroups(t *testing.T) {
+	u := &User{
+		BasePath:   "/",
+		Permission: 1 << 3,
+		Groups: []Group{
+			{Permission: 1 << 14, BasePath: "/team", AllowedStorages: "/team\n /shared/ \n"},
+			{Permission: 1 << 7, BasePath: "/other", AllowedStorages: "/archive"},
+		},
+	}
+	if !u.CanWrite() || !u.CanShare() || !u.CanRemove() || u.CanRename() {
+		t.Errorf("unexpected effective permission %b", u.EffectivePermission())
+	}
+	if p := u.GetBasePath(); p != "/team" {
+		t.Errorf("expected base path of the first group, got %s", p)
+	}
+	for path, want := range map[string]bool{
+		"/":             true,
+		"/shared":       true,
+		"/shared/a/b":   true,
+		"/archive/x":    true,
+		"/private":      false,
+		"/sharedfolder": false,
+	} {
+		if got := u.CanAccessStorageOf(path); got != want {
+			t.Errorf("CanAccessStorageOf(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if _, err := u.JoinPath("/x"); err != nil {
+		t.Errorf("unexpected error joining path: %v", err)
+	}
+
+	u.BasePath = "/own"
+	u.Groups = append(u.Groups, Group{})
+	if p := u.GetBasePath(); p != "/own" {
+		t.Errorf("expected the own base path, got %s", p)
+	}
+	if u.AllowedStorages() != nil {
+		t.Error("a group without allowed storages should allow all of them")
+	}
+}
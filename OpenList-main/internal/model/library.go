@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LibraryReq browses the audio tags of the search index, empty filters match everything
+type LibraryReq struct {
+	Parent      string `json:"parent" form:"parent"`
+	Artist      string `json:"artist" form:"artist"`
+	AlbumArtist string `json:"album_artist" form:"album_artist"`
+	Album       string `json:"album" form:"album"`
+	Genre       string `json:"genre" form:"genre"`
+	Year        int    `json:"year" form:"year"`
+	PageReq
+}
+
+type LibraryArtist struct {
+	Name       string `json:"name"`
+	AlbumCount int64  `json:"album_count"`
+	TrackCount int64  `json:"track_count"`
+}
+
+type LibraryAlbum struct {
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	Year   int    `json:"year"`
+	Genre  string `json:"genre"`
+	// Parent is the directory of the first track, where the cover usually is
+	Parent     string `json:"parent"`
+	TrackCount int64  `json:"track_count"`
+}
+
+// Match reports whether the node is a track matching the filters of the request
+func (r *LibraryReq) Match(node *SearchNode) bool {
+	if node.IsDir || !node.HasAudioTags() {
+		return false
+	}
+	if r.Parent != "" && r.Parent != "/" && node.Parent != r.Parent && !strings.HasPrefix(node.Parent, r.Parent+"/") {
+		return false
+	}
+	return (r.Artist == "" || node.Artist == r.Artist) &&
+		(r.AlbumArtist == "" || node.AlbumArtist == r.AlbumArtist) &&
+		(r.Album == "" || node.Album == r.Album) &&
+		(r.Genre == "" || node.Genre == r.Genre) &&
+		(r.Year == 0 || node.Year == r.Year)
+}
+
+func (r *LibraryReq) Validate() error {
+	if r.Page < 1 {
+		return fmt.Errorf("page can't < 1")
+	}
+	if r.PerPage < 1 {
+		return fmt.Errorf("per_page can't < 1")
+	}
+	return nil
+}
@@ -1,5 +1,15 @@
 package model
 
+import "slices"
+
+// actions of the access rules
+const (
+	ACLRead   = "read"
+	ACLWrite  = "write"
+	ACLDelete = "delete"
+	ACLShare  = "share"
+)
+
 type Meta struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
 	Path      string `json:"path" gorm:"unique" binding:"required"`
@@ -13,4 +23,43 @@ type Meta struct {
 	RSub      bool   `json:"r_sub"`
 	Header    string `json:"header"`
 	HeaderSub bool   `json:"header_sub"`
+	// ACL are the access rules of users and groups on the path
+	ACL  []ACLRule `json:"acl" gorm:"serializer:json;type:text"`
+	ASub bool      `json:"a_sub"`
+}
+
+// ACLRule allows or denies the actions to a user, to the members of a group,
+// or to everyone when neither is set
+type ACLRule struct {
+	User    string   `json:"user,omitempty"`
+	Group   string   `json:"group,omitempty"`
+	Deny    bool     `json:"deny"`
+	Actions []string `json:"actions"`
+}
+
+// Matches tells if the rule is about the user
+func (r *ACLRule) Matches(user *User) bool {
+	switch {
+	case r.User != "":
+		return r.User == user.Username
+	case r.Group != "":
+		return slices.ContainsFunc(user.Groups, func(g Group) bool { return g.Name == r.Group })
+	default:
+		return true
+	}
+}
+
+// CheckACL evaluates the rules of the meta, a deny rule wins over the allow rules.
+// decided is false when no rule is about the user and the action.
+func (m *Meta) CheckACL(user *User, action string) (allowed, decided bool) {
+	for _, r := range m.ACL {
+		if !slices.Contains(r.Actions, action) || !r.Matches(user) {
+			continue
+		}
+		if r.Deny {
+			return false, true
+		}
+		allowed = true
+	}
+	return allowed, allowed
 }
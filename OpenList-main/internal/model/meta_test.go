@@ -0,0 +1,32 @@
+package model
+
+import "testing"
+
+func TestMetaCheckACL(t *testing.T) {
+	m := &Meta{ACL: []ACLRule{
+		{Actions: []string{ACLRead}},
+		{Group: "staff", Actions: []string{ACLWrite, ACLDelete}},
+		{User: "bob", Deny: true, Actions: []string{ACLDelete}},
+	}}
+	alice := &User{Username: "alice", Groups: []Group{{Name: "staff"}}}
+	bob := &User{Username: "bob", Groups: []Group{{Name: "staff"}}}
+	guest := &User{Username: "guest"}
+	for _, c := range []struct {
+		user             *User
+		action           string
+		allowed, decided bool
+	}{
+		{guest, ACLRead, true, true},
+		{guest, ACLWrite, false, false},
+		{alice, ACLWrite, true, true},
+		{alice, ACLDelete, true, true},
+		{bob, ACLDelete, false, true},
+		{bob, ACLShare, false, false},
+	} {
+		allowed, decided := m.CheckACL(c.user, c.action)
+		if allowed != c.allowed || decided != c.decided {
+			t.Errorf("CheckACL(%s, %s) = %v, %v, want %v, %v",
+				c.user.Username, c.action, allowed, decided, c.allowed, c.decided)
+		}
+	}
+}
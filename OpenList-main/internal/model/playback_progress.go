@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// PlaybackProgress records where a user stopped playing a media file
+type PlaybackProgress struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserId       uint      `json:"user_id" gorm:"uniqueIndex:idx_playback_progress_user_fp"`
+	Fingerprint  string    `json:"fingerprint" gorm:"size:64;uniqueIndex:idx_playback_progress_user_fp"`
+	StorageId    uint      `json:"storage_id"`
+	OriginalPath string    `json:"original_path"`
+	Position     float64   `json:"position"` // in seconds
+	Duration     float64   `json:"duration"` // in seconds, 0 if unknown
+	Finished     bool      `json:"finished"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"index"`
+}
+
+// PlaybackProgressDTO is used for API responses to avoid exposing internal fields
+type PlaybackProgressDTO struct {
+	Position  float64   `json:"position"`
+	Duration  float64   `json:"duration"`
+	Finished  bool      `json:"finished"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ToDTO converts PlaybackProgress to PlaybackProgressDTO
+func (p *PlaybackProgress) ToDTO() PlaybackProgressDTO {
+	return PlaybackProgressDTO{
+		Position:  p.Position,
+		Duration:  p.Duration,
+		Finished:  p.Finished,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
@@ -18,3 +18,17 @@ func (p *PageReq) Validate() {
 		p.PerPage = MaxInt
 	}
 }
+
+// Paginate returns the page of s and the total count
+func Paginate[T any](s []T, req PageReq) ([]T, int64) {
+	total := int64(len(s))
+	start := (req.Page - 1) * req.PerPage
+	if start < 0 || start >= len(s) {
+		return []T{}, total
+	}
+	end := len(s)
+	if req.PerPage < end-start {
+		end = start + req.PerPage
+	}
+	return s[start:end], total
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// scheduled job kinds
+const (
+	ScheduledJobCopy                 = "copy"
+	ScheduledJobMove                 = "move"
+	ScheduledJobBuildIndex           = "build_index"
+	ScheduledJobRemoveEmptyDirectory = "remove_empty_directory"
+	ScheduledJobOfflineDownload      = "offline_download"
+)
+
+// scheduled job run status
+const (
+	ScheduledJobRunRunning   = "running"
+	ScheduledJobRunSucceeded = "succeeded"
+	ScheduledJobRunFailed    = "failed"
+)
+
+// ScheduledJob runs an operation on behalf of the admin at the times of a cron expression
+type ScheduledJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" binding:"required"`
+	Kind     string `json:"kind" binding:"required"`
+	Cron     string `json:"cron" binding:"required"` // 5 fields expression, @daily or @every 1h
+	Disabled bool   `json:"disabled"`
+	// SrcPath is the object to copy or move, or the directory to index or clean up
+	SrcPath string `json:"src_path"`
+	// DstPath is the directory to copy, move or download to
+	DstPath string `json:"dst_path"`
+	// URLs are the urls to download, one per line
+	URLs         string     `json:"urls" gorm:"type:text"`
+	Tool         string     `json:"tool"`
+	DeletePolicy string     `json:"delete_policy"`
+	MaxDepth     int        `json:"max_depth"` // 0 means the max_index_depth setting
+	LastRunAt    *time.Time `json:"last_run_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func IsValidScheduledJobKind(kind string) bool {
+	switch kind {
+	case ScheduledJobCopy, ScheduledJobMove, ScheduledJobBuildIndex,
+		ScheduledJobRemoveEmptyDirectory, ScheduledJobOfflineDownload:
+		return true
+	}
+	return false
+}
+
+// ScheduledJobRun is a run of a scheduled job. Copy, move and offline download runs
+// only submit tasks, TaskType and TaskIds tell where to follow them in the task api.
+type ScheduledJobRun struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	JobId      uint       `json:"job_id" gorm:"index"`
+	Manual     bool       `json:"manual"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error" gorm:"type:text"`
+	TaskType   string     `json:"task_type"`
+	TaskIdsRaw string     `json:"-" gorm:"type:text"`
+	TaskIds    []string   `json:"task_ids" gorm:"-"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
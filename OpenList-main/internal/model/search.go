@@ -1,7 +1,12 @@
 package model
 
 import (
+	"cmp"
 	"fmt"
+	stdpath "path"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,14 +22,51 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// the filters below are ignored when left zero
+	MinSize        int64      `json:"min_size"`
+	MaxSize        int64      `json:"max_size"`
+	ModifiedAfter  *time.Time `json:"modified_after"`
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// Exts are the extensions without the dot
+	Exts []string `json:"exts"`
+	// MediaTypes are the types of conf.FOLDER, conf.VIDEO and so on
+	MediaTypes []int `json:"media_types"`
+	// Name is a glob the whole name has to match case-insensitively, or a regexp if NameRegexp
+	Name       string `json:"name"`
+	NameRegexp bool   `json:"name_regexp"`
+	// OrderBy is name, size or modified, name by default
+	OrderBy        string `json:"order_by"`
+	OrderDirection string `json:"order_direction"`
 	PageReq
+	nameRegexp *regexp.Regexp
 }
 
+// search orders
+const (
+	SearchOrderByName     = "name"
+	SearchOrderBySize     = "size"
+	SearchOrderByModified = "modified"
+)
+
 type SearchNode struct {
 	Parent string `json:"parent" gorm:"index"`
 	Name   string `json:"name"`
 	IsDir  bool   `json:"is_dir"`
 	Size   int64  `json:"size"`
+	// Modified, Ext and MediaType are empty in the indexes built before they were added
+	Modified time.Time `json:"modified" gorm:"index"`
+	// Ext is the lower case extension without the dot, empty for folders
+	Ext       string `json:"ext,omitempty" gorm:"index"`
+	MediaType int    `json:"media_type" gorm:"index"`
+	// tags of audio files, only filled when the index_audio_tags setting is on
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty" gorm:"index"`
+	// AlbumArtist falls back to Artist, albums are grouped by it
+	AlbumArtist string `json:"album_artist,omitempty"`
+	Album       string `json:"album,omitempty" gorm:"index"`
+	Genre       string `json:"genre,omitempty"`
+	Year        int    `json:"year,omitempty"`
+	Track       int    `json:"track,omitempty"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +76,110 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	for i := range p.Exts {
+		p.Exts[i] = strings.ToLower(strings.TrimPrefix(p.Exts[i], "."))
+	}
+	if p.NameRegexp {
+		re, err := regexp.Compile(p.Name)
+		if err != nil {
+			return fmt.Errorf("invalid name regexp: %w", err)
+		}
+		p.nameRegexp = re
+	} else if _, err := stdpath.Match(p.Name, ""); err != nil {
+		return fmt.Errorf("invalid name glob: %w", err)
+	}
+	if p.OrderBy == "" {
+		p.OrderBy = SearchOrderByName
+	}
+	if p.OrderBy != SearchOrderByName && p.OrderBy != SearchOrderBySize && p.OrderBy != SearchOrderByModified {
+		return fmt.Errorf("can't order by %s", p.OrderBy)
+	}
+	if p.OrderDirection == "" {
+		p.OrderDirection = "asc"
+	}
+	if p.OrderDirection != "asc" && p.OrderDirection != "desc" {
+		return fmt.Errorf("invalid order direction: %s", p.OrderDirection)
+	}
 	return nil
 }
 
+// MatchName tells if the name matches the glob or the regexp of the request, the request must be validated
+func (p *SearchReq) MatchName(name string) bool {
+	if p.Name == "" {
+		return true
+	}
+	if p.nameRegexp != nil {
+		return p.nameRegexp.MatchString(name)
+	}
+	ok, _ := stdpath.Match(strings.ToLower(p.Name), strings.ToLower(name))
+	return ok
+}
+
+// Match tells if the node passes the filters of the request, except the keywords and the parent
+func (p *SearchReq) Match(node *SearchNode) bool {
+	if p.Scope != 0 && node.IsDir != (p.Scope == 1) {
+		return false
+	}
+	if node.Size < p.MinSize || (p.MaxSize > 0 && node.Size > p.MaxSize) {
+		return false
+	}
+	if (p.ModifiedAfter != nil && node.Modified.Before(*p.ModifiedAfter)) ||
+		(p.ModifiedBefore != nil && node.Modified.After(*p.ModifiedBefore)) {
+		return false
+	}
+	if len(p.Exts) > 0 && !slices.Contains(p.Exts, node.Ext) {
+		return false
+	}
+	if len(p.MediaTypes) > 0 && !slices.Contains(p.MediaTypes, node.MediaType) {
+		return false
+	}
+	return p.MatchName(node.Name)
+}
+
+// Filter filters, orders and paginates the nodes in memory,
+// for searchers that cannot apply some of the filters of the request by themselves
+func (p *SearchReq) Filter(nodes []SearchNode) ([]SearchNode, int64) {
+	res := make([]SearchNode, 0, len(nodes))
+	for i := range nodes {
+		if p.Match(&nodes[i]) {
+			res = append(res, nodes[i])
+		}
+	}
+	slices.SortStableFunc(res, func(a, b SearchNode) int {
+		if p.Less(&a, &b) {
+			return -1
+		}
+		if p.Less(&b, &a) {
+			return 1
+		}
+		return 0
+	})
+	return Paginate(res, p.PageReq)
+}
+
+// Less orders the nodes as requested
+func (p *SearchReq) Less(a, b *SearchNode) bool {
+	c := 0
+	switch p.OrderBy {
+	case SearchOrderBySize:
+		c = cmp.Compare(a.Size, b.Size)
+	case SearchOrderByModified:
+		c = a.Modified.Compare(b.Modified)
+	}
+	if c == 0 {
+		c = strings.Compare(a.Name, b.Name)
+	}
+	if p.OrderDirection == "desc" {
+		return c > 0
+	}
+	return c < 0
+}
+
+// HasAudioTags reports whether the node is a track of the music library
+func (s *SearchNode) HasAudioTags() bool {
+	return s.Artist != "" || s.Album != ""
+}
+
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
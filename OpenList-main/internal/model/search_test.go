@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchReqMatch(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := day.Add(-time.Hour)
+	node := SearchNode{Name: "Holiday.MP4", Size: 100, Modified: day, Ext: "mp4", MediaType: 2}
+	for _, c := range []struct {
+		name string
+		req  SearchReq
+		want bool
+	}{
+		{"no filters", SearchReq{}, true},
+		{"files only", SearchReq{Scope: 2}, true},
+		{"folders only", SearchReq{Scope: 1}, false},
+		{"size in range", SearchReq{MinSize: 100, MaxSize: 100}, true},
+		{"too small", SearchReq{MinSize: 101}, false},
+		{"modified after", SearchReq{ModifiedAfter: &day}, true},
+		{"modified before", SearchReq{ModifiedBefore: &before}, false},
+		{"ext with dot", SearchReq{Exts: []string{".MP4"}}, true},
+		{"other ext", SearchReq{Exts: []string{"mkv"}}, false},
+		{"media type", SearchReq{MediaTypes: []int{1, 2}}, true},
+		{"glob ignores case", SearchReq{Name: "holiday.*"}, true},
+		{"glob matches the whole name", SearchReq{Name: "holiday"}, false},
+		{"regexp", SearchReq{Name: `^Holi`, NameRegexp: true}, true},
+		{"regexp is case-sensitive", SearchReq{Name: `^holi`, NameRegexp: true}, false},
+	} {
+		c.req.PageReq = PageReq{Page: 1, PerPage: 10}
+		if err := c.req.Validate(); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got := c.req.Match(&node); got != c.want {
+			t.Errorf("%s: Match() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSearchReqValidate(t *testing.T) {
+	for _, req := range []SearchReq{
+		{Name: "[a", PageReq: PageReq{Page: 1, PerPage: 10}},
+		{Name: "(a", NameRegexp: true, PageReq: PageReq{Page: 1, PerPage: 10}},
+		{OrderBy: "parent", PageReq: PageReq{Page: 1, PerPage: 10}},
+		{OrderDirection: "up", PageReq: PageReq{Page: 1, PerPage: 10}},
+	} {
+		if err := req.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", req)
+		}
+	}
+}
+
+func TestSearchReqLess(t *testing.T) {
+	a := &SearchNode{Name: "a", Size: 2}
+	b := &SearchNode{Name: "b", Size: 1}
+	req := SearchReq{OrderBy: SearchOrderBySize}
+	if !req.Less(b, a) || req.Less(a, b) {
+		t.Error("size asc ordered wrongly")
+	}
+	req.OrderDirection = "desc"
+	if !req.Less(a, b) || req.Less(b, a) || req.Less(a, a) {
+		t.Error("size desc ordered wrongly")
+	}
+}
@@ -2,6 +2,16 @@ package model
 
 import "time"
 
+// sharing source types
+const (
+	SharingSourceFiles          = "files"
+	SharingSourceFavoriteFolder = "favorite_folder"
+)
+
+func IsValidSharingSource(sourceType string) bool {
+	return sourceType == "" || sourceType == SharingSourceFiles || sourceType == SharingSourceFavoriteFolder
+}
+
 type SharingDB struct {
 	ID          string     `json:"id" gorm:"type:char(12);primaryKey"`
 	FilesRaw    string     `json:"-" gorm:"type:text"`
@@ -14,15 +24,24 @@ type SharingDB struct {
 	Remark      string     `json:"remark"`
 	Readme      string     `json:"readme" gorm:"type:text"`
 	Header      string     `json:"header" gorm:"type:text"`
+	// SourceType is empty or files for a sharing of FilesRaw, favorite_folder
+	// shares the favorite folder SourceId of the creator as a read-only list
+	SourceType string `json:"source_type"`
+	SourceId   uint   `json:"source_id"`
 	Sort
 }
 
+func (s *SharingDB) FromFavoriteFolder() bool {
+	return s.SourceType == SharingSourceFavoriteFolder
+}
+
 type Sharing struct {
 	*SharingDB
 	Files   []string `json:"files"`
 	Creator *User    `json:"-"`
 }
 
+// Valid checks the state of the sharing, op.SharingValid checks the creator can still share the files as well
 func (s *Sharing) Valid() bool {
 	if s.Disabled {
 		return false
@@ -30,10 +49,15 @@ func (s *Sharing) Valid() bool {
 	if s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed {
 		return false
 	}
-	if len(s.Files) == 0 {
+	if s.FromFavoriteFolder() {
+		// the files are resolved on access, an empty folder is still a valid sharing
+		if s.SourceId == 0 {
+			return false
+		}
+	} else if len(s.Files) == 0 {
 		return false
 	}
-	if s.Creator == nil || !s.Creator.CanShare() {
+	if s.Creator == nil {
 		return false
 	}
 	if s.Expires != nil && !s.Expires.IsZero() && s.Expires.Before(time.Now()) {
@@ -42,6 +66,11 @@ func (s *Sharing) Valid() bool {
 	return true
 }
 
+// SingleRoot reports whether the only shared object is the root of the sharing
+func (s *Sharing) SingleRoot() bool {
+	return !s.FromFavoriteFolder() && len(s.Files) == 1
+}
+
 func (s *Sharing) Verify(pwd string) bool {
 	return s.Pwd == "" || s.Pwd == pwd
 }
@@ -17,8 +17,10 @@ type Storage struct {
 	Disabled        bool      `json:"disabled"` // if disabled
 	DisableIndex    bool      `json:"disable_index"`
 	EnableSign      bool      `json:"enable_sign"`
+	RecycleBin      bool      `json:"recycle_bin"` // move the removed objects into a hidden folder
 	Sort
 	Proxy
+	Versioning
 }
 
 type Sort struct {
@@ -27,6 +29,13 @@ type Sort struct {
 	ExtractFolder  string `json:"extract_folder"`
 }
 
+// Versioning keeps the overwritten files in a .versions folder next to them
+type Versioning struct {
+	EnableVersions bool `json:"enable_versions"`
+	MaxVersions    int  `json:"max_versions"`    // versions kept of a file, 0 keeps all of them
+	MaxVersionAge  int  `json:"max_version_age"` // days to keep a version, 0 keeps it forever
+}
+
 type Proxy struct {
 	WebProxy     bool   `json:"web_proxy"`
 	WebdavPolicy string `json:"webdav_policy"`
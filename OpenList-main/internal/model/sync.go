@@ -0,0 +1,19 @@
+package model
+
+// sync actions
+const (
+	SyncMkdir    = "mkdir"
+	SyncCopy     = "copy"
+	SyncDelete   = "delete"
+	SyncConflict = "conflict"
+)
+
+// SyncAction is a step of a sync plan, Path is relative to the synced directories
+type SyncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	// Reverse actions go from the destination to the source, they only happen in two-way sync
+	Reverse bool   `json:"reverse"`
+	Size    int64  `json:"size"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// TrashDirName is the hidden folder at the root of a storage the removed objects are moved into,
+// the objects of storages not able to move are renamed in place with it as a prefix
+const TrashDirName = ".openlist_trash"
+
+// TrashItem is an object moved to the recycle bin of its storage instead of being removed.
+// Path is the mount path it was removed from, ActualPath and TrashPath are paths in the storage.
+type TrashItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	StorageId  uint      `json:"storage_id" gorm:"index"`
+	Path       string    `json:"path"`
+	ActualPath string    `json:"-"`
+	TrashPath  string    `json:"-"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	IsDir      bool      `json:"is_dir"`
+	UserId     uint      `json:"user_id" gorm:"index"`
+	Username   string    `json:"username"`
+	RemovedAt  time.Time `json:"removed_at" gorm:"index"`
+}
+
+// TrashQuery filters the items of the recycle bins, a zero UserId matches everyone
+type TrashQuery struct {
+	PageReq
+	Path   string `json:"path" form:"path"`
+	UserId uint   `json:"-" form:"-"`
+}
+
+// IsTrashName tells if name is the trash folder or an object renamed into the trash
+func IsTrashName(name string) bool {
+	return strings.HasPrefix(name, TrashDirName)
+}
@@ -55,10 +55,18 @@ type User struct {
 	//   12: can read archives
 	//   13: can decompress archives
 	//   14: can share
-	Permission int32  `json:"permission"`
-	OtpSecret  string `json:"-"`
-	SsoID      string `json:"sso_id"` // unique by sso platform
-	Authn      string `gorm:"type:text" json:"-"`
+	Permission int32 `json:"permission"`
+	// GroupIds are the groups of the user, whose permissions add up to Permission
+	GroupIds []uint `json:"group_ids" gorm:"serializer:json;type:text"`
+	// Groups are loaded together with the user from GroupIds
+	Groups []Group `json:"-" gorm:"-"`
+	// UploadQuota limits the bytes uploaded in total, 0 means unlimited
+	UploadQuota int64 `json:"upload_quota"`
+	// DownloadCap limits the bytes downloaded per month, 0 means unlimited
+	DownloadCap int64  `json:"download_cap"`
+	OtpSecret   string `json:"-"`
+	SsoID       string `json:"sso_id"` // unique by sso platform
+	Authn       string `gorm:"type:text" json:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -90,68 +98,129 @@ func (u *User) SetPassword(pwd string) *User {
 	return u
 }
 
+// EffectivePermission is the permission of the user together with the ones of its groups
+func (u *User) EffectivePermission() int32 {
+	p := u.Permission
+	for _, g := range u.Groups {
+		p |= g.Permission
+	}
+	return p
+}
+
+// GetBasePath returns the base path of the user, or the one of its first group
+// that has a base path when the user has the root
+func (u *User) GetBasePath() string {
+	basePath := utils.FixAndCleanPath(u.BasePath)
+	if basePath != "/" {
+		return basePath
+	}
+	for _, g := range u.Groups {
+		if p := utils.FixAndCleanPath(g.BasePath); p != "/" {
+			return p
+		}
+	}
+	return basePath
+}
+
+// AllowedStorages returns the mount paths the user can access, nil means all storages
+func (u *User) AllowedStorages() []string {
+	var storages []string
+	for _, g := range u.Groups {
+		s := g.GetAllowedStorages()
+		if len(s) == 0 {
+			return nil
+		}
+		storages = append(storages, s...)
+	}
+	return storages
+}
+
+// CanAccessStorageOf tells if the path is in, or leads to, a storage allowed by the groups of the user
+func (u *User) CanAccessStorageOf(path string) bool {
+	if u.IsAdmin() {
+		return true
+	}
+	storages := u.AllowedStorages()
+	if storages == nil {
+		return true
+	}
+	for _, mountPath := range storages {
+		if utils.IsSubPath(mountPath, path) || utils.IsSubPath(path, mountPath) {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *User) CanSeeHides() bool {
-	return u.Permission&1 == 1
+	return u.EffectivePermission()&1 == 1
 }
 
 func (u *User) CanAccessWithoutPassword() bool {
-	return (u.Permission>>1)&1 == 1
+	return (u.EffectivePermission()>>1)&1 == 1
 }
 
 func (u *User) CanAddOfflineDownloadTasks() bool {
-	return (u.Permission>>2)&1 == 1
+	return (u.EffectivePermission()>>2)&1 == 1
 }
 
 func (u *User) CanWrite() bool {
-	return (u.Permission>>3)&1 == 1
+	return (u.EffectivePermission()>>3)&1 == 1
 }
 
 func (u *User) CanRename() bool {
-	return (u.Permission>>4)&1 == 1
+	return (u.EffectivePermission()>>4)&1 == 1
 }
 
 func (u *User) CanMove() bool {
-	return (u.Permission>>5)&1 == 1
+	return (u.EffectivePermission()>>5)&1 == 1
 }
 
 func (u *User) CanCopy() bool {
-	return (u.Permission>>6)&1 == 1
+	return (u.EffectivePermission()>>6)&1 == 1
 }
 
 func (u *User) CanRemove() bool {
-	return (u.Permission>>7)&1 == 1
+	return (u.EffectivePermission()>>7)&1 == 1
 }
 
 func (u *User) CanWebdavRead() bool {
-	return (u.Permission>>8)&1 == 1
+	return (u.EffectivePermission()>>8)&1 == 1
 }
 
 func (u *User) CanWebdavManage() bool {
-	return (u.Permission>>9)&1 == 1
+	return (u.EffectivePermission()>>9)&1 == 1
 }
 
 func (u *User) CanFTPAccess() bool {
-	return (u.Permission>>10)&1 == 1
+	return (u.EffectivePermission()>>10)&1 == 1
 }
 
 func (u *User) CanFTPManage() bool {
-	return (u.Permission>>11)&1 == 1
+	return (u.EffectivePermission()>>11)&1 == 1
 }
 
 func (u *User) CanReadArchives() bool {
-	return (u.Permission>>12)&1 == 1
+	return (u.EffectivePermission()>>12)&1 == 1
 }
 
 func (u *User) CanDecompress() bool {
-	return (u.Permission>>13)&1 == 1
+	return (u.EffectivePermission()>>13)&1 == 1
 }
 
 func (u *User) CanShare() bool {
-	return (u.Permission>>14)&1 == 1
+	return (u.EffectivePermission()>>14)&1 == 1
 }
 
 func (u *User) JoinPath(reqPath string) (string, error) {
-	return utils.JoinBasePath(u.BasePath, reqPath)
+	path, err := utils.JoinBasePath(u.GetBasePath(), reqPath)
+	if err != nil {
+		return "", err
+	}
+	if !u.CanAccessStorageOf(path) {
+		return "", errors.WithStack(errs.PermissionDenied)
+	}
+	return path, nil
 }
 
 func StaticHash(password string) string {
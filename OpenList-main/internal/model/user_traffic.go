@@ -0,0 +1,20 @@
+package model
+
+// UserTraffic is the bytes a user uploaded and downloaded in a month
+type UserTraffic struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserId     uint   `json:"user_id" gorm:"uniqueIndex:idx_user_traffic_month"`
+	Month      string `json:"month" gorm:"size:7;uniqueIndex:idx_user_traffic_month"` // such as 2006-01
+	Uploaded   int64  `json:"uploaded"`
+	Downloaded int64  `json:"downloaded"`
+}
+
+// UserUsage is the traffic of a user against the limits
+type UserUsage struct {
+	Month           string `json:"month"`
+	Uploaded        int64  `json:"uploaded"` // in total
+	UploadQuota     int64  `json:"upload_quota"`
+	MonthUploaded   int64  `json:"month_uploaded"`
+	MonthDownloaded int64  `json:"month_downloaded"`
+	DownloadCap     int64  `json:"download_cap"`
+}
@@ -339,7 +339,7 @@ func removeObjTemp(t *TransferTask) {
 	if err != nil || srcObj.IsDir() {
 		return
 	}
-	if err := op.Remove(t.Ctx(), t.SrcStorage, t.SrcActualPath); err != nil {
+	if err := op.RemovePermanently(t.Ctx(), t.SrcStorage, t.SrcActualPath); err != nil {
 		log.Errorf("failed to delete temp obj %s, error: %s", t.SrcActualPath, err.Error())
 	}
 }
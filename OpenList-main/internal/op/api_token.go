@@ -0,0 +1,117 @@
+package op
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateAPIToken creates a token of user, the returned plain token is not stored and can't be shown again
+func CreateAPIToken(user *model.User, t *model.APIToken) (string, error) {
+	if t.Permission&^user.EffectivePermission() != 0 {
+		return "", errors.New("token permissions must be a subset of the user's permissions")
+	}
+	t.PathPrefix = utils.FixAndCleanPath(t.PathPrefix)
+	if _, err := user.JoinPath(t.PathPrefix); err != nil {
+		return "", err
+	}
+	if t.IsExpired() {
+		return "", errors.New("expiry is in the past")
+	}
+	token := model.APITokenPrefix + random.String(40)
+	t.ID = 0
+	t.UserId = user.ID
+	t.TokenHash = model.HashAPIToken(token)
+	t.TokenPrefix = token[:len(model.APITokenPrefix)+6]
+	t.CreatedAt = time.Now()
+	t.LastUsedAt = nil
+	return token, db.CreateAPIToken(t)
+}
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUserId(userId, pageIndex, pageSize)
+}
+
+func DeleteAPITokenByIdAndUserId(id, userId uint) error {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return err
+	}
+	if t.UserId != userId {
+		return errors.WithStack(errs.InvalidAPIToken)
+	}
+	return db.DeleteAPITokenById(id)
+}
+
+// GetUserByAPIToken returns the user of the token, restricted by the token
+func GetUserByAPIToken(token string) (*model.User, *model.APIToken, error) {
+	t, err := db.GetAPITokenByHash(model.HashAPIToken(token))
+	if err != nil {
+		return nil, nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return userOfAPIToken(t)
+}
+
+// GetUserByS3AccessKeyId returns the user of the token of the s3 access key id, restricted by the token,
+// together with the secret access key the requests must be signed with
+func GetUserByS3AccessKeyId(accessKeyId string) (*model.User, string, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(accessKeyId, model.APITokenPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(accessKeyId, model.APITokenPrefix) {
+		return nil, "", errors.WithStack(errs.InvalidAPIToken)
+	}
+	t, err := db.GetAPITokenById(uint(id))
+	if err != nil {
+		return nil, "", errors.WithStack(errs.InvalidAPIToken)
+	}
+	user, t, err := userOfAPIToken(t)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, APITokenS3Secret(t), nil
+}
+
+// APITokenS3Secret is the secret access key of the token for the s3 server. SigV4 needs the plain
+// secret to verify the signatures while only the hash of the token is stored, so it is derived from
+// the hash with the jwt secret, changing the jwt secret changes the secrets of all the tokens
+func APITokenS3Secret(t *model.APIToken) string {
+	h := hmac.New(sha256.New, []byte(conf.Conf.JwtSecret))
+	h.Write([]byte(t.TokenHash))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func userOfAPIToken(t *model.APIToken) (*model.User, *model.APIToken, error) {
+	if t.IsExpired() {
+		return nil, nil, errors.WithStack(errs.APITokenExpired)
+	}
+	user, err := db.GetUserById(t.UserId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errors.New("the user of the token is disabled")
+	}
+	scoped, err := t.Scope(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	// webdav clients send the token with every request, there is no need to write each of them
+	if now := time.Now(); t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > time.Minute {
+		if err := db.SetAPITokenLastUsed(t.ID, now); err != nil {
+			log.Warnf("failed update last used time of api token %d: %+v", t.ID, err)
+		}
+		t.LastUsedAt = &now
+	}
+	return scoped, t, nil
+}
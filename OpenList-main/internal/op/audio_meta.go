@@ -0,0 +1,235 @@
+package op
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/audiotag"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+)
+
+const (
+	audioMetaCacheExpiration = 24 * time.Hour
+	// tags with large cover art may take a few MB, reading more means the file is not what it claims
+	maxAudioMetaRead   = 16 << 20
+	audioMetaBlockSize = 64 << 10
+)
+
+// audioMetaCache keeps the tags without the cover art, which would take up to maxAudioMetaRead per file
+var audioMetaCache = cache.NewMemCache(cache.WithShards[*model.AudioMeta](16))
+var audioMetaG singleflight.Group[*model.AudioMeta]
+var audioCoverG singleflight.Group[*model.AudioMeta]
+
+func getAudioObj(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	obj, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get obj")
+	}
+	if obj.IsDir() || utils.GetFileType(obj.GetName()) != conf.AUDIO {
+		return nil, errors.New("not an audio file")
+	}
+	return obj, nil
+}
+
+// cacheAudioMeta caches the tags without the cover art and returns them
+func cacheAudioMeta(key string, meta *model.AudioMeta) *model.AudioMeta {
+	cached := *meta
+	cached.Cover = nil
+	audioMetaCache.Set(key, &cached, cache.WithEx[*model.AudioMeta](audioMetaCacheExpiration))
+	return &cached
+}
+
+// GetAudioMeta reads the embedded tags of the audio file, the result is cached by the
+// fingerprint of the file so that renamed or moved files are not read again.
+// The cover art is left out, see GetAudioCover
+func GetAudioMeta(ctx context.Context, storage driver.Driver, path string) (*model.AudioMeta, error) {
+	obj, err := getAudioObj(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	key := BuildMediaFingerprint(storage, obj)
+	if meta, ok := audioMetaCache.Get(key); ok {
+		return meta, nil
+	}
+	meta, err, _ := audioMetaG.Do(key, func() (*model.AudioMeta, error) {
+		meta, err := readAudioMeta(ctx, storage, path, obj.GetSize())
+		if err != nil {
+			return nil, err
+		}
+		return cacheAudioMeta(key, meta), nil
+	})
+	return meta, err
+}
+
+// GetAudioCover reads the embedded tags of the audio file together with the cover art,
+// the cover art is read again on every call as it is not cached
+func GetAudioCover(ctx context.Context, storage driver.Driver, path string) (*model.AudioMeta, error) {
+	obj, err := getAudioObj(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	key := BuildMediaFingerprint(storage, obj)
+	if meta, ok := audioMetaCache.Get(key); ok && !meta.HasCover {
+		return meta, nil
+	}
+	meta, err, _ := audioCoverG.Do(key, func() (*model.AudioMeta, error) {
+		meta, err := readAudioMeta(ctx, storage, path, obj.GetSize())
+		if err != nil {
+			return nil, err
+		}
+		cacheAudioMeta(key, meta)
+		return meta, nil
+	})
+	return meta, err
+}
+
+// ReadAudioMeta reads the embedded tags of the audio file without caching them,
+// for the callers reading many files once such as indexing
+func ReadAudioMeta(ctx context.Context, storage driver.Driver, path string) (*model.AudioMeta, error) {
+	obj, err := getAudioObj(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	if meta, ok := audioMetaCache.Get(BuildMediaFingerprint(storage, obj)); ok {
+		return meta, nil
+	}
+	return readAudioMeta(ctx, storage, path, obj.GetSize())
+}
+
+func readAudioMeta(ctx context.Context, storage driver.Driver, path string, size int64) (*model.AudioMeta, error) {
+	link, _, err := Link(ctx, storage, path, model.LinkArgs{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get link")
+	}
+	defer link.Close()
+	if link.ContentLength > 0 {
+		size = link.ContentLength
+	}
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := audiotag.Read(&rangeReadSeeker{ctx: ctx, rr: rr, size: size, blocks: map[int64][]byte{}}, size)
+	if errors.Is(err, audiotag.ErrNotAudio) {
+		// remember files without tags as well, they would be read again on every request otherwise
+		return &model.AudioMeta{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := &model.AudioMeta{
+		Format:      tags.Format,
+		Title:       tags.Title,
+		Artist:      tags.Artist,
+		Album:       tags.Album,
+		AlbumArtist: tags.AlbumArtist,
+		Genre:       tags.Genre,
+		Year:        tags.Year,
+		Track:       tags.Track,
+		TrackTotal:  tags.TrackTotal,
+		Disc:        tags.Disc,
+		DiscTotal:   tags.DiscTotal,
+		Duration:    tags.Duration,
+	}
+	if tags.Picture != nil {
+		meta.HasCover = true
+		meta.CoverType = tags.Picture.MIMEType
+		meta.Cover = tags.Picture.Data
+	}
+	return meta, nil
+}
+
+// rangeReadSeeker reads a remote file in blocks on demand, so that only the head and
+// the tail holding the tags are downloaded
+type rangeReadSeeker struct {
+	ctx    context.Context
+	rr     model.RangeReaderIF
+	size   int64
+	off    int64
+	read   int64
+	blocks map[int64][]byte
+}
+
+func (r *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before the start of the file")
+	}
+	r.off = offset
+	return offset, nil
+}
+
+func (r *rangeReadSeeker) Read(p []byte) (int, error) {
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+	end := min(r.off+int64(len(p)), r.size)
+	first, last := r.off/audioMetaBlockSize, (end-1)/audioMetaBlockSize
+	// fetch the missing blocks in as few requests as possible
+	for b := first; b <= last; {
+		if _, ok := r.blocks[b]; ok {
+			b++
+			continue
+		}
+		missing := b
+		for missing <= last {
+			if _, ok := r.blocks[missing]; ok {
+				break
+			}
+			missing++
+		}
+		if err := r.fetch(b, missing); err != nil {
+			return 0, err
+		}
+		b = missing
+	}
+	n := 0
+	for r.off < end {
+		block := r.blocks[r.off/audioMetaBlockSize]
+		c := copy(p[n:], block[r.off%audioMetaBlockSize:])
+		if c == 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		n += c
+		r.off += int64(c)
+	}
+	return n, nil
+}
+
+// fetch downloads the blocks [from, to)
+func (r *rangeReadSeeker) fetch(from, to int64) error {
+	start := from * audioMetaBlockSize
+	length := min(to*audioMetaBlockSize, r.size) - start
+	if r.read+length > maxAudioMetaRead {
+		return errors.New("audio tags are too large")
+	}
+	r.read += length
+	rc, err := r.rr.RangeRead(r.ctx, http_range.Range{Start: start, Length: length})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	buf := make([]byte, length)
+	if _, err = io.ReadFull(rc, buf); err != nil {
+		return errors.WithStack(err)
+	}
+	for b := from; b < to; b++ {
+		off := (b - from) * audioMetaBlockSize
+		r.blocks[b] = buf[off:min(off+audioMetaBlockSize, length)]
+	}
+	return nil
+}
@@ -127,6 +127,11 @@ func (cm *CacheManager) DeleteUser(username string) {
 	cm.userCache.Delete(username)
 }
 
+// remove all users from cache
+func (cm *CacheManager) ClearUsers() {
+	cm.userCache.Clear()
+}
+
 // caches setting
 func (cm *CacheManager) SetSetting(key string, setting *model.SettingItem) {
 	cm.settingCache.Set(key, setting)
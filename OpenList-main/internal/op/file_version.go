@@ -0,0 +1,183 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// inVersions tells if the actual path is in a versions folder, the files there are not versioned
+func inVersions(path string) bool {
+	return utils.SliceContains(strings.Split(utils.FixAndCleanPath(path), "/"), model.VersionsDirName)
+}
+
+// shouldKeepVersion tells if the file should be kept as a version before being overwritten,
+// the storage has to be able to move and rename
+func shouldKeepVersion(storage driver.Driver, path string) bool {
+	if !storage.GetStorage().EnableVersions || inVersions(path) {
+		return false
+	}
+	_, canMove := storage.(driver.Move)
+	_, canMoveResult := storage.(driver.MoveResult)
+	_, canRename := storage.(driver.Rename)
+	_, canRenameResult := storage.(driver.RenameResult)
+	return (canMove || canMoveResult) && (canRename || canRenameResult)
+}
+
+// saveVersion moves the file to .versions/<name>/<timestamp> next to it,
+// the returned version is not recorded yet
+func saveVersion(ctx context.Context, storage driver.Driver, path string, obj model.Obj) (*model.FileVersion, error) {
+	dir, name := stdpath.Split(path)
+	versionsDir := stdpath.Join(dir, model.VersionsDirName, name)
+	if err := MakeDir(ctx, storage, versionsDir); err != nil {
+		return nil, errors.WithMessage(err, "failed make versions dir")
+	}
+	if _, _, err := move(ctx, storage, path, versionsDir); err != nil {
+		return nil, errors.WithMessage(err, "failed move to versions dir")
+	}
+	stamp := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if _, _, err := rename(ctx, storage, stdpath.Join(versionsDir, name), stamp); err != nil {
+		if _, _, err := move(ctx, storage, stdpath.Join(versionsDir, name), dir); err != nil {
+			log.Errorf("failed move %s back from versions dir: %+v", path, err)
+		}
+		return nil, errors.WithMessage(err, "failed rename version")
+	}
+	v := &model.FileVersion{
+		StorageId:   storage.GetStorage().ID,
+		ActualPath:  path,
+		Path:        stdpath.Join(storage.GetStorage().MountPath, path),
+		VersionPath: stdpath.Join(versionsDir, stamp),
+		Size:        obj.GetSize(),
+		Modified:    obj.ModTime(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		v.UserId = user.ID
+		v.Username = user.Username
+	}
+	return v, nil
+}
+
+// restoreVersionFile moves the file of the version back to the path of the file
+func restoreVersionFile(ctx context.Context, storage driver.Driver, v *model.FileVersion) error {
+	versionsDir := stdpath.Dir(v.VersionPath)
+	name := stdpath.Base(v.ActualPath)
+	if _, _, err := rename(ctx, storage, v.VersionPath, name); err != nil {
+		return errors.WithMessage(err, "failed rename version")
+	}
+	if _, _, err := move(ctx, storage, stdpath.Join(versionsDir, name), stdpath.Dir(v.ActualPath)); err != nil {
+		return errors.WithMessage(err, "failed move version back")
+	}
+	return nil
+}
+
+// recordVersion saves the version and drops the versions of the file beyond the policy of the storage
+func recordVersion(ctx context.Context, storage driver.Driver, v *model.FileVersion) error {
+	if err := db.CreateFileVersion(v); err != nil {
+		return errors.WithMessage(err, "failed save file version")
+	}
+	versions, err := db.GetFileVersions(v.StorageId, v.ActualPath)
+	if err != nil {
+		return err
+	}
+	s := storage.GetStorage()
+	for i := range versions {
+		expired := s.MaxVersionAge > 0 && time.Since(versions[i].CreatedAt) > time.Duration(s.MaxVersionAge)*24*time.Hour
+		if (s.MaxVersions > 0 && i >= s.MaxVersions) || expired {
+			if err := deleteFileVersion(ctx, storage, &versions[i]); err != nil {
+				log.Errorf("failed delete old version of %s: %+v", v.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func deleteFileVersion(ctx context.Context, storage driver.Driver, v *model.FileVersion) error {
+	if err := RemovePermanently(ctx, storage, v.VersionPath); err != nil {
+		return err
+	}
+	return db.DeleteFileVersionById(v.ID)
+}
+
+// GetFileVersions returns the versions of the file at the actual path of the storage, the latest first
+func GetFileVersions(storage driver.Driver, actualPath string) ([]model.FileVersion, error) {
+	return db.GetFileVersions(storage.GetStorage().ID, utils.FixAndCleanPath(actualPath))
+}
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	return db.GetFileVersionById(id)
+}
+
+// RestoreFileVersion puts the version back in place of the file, the current file is kept as a version
+func RestoreFileVersion(ctx context.Context, v *model.FileVersion) error {
+	storage, err := getStorageById(v.StorageId)
+	if err != nil {
+		return err
+	}
+	var current *model.FileVersion
+	if obj, err := GetUnwrap(ctx, storage, v.ActualPath); err == nil {
+		if current, err = saveVersion(ctx, storage, v.ActualPath, obj); err != nil {
+			return errors.WithMessage(err, "failed save the current file")
+		}
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed get the current file")
+	}
+	if err := restoreVersionFile(ctx, storage, v); err != nil {
+		if current != nil {
+			if err := restoreVersionFile(ctx, storage, current); err != nil {
+				log.Errorf("failed put back the current file %s: %+v", v.Path, err)
+			}
+		}
+		return err
+	}
+	HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: v.ActualPath})
+	if err := db.DeleteFileVersionById(v.ID); err != nil {
+		return err
+	}
+	if current != nil {
+		return recordVersion(ctx, storage, current)
+	}
+	return nil
+}
+
+// DeleteFileVersion removes the version permanently
+func DeleteFileVersion(ctx context.Context, v *model.FileVersion) error {
+	storage, err := getStorageById(v.StorageId)
+	if err != nil {
+		return err
+	}
+	return deleteFileVersion(ctx, storage, v)
+}
+
+// PruneExpiredVersions deletes the versions older than the max version age of their storages
+func PruneExpiredVersions(ctx context.Context) (int, error) {
+	pruned := 0
+	for _, storage := range GetAllStorages() {
+		s := storage.GetStorage()
+		if !s.EnableVersions || s.MaxVersionAge <= 0 {
+			continue
+		}
+		versions, err := db.GetFileVersionsBefore(s.ID, time.Now().AddDate(0, 0, -s.MaxVersionAge))
+		if err != nil {
+			return pruned, err
+		}
+		for i := range versions {
+			if err := deleteFileVersion(ctx, storage, &versions[i]); err != nil {
+				log.Errorf("failed delete expired version of %s: %+v", versions[i].Path, err)
+				continue
+			}
+			pruned++
+		}
+	}
+	return pruned, nil
+}
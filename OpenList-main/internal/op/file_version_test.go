@@ -0,0 +1,60 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+func TestFileVersions(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:     "Local",
+		MountPath:  "/versions_test",
+		Versioning: model.Versioning{EnableVersions: true, MaxVersions: 2},
+		Addition:   fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/versions_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string) {
+		file := &stream.FileStream{
+			Obj:    &model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+			Reader: strings.NewReader(content),
+		}
+		if err := op.Put(ctx, storage, "/", file, nil); err != nil {
+			t.Fatalf("failed put: %+v", err)
+		}
+	}
+	for _, content := range []string{"1", "22", "333", "4444"} {
+		put(content)
+	}
+	versions, err := op.GetFileVersions(storage, "/a.txt")
+	if err != nil || len(versions) != 2 || versions[0].Size != 3 || versions[1].Size != 2 {
+		t.Fatalf("unexpected versions %+v, %v", versions, err)
+	}
+
+	if err := op.RestoreFileVersion(ctx, &versions[1]); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(b) != "22" {
+		t.Errorf("expected the restored content, got %s", b)
+	}
+	versions, _ = op.GetFileVersions(storage, "/a.txt")
+	if len(versions) != 2 || versions[0].Size != 4 {
+		t.Errorf("expected the overwritten file to be the latest version, got %+v", versions)
+	}
+}
@@ -232,7 +232,7 @@ func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (
 		return nil, errors.WithMessagef(err, "failed to get obj")
 	}
 	
-	// Handle media marks methods
+	// Handle media marks and progress methods
 	switch args.Method {
 	case "media_marks_list":
 		user := ctx.Value(conf.UserKey).(*model.User)
@@ -246,6 +246,12 @@ func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (
 	case "media_marks_delete":
 		user := ctx.Value(conf.UserKey).(*model.User)
 		return HandleMediaMarksDelete(ctx, storage, obj, user, args.Data)
+	case "media_progress_get":
+		user := ctx.Value(conf.UserKey).(*model.User)
+		return HandleMediaProgressGet(ctx, storage, obj, user)
+	case "media_progress_set":
+		user := ctx.Value(conf.UserKey).(*model.User)
+		return HandleMediaProgressSet(ctx, storage, obj, user, args.Data)
 	}
 	
 	// Default behavior: delegate to storage driver
@@ -284,9 +290,9 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
 				}
 
+				var newObj model.Obj
 				switch s := storage.(type) {
 				case driver.MkdirResult:
-					var newObj model.Obj
 					newObj, err = s.MakeDir(ctx, parentDir, dirName)
 					if err == nil {
 						if newObj != nil {
@@ -307,6 +313,9 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 				default:
 					return nil, errs.NotImplement
 				}
+				if err == nil {
+					HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: path, Obj: newObj})
+				}
 				return nil, errors.WithStack(err)
 			}
 			return nil, errors.WithMessage(err, "failed to check if dir exists")
@@ -322,28 +331,46 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 }
 
 func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string, lazyCache ...bool) error {
+	srcObj, newObj, err := move(ctx, storage, srcPath, dstDirPath, lazyCache...)
+	if err == nil {
+		srcPath = utils.FixAndCleanPath(srcPath)
+		HandleObjMoveHook(ctx, ObjMove{
+			SrcStorage: storage,
+			SrcPath:    srcPath,
+			SrcObj:     srcObj,
+			DstStorage: storage,
+			DstPath:    stdpath.Join(utils.FixAndCleanPath(dstDirPath), srcObj.GetName()),
+			DstObj:     newObj,
+		})
+	}
+	return err
+}
+
+// move moves the object without calling the move hooks,
+// it returns the object before moving and the moved one if the driver provides it
+func move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string, lazyCache ...bool) (model.Obj, model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
-		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+		return nil, nil, errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	srcDirPath := stdpath.Dir(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	if dstDirPath == srcDirPath {
-		return stderrors.New("move in place")
+		return nil, nil, stderrors.New("move in place")
 	}
 	srcRawObj, err := Get(ctx, storage, srcPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed to get src object")
+		return nil, nil, errors.WithMessage(err, "failed to get src object")
 	}
 	srcObj := model.UnwrapObj(srcRawObj)
 	dstDir, err := GetUnwrap(ctx, storage, dstDirPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed to get dst dir")
+		return nil, nil, errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.MoveResult:
-		var newObj model.Obj
 		newObj, err = s.Move(ctx, srcObj, dstDir)
 		if err == nil {
 			Cache.removeDirectoryObject(storage, srcDirPath, srcRawObj)
@@ -362,25 +389,43 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	default:
-		return errs.NotImplement
+		return nil, nil, errs.NotImplement
 	}
-	return errors.WithStack(err)
+	return srcObj, newObj, errors.WithStack(err)
 }
 
 func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string, lazyCache ...bool) error {
+	srcObj, newObj, err := rename(ctx, storage, srcPath, dstName, lazyCache...)
+	if err == nil {
+		srcPath = utils.FixAndCleanPath(srcPath)
+		HandleObjMoveHook(ctx, ObjMove{
+			SrcStorage: storage,
+			SrcPath:    srcPath,
+			SrcObj:     srcObj,
+			DstStorage: storage,
+			DstPath:    stdpath.Join(stdpath.Dir(srcPath), dstName),
+			DstObj:     newObj,
+		})
+	}
+	return err
+}
+
+// rename renames the object without calling the move hooks,
+// it returns the object before renaming and the renamed one if the driver provides it
+func rename(ctx context.Context, storage driver.Driver, srcPath, dstName string, lazyCache ...bool) (model.Obj, model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
-		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+		return nil, nil, errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	srcRawObj, err := Get(ctx, storage, srcPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed to get src object")
+		return nil, nil, errors.WithMessage(err, "failed to get src object")
 	}
 	srcObj := model.UnwrapObj(srcRawObj)
 
+	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.RenameResult:
-		var newObj model.Obj
 		newObj, err = s.Rename(ctx, srcObj, dstName)
 		if err == nil {
 			srcDirPath := stdpath.Dir(srcPath)
@@ -403,9 +448,9 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 			}
 		}
 	default:
-		return errs.NotImplement
+		return nil, nil, errs.NotImplement
 	}
-	return errors.WithStack(err)
+	return srcObj, newObj, errors.WithStack(err)
 }
 
 // Copy Just copy file[s] in a storage
@@ -428,9 +473,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.CopyResult:
-		var newObj model.Obj
 		newObj, err = s.Copy(ctx, srcObj, dstDir)
 		if err == nil {
 			if newObj != nil {
@@ -449,10 +494,30 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		HandleObjChangeHook(ctx, ObjChange{
+			Storage: storage,
+			Path:    stdpath.Join(dstDirPath, srcObj.GetName()),
+			Obj:     newObj,
+		})
+	}
 	return errors.WithStack(err)
 }
 
+// Remove removes the object, or moves it to the recycle bin if the storage has one
 func Remove(ctx context.Context, storage driver.Driver, path string) error {
+	if storage.GetStorage().RecycleBin && !inTrash(path) {
+		err := moveToTrash(ctx, storage, path)
+		if err == nil {
+			HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: utils.FixAndCleanPath(path), Removed: true})
+		}
+		return err
+	}
+	return RemovePermanently(ctx, storage, path)
+}
+
+// RemovePermanently removes the object bypassing the recycle bin
+func RemovePermanently(ctx context.Context, storage driver.Driver, path string) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -476,6 +541,7 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		err = s.Remove(ctx, model.UnwrapObj(rawObj))
 		if err == nil {
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
+			HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: path, Removed: true})
 		}
 	default:
 		return errs.NotImplement
@@ -505,15 +571,21 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempName := file.GetName() + ".openlist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
+	var version *model.FileVersion
 	if err == nil {
 		if fi.GetSize() == 0 {
-			err = Remove(ctx, storage, dstPath)
+			err = RemovePermanently(ctx, storage, dstPath)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
+		} else if !fi.IsDir() && shouldKeepVersion(storage, dstPath) {
+			version, err = saveVersion(ctx, storage, dstPath, fi)
+			if err != nil {
+				return errors.WithMessagef(err, "while uploading, failed keep the version of existing file")
+			}
 		} else if storage.Config().NoOverwriteUpload {
 			// try to rename old obj
-			err = Rename(ctx, storage, dstPath, tempName)
+			_, _, err = rename(ctx, storage, dstPath, tempName)
 			if err != nil {
 				return err
 			}
@@ -540,9 +612,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		log.Warnf("file size < 0, try to get full size from cache")
 		file.CacheFullAndWriter(nil, nil)
 	}
+	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.PutResult:
-		var newObj model.Obj
 		newObj, err = s.Put(ctx, parentDir, file, up)
 		if err == nil {
 			Cache.linkCache.DeleteKey(Key(storage, dstPath))
@@ -564,18 +636,30 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	log.Debugf("put file [%s] done", file.GetName())
-	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
+	if version != nil {
+		if err != nil {
+			// upload failed, put the old file back
+			if err := restoreVersionFile(ctx, storage, version); err != nil {
+				log.Errorf("failed recover old obj: %+v", err)
+			}
+		} else if err := recordVersion(ctx, storage, version); err != nil {
+			log.Errorf("failed record version of %s: %+v", version.Path, err)
+		}
+	} else if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
-			err := Rename(ctx, storage, tempPath, file.GetName())
+			_, _, err := rename(ctx, storage, tempPath, file.GetName())
 			if err != nil {
 				log.Errorf("failed recover old obj: %+v", err)
 			}
 		} else {
 			// upload success, remove old obj
-			err = Remove(ctx, storage, tempPath)
+			err = RemovePermanently(ctx, storage, tempPath)
 		}
 	}
+	if err == nil {
+		HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: dstPath, Obj: newObj})
+	}
 	return errors.WithStack(err)
 }
 
@@ -597,9 +681,9 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if err != nil {
 		return errors.WithMessagef(err, "failed to get dir [%s]", dstDirPath)
 	}
+	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.PutURLResult:
-		var newObj model.Obj
 		newObj, err = s.PutURL(ctx, dstDir, dstName, url)
 		if err == nil {
 			Cache.linkCache.DeleteKey(Key(storage, dstPath))
@@ -621,6 +705,9 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 		return errors.WithStack(errs.NotImplement)
 	}
 	log.Debugf("put url [%s](%s) done", dstName, url)
+	if err == nil {
+		HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: dstPath, Obj: newObj})
+	}
 	return errors.WithStack(err)
 }
 
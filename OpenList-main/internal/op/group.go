@@ -0,0 +1,116 @@
+package op
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// clearGroupedUsers drops the cached users, which hold the groups loaded with them
+func clearGroupedUsers() {
+	Cache.ClearUsers()
+	adminUser = nil
+	guestUser = nil
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func CreateGroup(g *model.Group) error {
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	defer clearGroupedUsers()
+	return db.UpdateGroup(g)
+}
+
+// DeleteGroupById deletes the group and removes it from its members
+func DeleteGroupById(id uint) error {
+	defer clearGroupedUsers()
+	if err := db.DeleteGroupById(id); err != nil {
+		return err
+	}
+	page := 1
+	for {
+		users, _, err := db.GetUsers(page, 100)
+		if err != nil {
+			return errors.WithMessage(err, "failed remove group from users")
+		}
+		for i := range users {
+			u := &users[i]
+			if !slices.Contains(u.GroupIds, id) {
+				continue
+			}
+			u.GroupIds = slices.DeleteFunc(u.GroupIds, func(gid uint) bool { return gid == id })
+			if err := db.UpdateUser(u); err != nil {
+				return errors.WithMessagef(err, "failed remove group from user %s", u.Username)
+			}
+		}
+		if len(users) < 100 {
+			return nil
+		}
+		page++
+	}
+}
+
+// SyncExternalGroups sets the groups of the user that are mapped from LDAP or SSO to the ones
+// of externalGroups, the groups without a mapping are managed by hand and kept as they are
+func SyncExternalGroups(user *model.User, externalGroups []string) error {
+	groups, err := db.GetAllGroups()
+	if err != nil {
+		return err
+	}
+	var groupIds []uint
+	for _, g := range groups {
+		mapped := g.GetExternalGroups()
+		if len(mapped) == 0 {
+			if slices.Contains(user.GroupIds, g.ID) {
+				groupIds = append(groupIds, g.ID)
+			}
+			continue
+		}
+		if slices.ContainsFunc(mapped, func(name string) bool {
+			return slices.ContainsFunc(externalGroups, func(e string) bool { return strings.EqualFold(e, name) })
+		}) {
+			groupIds = append(groupIds, g.ID)
+		}
+	}
+	if sameGroupIds(groupIds, user.GroupIds) {
+		return nil
+	}
+	user.GroupIds = groupIds
+	Cache.DeleteUser(user.Username)
+	if err := db.UpdateUser(user); err != nil {
+		return err
+	}
+	// reload the groups of the user
+	updated, err := db.GetUserById(user.ID)
+	if err != nil {
+		return err
+	}
+	user.Groups = updated.Groups
+	return nil
+}
+
+// sameGroupIds reports whether a and b hold the same group ids, in whatever order
+func sameGroupIds(a, b []uint) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
@@ -0,0 +1,38 @@
+package op_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSyncExternalGroupsKeepsOrder(t *testing.T) {
+	mapped := &model.Group{Name: "sync-mapped", BasePath: "/", ExternalGroups: "staff"}
+	manual := &model.Group{Name: "sync-manual", BasePath: "/"}
+	for _, g := range []*model.Group{mapped, manual} {
+		if err := op.CreateGroup(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := []uint{manual.ID, mapped.ID}
+	user := &model.User{Username: "sync-groups", BasePath: "/", GroupIds: slices.Clone(ids)}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.SyncExternalGroups(user, []string{"Staff"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(user.GroupIds, ids) {
+		t.Errorf("unchanged groups were rewritten: got %v, want %v", user.GroupIds, ids)
+	}
+
+	if err := op.SyncExternalGroups(user, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint{manual.ID}; !slices.Equal(user.GroupIds, want) {
+		t.Errorf("after leaving the external group: got %v, want %v", user.GroupIds, want)
+	}
+}
@@ -30,6 +30,58 @@ func HandleObjsUpdateHook(ctx context.Context, parent string, objs []model.Obj)
 	}
 }
 
+// ObjMove describes an object which has been renamed or moved.
+// Paths are actual paths in their storages, SrcObj and DstObj may be nil if unknown.
+type ObjMove struct {
+	SrcStorage driver.Driver
+	SrcPath    string
+	SrcObj     model.Obj
+	DstStorage driver.Driver
+	DstPath    string
+	DstObj     model.Obj
+}
+
+type ObjMoveHook = func(ctx context.Context, move ObjMove)
+
+var objMoveHooks = make([]ObjMoveHook, 0)
+
+func RegisterObjMoveHook(hook ObjMoveHook) {
+	objMoveHooks = append(objMoveHooks, hook)
+}
+
+func HandleObjMoveHook(ctx context.Context, move ObjMove) {
+	for _, hook := range objMoveHooks {
+		hook(ctx, move)
+	}
+}
+
+// ObjChange describes an object which has been created, overwritten or removed.
+// Path is the actual path in its storage, Obj may be nil if unknown and is nil if removed.
+type ObjChange struct {
+	Storage driver.Driver
+	Path    string
+	Obj     model.Obj
+	Removed bool
+}
+
+type ObjChangeHook = func(ctx context.Context, change ObjChange)
+
+var objChangeHooks = make([]ObjChangeHook, 0)
+
+func RegisterObjChangeHook(hook ObjChangeHook) {
+	objChangeHooks = append(objChangeHooks, hook)
+}
+
+func HandleObjChangeHook(ctx context.Context, change ObjChange) {
+	// the recycle bin and the kept versions are internal
+	if inTrash(change.Path) || inVersions(change.Path) {
+		return
+	}
+	for _, hook := range objChangeHooks {
+		hook(ctx, change)
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
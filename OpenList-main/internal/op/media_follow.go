@@ -0,0 +1,94 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// BuildClientMediaFingerprint mirrors buildMediaFingerprint of the web frontend,
+// which hashes "name_size" with 32-bit FNV-1a over UTF-16 code units
+func BuildClientMediaFingerprint(name string, size int64) string {
+	hash := uint32(2166136261)
+	for _, c := range utf16.Encode([]rune(fmt.Sprintf("%s_%d", name, size))) {
+		hash ^= uint32(c)
+		hash *= 16777619
+	}
+	return fmt.Sprintf("%08x", hash)
+}
+
+// followMovedMedia rewrites the favorites, media marks and playback progresses
+// of a renamed or moved object so that they keep pointing at it
+func followMovedMedia(ctx context.Context, move ObjMove) {
+	srcStorage, dstStorage := move.SrcStorage.GetStorage(), move.DstStorage.GetStorage()
+	srcPath := utils.GetFullPath(srcStorage.MountPath, move.SrcPath)
+	dstPath := utils.GetFullPath(dstStorage.MountPath, move.DstPath)
+
+	// favorites store paths relative to the base path of their owners
+	users, err := db.GetFavoriteOwners()
+	if err != nil {
+		log.Errorf("failed to get users for moved favorites: %+v", err)
+	}
+	// the fingerprints depend on the storage and the object, so they may change with a file
+	var oldClientFp, newClientFp, oldFp, newFp string
+	if move.SrcObj != nil && !move.SrcObj.IsDir() {
+		oldClientFp = BuildClientMediaFingerprint(move.SrcObj.GetName(), move.SrcObj.GetSize())
+		newClientFp = BuildClientMediaFingerprint(stdpath.Base(move.DstPath), move.SrcObj.GetSize())
+		if move.DstObj != nil {
+			oldFp = BuildMediaFingerprint(move.SrcStorage, move.SrcObj)
+			newFp = BuildMediaFingerprint(move.DstStorage, move.DstObj)
+		}
+	}
+	for _, user := range users {
+		basePath := user.GetBasePath()
+		if !utils.IsSubPath(basePath, srcPath) || !utils.IsSubPath(basePath, dstPath) {
+			continue
+		}
+		userDstPath := trimBasePath(basePath, dstPath)
+		err = db.MoveFavoritePaths(user.ID, trimBasePath(basePath, srcPath), userDstPath, dstStorage.ID)
+		if err != nil {
+			log.Errorf("failed to move favorites of user [%s] from [%s] to [%s]: %+v", user.Username, srcPath, dstPath, err)
+			continue
+		}
+		// only the favorites of the moved file get the new fingerprints,
+		// other files may share the same name and size
+		for old, fp := range map[string]string{oldClientFp: newClientFp, oldFp: newFp} {
+			if old == fp {
+				continue
+			}
+			if err = db.UpdateFavoriteFingerprint(user.ID, userDstPath, old, fp); err != nil {
+				log.Errorf("failed to update favorite fingerprints of user [%s] at [%s]: %+v", user.Username, dstPath, err)
+			}
+		}
+	}
+	if err = db.MoveMediaMarkPaths(srcStorage.ID, srcPath, dstPath, dstStorage.ID); err != nil {
+		log.Errorf("failed to move media marks from [%s] to [%s]: %+v", srcPath, dstPath, err)
+	}
+	if err = db.MovePlaybackProgressPaths(srcPath, dstPath, dstStorage.ID); err != nil {
+		log.Errorf("failed to move playback progresses from [%s] to [%s]: %+v", srcPath, dstPath, err)
+	}
+	if oldFp == newFp {
+		return
+	}
+	if err = db.UpdateMediaMarkFingerprint(dstStorage.ID, dstPath, oldFp, newFp); err != nil {
+		log.Errorf("failed to update media mark fingerprints of [%s]: %+v", dstPath, err)
+	}
+	if err = db.UpdatePlaybackProgressFingerprint(dstPath, oldFp, newFp); err != nil {
+		log.Errorf("failed to update playback progress fingerprints of [%s]: %+v", dstPath, err)
+	}
+}
+
+// trimBasePath converts a full path to the path seen by a user with the base path
+func trimBasePath(basePath, path string) string {
+	return utils.FixAndCleanPath(strings.TrimPrefix(path, basePath))
+}
+
+func init() {
+	RegisterObjMoveHook(followMovedMedia)
+}
@@ -0,0 +1,136 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestBuildClientMediaFingerprint(t *testing.T) {
+	// expected values are computed by buildMediaFingerprint of the web frontend
+	tests := []struct {
+		name string
+		size int64
+		want string
+	}{
+		{name: "歌曲 😀.mp3", size: 12345, want: "e7d61ac0"},
+	}
+	for _, tt := range tests {
+		if got := op.BuildClientMediaFingerprint(tt.name, tt.size); got != tt.want {
+			t.Errorf("BuildClientMediaFingerprint(%q, %d) = %s, want %s", tt.name, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFavoritesFollowRename(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "album"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "album", "a.mp3"), []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/follow",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	user := &model.User{Username: "follow", BasePath: "/"}
+	if err = db.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	favorite := &model.Favorite{UserId: user.ID, MediaType: model.FavoriteMediaAudio,
+		OriginalPath: "/follow/album/a.mp3", FileName: "a.mp3"}
+	if err = db.CreateFavorite(favorite); err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := op.GetStorageByMountPath("/follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Rename(ctx, storage, "/album", "renamed"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	got, err := db.GetFavoriteById(favorite.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OriginalPath != "/follow/renamed/a.mp3" {
+		t.Errorf("expected favorite to follow the renamed dir, got %s", got.OriginalPath)
+	}
+}
+
+func TestRenamedFileFingerprintIsScoped(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.mp3"), []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/scoped",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/scoped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []*model.User{{Username: "scoped1", BasePath: "/"}, {Username: "scoped2", BasePath: "/"}}
+	for _, user := range users {
+		if err = db.CreateUser(user); err != nil {
+			t.Fatalf("failed to create user: %+v", err)
+		}
+	}
+	fp := op.BuildClientMediaFingerprint("a.mp3", 5)
+	moved := &model.Favorite{UserId: users[0].ID, MediaType: model.FavoriteMediaAudio,
+		OriginalPath: "/scoped/a.mp3", FileName: "a.mp3", Fingerprint: fp}
+	// another file with the same name and size
+	other := &model.Favorite{UserId: users[1].ID, MediaType: model.FavoriteMediaAudio,
+		OriginalPath: "/elsewhere/a.mp3", FileName: "a.mp3", Fingerprint: fp}
+	for _, favorite := range []*model.Favorite{moved, other} {
+		if err = db.CreateFavorite(favorite); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mark := &model.MediaMark{UserId: users[0].ID, StorageId: storage.GetStorage().ID, OriginalPath: "/scoped/a.mp3"}
+	if err = db.CreateMediaMark(mark); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = op.Rename(ctx, storage, "/a.mp3", "b.mp3"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	got, err := db.GetFavoriteById(moved.ID, users[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OriginalPath != "/scoped/b.mp3" || got.FileName != "b.mp3" || got.Fingerprint != op.BuildClientMediaFingerprint("b.mp3", 5) {
+		t.Errorf("moved favorite = %+v", got)
+	}
+	if got, err = db.GetFavoriteById(other.ID, users[1].ID); err != nil {
+		t.Fatal(err)
+	} else if got.OriginalPath != "/elsewhere/a.mp3" || got.Fingerprint != fp {
+		t.Errorf("unrelated favorite is changed: %+v", got)
+	}
+	gotMark, err := db.GetMediaMarkByIdAndUser(mark.ID, users[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotMark.OriginalPath != "/scoped/b.mp3" {
+		t.Errorf("expected media mark to follow the renamed file, got %s", gotMark.OriginalPath)
+	}
+}
@@ -0,0 +1,158 @@
+package op
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/chapters"
+	"github.com/pkg/errors"
+)
+
+// media marks export formats
+const (
+	MediaMarksFormatVTT        = "vtt"
+	MediaMarksFormatFFMetadata = "ffmetadata"
+	MediaMarksFormatJSON       = "json"
+)
+
+const mediaMarksBundleVersion = 1
+
+// MediaMarksBundle is the JSON export of the marks of a file
+type MediaMarksBundle struct {
+	Version  int                    `json:"version"`
+	FileName string                 `json:"file_name"`
+	Marks    []MediaMarksCreateArgs `json:"marks"`
+}
+
+// MediaMarksExportName returns the file name of the exported marks of obj
+func MediaMarksExportName(obj model.Obj, format string) string {
+	base := strings.TrimSuffix(obj.GetName(), stdpath.Ext(obj.GetName()))
+	switch format {
+	case MediaMarksFormatVTT:
+		return base + ".chapters.vtt"
+	case MediaMarksFormatFFMetadata:
+		return base + ".ffmetadata.txt"
+	default:
+		return base + ".marks.json"
+	}
+}
+
+// ExportMediaMarks writes the marks of the user on obj in the given format,
+// duration is used as the end of the last chapter and may be 0 if unknown
+func ExportMediaMarks(w io.Writer, storage driver.Driver, obj model.Obj, user *model.User, format string, duration float64) error {
+	marks, err := db.ListMediaMarksByUserAndFingerprint(user.ID, BuildMediaFingerprint(storage, obj))
+	if err != nil {
+		return errors.WithMessage(err, "failed to list media marks")
+	}
+	if format == MediaMarksFormatJSON {
+		bundle := MediaMarksBundle{
+			Version:  mediaMarksBundleVersion,
+			FileName: obj.GetName(),
+			Marks:    make([]MediaMarksCreateArgs, len(marks)),
+		}
+		for i, mark := range marks {
+			bundle.Marks[i] = MediaMarksCreateArgs{TimeSecond: mark.TimeSecond, Title: mark.Title, Content: mark.Content}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.WithStack(encoder.Encode(bundle))
+	}
+	list := make([]chapters.Chapter, len(marks))
+	for i, mark := range marks {
+		list[i] = chapters.Chapter{Start: mark.TimeSecond, Title: mark.Title, Content: mark.Content}
+	}
+	switch format {
+	case MediaMarksFormatVTT:
+		return errors.WithStack(chapters.WriteWebVTT(w, list, duration))
+	case MediaMarksFormatFFMetadata:
+		return errors.WithStack(chapters.WriteFFMetadata(w, list, duration))
+	default:
+		return errors.Errorf("unsupported media marks format: %s", format)
+	}
+}
+
+// ParseMediaMarks parses exported marks, an empty format is detected from the content
+func ParseMediaMarks(format string, content []byte) ([]MediaMarksCreateArgs, error) {
+	if format == "" {
+		trimmed := bytes.TrimLeft(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), " \t\r\n")
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("WEBVTT")):
+			format = MediaMarksFormatVTT
+		case bytes.HasPrefix(trimmed, []byte(";FFMETADATA")):
+			format = MediaMarksFormatFFMetadata
+		default:
+			format = MediaMarksFormatJSON
+		}
+	}
+	var list []chapters.Chapter
+	var err error
+	switch format {
+	case MediaMarksFormatJSON:
+		var bundle MediaMarksBundle
+		if err = json.Unmarshal(content, &bundle); err != nil {
+			return nil, errors.WithMessage(err, "failed to parse media marks bundle")
+		}
+		for _, mark := range bundle.Marks {
+			if mark.TimeSecond < 0 || math.IsNaN(mark.TimeSecond) || math.IsInf(mark.TimeSecond, 0) {
+				return nil, errors.Errorf("invalid mark time: %v", mark.TimeSecond)
+			}
+		}
+		return bundle.Marks, nil
+	case MediaMarksFormatVTT:
+		list, err = chapters.ParseWebVTT(bytes.NewReader(content))
+	case MediaMarksFormatFFMetadata:
+		list, err = chapters.ParseFFMetadata(bytes.NewReader(content))
+	default:
+		return nil, errors.Errorf("unsupported media marks format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := make([]MediaMarksCreateArgs, len(list))
+	for i, c := range list {
+		res[i] = MediaMarksCreateArgs{TimeSecond: c.Start, Title: c.Title, Content: c.Content}
+	}
+	return res, nil
+}
+
+// ImportMediaMarks creates the given marks on obj, marks with the same time and title
+// as an existing one are skipped so that importing twice does not duplicate them
+func ImportMediaMarks(ctx context.Context, storage driver.Driver, obj model.Obj, user *model.User, marks []MediaMarksCreateArgs) ([]model.MediaMarkDTO, error) {
+	if user.IsGuest() || user.Disabled {
+		return nil, errors.New("permission denied: only logged-in users can create media marks")
+	}
+	existing, err := db.ListMediaMarksByUserAndFingerprint(user.ID, BuildMediaFingerprint(storage, obj))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list media marks")
+	}
+	type markKey struct {
+		ms    int64
+		title string
+	}
+	seen := make(map[markKey]struct{}, len(existing)+len(marks))
+	for _, mark := range existing {
+		seen[markKey{int64(math.Round(mark.TimeSecond * 1000)), mark.Title}] = struct{}{}
+	}
+	created := make([]model.MediaMarkDTO, 0, len(marks))
+	for _, mark := range marks {
+		key := markKey{int64(math.Round(mark.TimeSecond * 1000)), mark.Title}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res, err := HandleMediaMarksCreate(ctx, storage, obj, user, mark)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, res.(model.MediaMarkDTO))
+	}
+	return created, nil
+}
@@ -0,0 +1,91 @@
+package op
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// a media is considered finished once this ratio of its duration has been played
+const mediaFinishedRatio = 0.95
+
+// MediaProgressSetArgs represents the arguments for saving the playback progress
+type MediaProgressSetArgs struct {
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+	Finished *bool   `json:"finished"` // optional, derived from position and duration if omitted
+}
+
+// HandleMediaProgressGet handles the media_progress_get method
+func HandleMediaProgressGet(ctx context.Context, storage driver.Driver, obj model.Obj, user *model.User) (interface{}, error) {
+	if user.IsGuest() {
+		return nil, nil // Guest users have no saved progress
+	}
+
+	fingerprint := BuildMediaFingerprint(storage, obj)
+	progress, err := db.GetPlaybackProgress(user.ID, fingerprint)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "failed to get media progress")
+	}
+
+	return progress.ToDTO(), nil
+}
+
+// HandleMediaProgressSet handles the media_progress_set method
+func HandleMediaProgressSet(ctx context.Context, storage driver.Driver, obj model.Obj, user *model.User, data interface{}) (interface{}, error) {
+	if user.IsGuest() || user.Disabled {
+		return nil, errors.New("permission denied: only logged-in users can save media progress")
+	}
+
+	// Parse arguments
+	var args MediaProgressSetArgs
+	if data != nil {
+		dataBytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to marshal data")
+		}
+		if err := json.Unmarshal(dataBytes, &args); err != nil {
+			return nil, errors.WithMessage(err, "failed to parse set arguments")
+		}
+	}
+	if args.Position < 0 || args.Duration < 0 {
+		return nil, errors.New("position and duration must not be negative")
+	}
+
+	fingerprint := BuildMediaFingerprint(storage, obj)
+	progress, err := db.GetPlaybackProgress(user.ID, fingerprint)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithMessage(err, "failed to get media progress")
+		}
+		progress = &model.PlaybackProgress{
+			UserId:      user.ID,
+			Fingerprint: fingerprint,
+		}
+	}
+
+	progress.StorageId = storage.GetStorage().ID
+	progress.OriginalPath = utils.GetFullPath(storage.GetStorage().MountPath, obj.GetPath())
+	progress.Position = args.Position
+	progress.Duration = args.Duration
+	if args.Finished != nil {
+		progress.Finished = *args.Finished
+	} else {
+		progress.Finished = args.Duration > 0 && args.Position >= args.Duration*mediaFinishedRatio
+	}
+
+	if err := db.SavePlaybackProgress(progress); err != nil {
+		return nil, errors.WithMessage(err, "failed to save media progress")
+	}
+
+	return progress.ToDTO(), nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/go-cache"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -94,3 +95,28 @@ func GetMetaById(id uint) (*model.Meta, error) {
 func GetMetas(pageIndex, pageSize int) (metas []model.Meta, count int64, err error) {
 	return db.GetMetas(pageIndex, pageSize)
 }
+
+// CheckACL evaluates the access rules of the metas from path up to the root, the nearest meta
+// having a rule about the user and the action decides. Admins are not subject to the rules.
+func CheckACL(user *model.User, path, action string) (allowed, decided bool) {
+	if user == nil || user.IsAdmin() {
+		return false, false
+	}
+	path = utils.FixAndCleanPath(path)
+	for p := path; ; p = stdpath.Dir(p) {
+		meta, err := getMetaByPath(p)
+		if err != nil && errors.Cause(err) != errs.MetaNotFound {
+			log.Errorf("failed get meta of %s to check acl: %+v", p, err)
+			// be safe if the rules can't be read
+			return false, true
+		}
+		if meta != nil && (p == path || meta.ASub) {
+			if allowed, decided = meta.CheckACL(user, action); decided {
+				return allowed, decided
+			}
+		}
+		if p == "/" {
+			return false, false
+		}
+	}
+}
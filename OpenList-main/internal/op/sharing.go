@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -87,11 +88,56 @@ func GetSharingsByCreatorId(userId uint, pageIndex, pageSize int) ([]model.Shari
 	return makeJoined(s), cnt, nil
 }
 
+// CanShare tells if the user can share path, the access rules of path take precedence over the permission
+func CanShare(user *model.User, path string) bool {
+	if allowed, decided := CheckACL(user, path, model.ACLShare); decided {
+		return allowed
+	}
+	return user.CanShare()
+}
+
+// SharingValid tells if the sharing is valid and its creator can still share its files,
+// the access rules can allow sharing the files to a creator without the share permission
+func SharingValid(sharing *model.Sharing) bool {
+	if !sharing.Valid() {
+		return false
+	}
+	// the favorites are checked one by one when the files are resolved
+	if sharing.FromFavoriteFolder() {
+		return sharing.Creator.CanShare()
+	}
+	for _, path := range sharing.Files {
+		if !CanShare(sharing.Creator, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSharingUnwrapPath maps path in the sharing to the mount path, it fails when the access
+// rules of the metas don't allow the creator to read it anymore
 func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath string, err error) {
+	unwrapPath, err = getSharingUnwrapPath(sharing, path)
+	if err != nil {
+		return "", err
+	}
+	if SharingPathDenied(sharing, unwrapPath) {
+		return "", errors.WithStack(errs.PermissionDenied)
+	}
+	return unwrapPath, nil
+}
+
+// SharingPathDenied tells if the creator of the sharing is denied reading the mount path
+func SharingPathDenied(sharing *model.Sharing, unwrapPath string) bool {
+	allowed, decided := CheckACL(sharing.Creator, unwrapPath, model.ACLRead)
+	return decided && !allowed
+}
+
+func getSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath string, err error) {
 	if len(sharing.Files) == 0 {
 		return "", errors.New("cannot get actual path of an invalid sharing")
 	}
-	if len(sharing.Files) == 1 {
+	if sharing.SingleRoot() {
 		return stdpath.Join(sharing.Files[0], path), nil
 	}
 	path = utils.FixAndCleanPath(path)[1:]
@@ -43,6 +43,16 @@ func GetStorageByMountPath(mountPath string) (driver.Driver, error) {
 	return storageDriver, nil
 }
 
+// getStorageById returns the loaded storage of the id
+func getStorageById(id uint) (driver.Driver, error) {
+	for _, storage := range GetAllStorages() {
+		if storage.GetStorage().ID == id {
+			return storage, nil
+		}
+	}
+	return nil, errors.WithStack(errs.StorageNotFound)
+}
+
 // CreateStorage Save the storage to database so storage can get an id
 // then instantiate corresponding driver and save it in memory
 func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
@@ -282,6 +292,12 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteTrashItemsByStorageId(id); err != nil {
+		log.Errorf("failed delete trash items of storage %s: %+v", storage.MountPath, err)
+	}
+	if err := db.DeleteFileVersionsByStorageId(id); err != nil {
+		log.Errorf("failed delete file versions of storage %s: %+v", storage.MountPath, err)
+	}
 	return dropErr
 }
 
@@ -0,0 +1,167 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// inTrash tells if the actual path is in the recycle bin, removing it is permanent
+func inTrash(path string) bool {
+	for _, name := range strings.Split(utils.FixAndCleanPath(path), "/") {
+		if model.IsTrashName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveToTrash moves the object into a folder of its own in the trash folder of the storage,
+// or renames it in place if the storage can't move objects
+func moveToTrash(ctx context.Context, storage driver.Driver, path string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+	}
+	if utils.PathEqual(path, "/") {
+		return errors.New("delete root folder is not allowed, please goto the manage page to delete the storage instead")
+	}
+	path = utils.FixAndCleanPath(path)
+	obj, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			log.Debugf("%s have been removed", path)
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	stamp := strconv.FormatInt(time.Now().UnixNano(), 10)
+	var trashPath string
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		trashDir := stdpath.Join("/", model.TrashDirName, stamp)
+		if err = MakeDir(ctx, storage, trashDir); err != nil {
+			return errors.WithMessage(err, "failed make trash dir")
+		}
+		if _, _, err = move(ctx, storage, path, trashDir); err != nil {
+			return errors.WithMessage(err, "failed move to trash")
+		}
+		trashPath = stdpath.Join(trashDir, obj.GetName())
+	case driver.Rename, driver.RenameResult:
+		name := model.TrashDirName + "." + stamp + "." + obj.GetName()
+		if _, _, err = rename(ctx, storage, path, name); err != nil {
+			return errors.WithMessage(err, "failed rename to trash")
+		}
+		trashPath = stdpath.Join(stdpath.Dir(path), name)
+	default:
+		return RemovePermanently(ctx, storage, path)
+	}
+	item := &model.TrashItem{
+		StorageId:  storage.GetStorage().ID,
+		Path:       stdpath.Join(storage.GetStorage().MountPath, path),
+		ActualPath: path,
+		TrashPath:  trashPath,
+		Name:       obj.GetName(),
+		Size:       obj.GetSize(),
+		IsDir:      obj.IsDir(),
+		RemovedAt:  time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.UserId = user.ID
+		item.Username = user.Username
+	}
+	return errors.WithMessage(db.CreateTrashItem(item), "failed save trash item")
+}
+
+// trashPathToRemove is the folder made for the item in the trash folder, or the renamed object
+func trashPathToRemove(item *model.TrashItem) string {
+	if dir := stdpath.Dir(item.TrashPath); inTrash(dir) {
+		return dir
+	}
+	return item.TrashPath
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	return db.GetTrashItemById(id)
+}
+
+func GetTrashItems(q *model.TrashQuery) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItems(q)
+}
+
+// RestoreTrashItem moves the object back to where it was removed from,
+// it fails if an object has been created at the path since
+func RestoreTrashItem(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getStorageById(item.StorageId)
+	if err != nil {
+		return err
+	}
+	if _, err := Get(ctx, storage, item.ActualPath); err == nil {
+		return errors.Errorf("%s already exists", item.Path)
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed check the original path")
+	}
+	dstDirPath := stdpath.Dir(item.ActualPath)
+	if stdpath.Dir(item.TrashPath) == dstDirPath {
+		_, _, err = rename(ctx, storage, item.TrashPath, stdpath.Base(item.ActualPath))
+	} else {
+		if err = MakeDir(ctx, storage, dstDirPath); err != nil {
+			return errors.WithMessage(err, "failed make the original dir")
+		}
+		if _, _, err = move(ctx, storage, item.TrashPath, dstDirPath); err == nil {
+			if err := RemovePermanently(ctx, storage, stdpath.Dir(item.TrashPath)); err != nil {
+				log.Warnf("failed remove trash dir of %s: %+v", item.Path, err)
+			}
+		}
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed restore from trash")
+	}
+	HandleObjChangeHook(ctx, ObjChange{Storage: storage, Path: item.ActualPath})
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrashItem removes the object from the recycle bin permanently
+func PurgeTrashItem(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getStorageById(item.StorageId)
+	if err == nil {
+		err = RemovePermanently(ctx, storage, trashPathToRemove(item))
+	} else if errors.Is(err, errs.StorageNotFound) {
+		// the storage is disabled or gone, forget the item anyway
+		err = nil
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed purge from trash")
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeExpiredTrash purges the items removed more than days ago and returns how many were purged
+func PurgeExpiredTrash(ctx context.Context, days int) (int, error) {
+	if days <= 0 {
+		return 0, nil
+	}
+	items, err := db.GetTrashItemsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for i := range items {
+		if err := PurgeTrashItem(ctx, &items[i]); err != nil {
+			log.Errorf("failed purge expired trash item %s: %+v", items[i].Path, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,63 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestRecycleBin(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:     "Local",
+		MountPath:  "/trash_test",
+		RecycleBin: true,
+		Addition:   fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/trash_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected a.txt to be moved to the trash, got %v", err)
+	}
+	items, total, err := op.GetTrashItems(&model.TrashQuery{PageReq: model.PageReq{Page: 1, PerPage: 10}, Path: "/trash_test"})
+	if err != nil || total != 1 || items[0].Path != "/trash_test/a.txt" {
+		t.Fatalf("unexpected trash items %+v, %d, %v", items, total, err)
+	}
+
+	if err := op.RestoreTrashItem(ctx, &items[0]); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatalf("expected a.txt to be restored, got %v", err)
+	}
+
+	if err := op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	items, _, _ = op.GetTrashItems(&model.TrashQuery{PageReq: model.PageReq{Page: 1, PerPage: 10}, Path: "/trash_test"})
+	if err := op.PurgeTrashItem(ctx, &items[0]); err != nil {
+		t.Fatalf("failed purge: %+v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, model.TrashDirName))
+	if len(entries) != 0 {
+		t.Errorf("expected an empty trash folder, got %d entries", len(entries))
+	}
+}
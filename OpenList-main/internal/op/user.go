@@ -82,6 +82,12 @@ func DeleteUserById(id uint) error {
 	if err := DeleteSharingsByCreatorId(id); err != nil {
 		return errors.WithMessage(err, "failed to delete user's sharings")
 	}
+	if err := deleteUserUsage(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's traffic")
+	}
+	if err := db.DeleteAPITokensByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's api tokens")
+	}
 	return db.DeleteUserById(id)
 }
 
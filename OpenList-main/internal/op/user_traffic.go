@@ -0,0 +1,126 @@
+package op
+
+import (
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	usageMu sync.Mutex
+	// usageCache holds the usage of the users in the current month, so that checking the
+	// limits of every upload and download doesn't query the database
+	usageCache = make(map[uint]*model.UserUsage)
+)
+
+func currentMonth() string {
+	return time.Now().Format("2006-01")
+}
+
+// getUsage returns the cached usage of the user, usageMu must be held
+func getUsage(userId uint) (*model.UserUsage, error) {
+	month := currentMonth()
+	if u, ok := usageCache[userId]; ok && u.Month == month {
+		return u, nil
+	}
+	traffic, err := db.GetUserTraffic(userId, month)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := db.GetUserUploaded(userId)
+	if err != nil {
+		return nil, err
+	}
+	u := &model.UserUsage{
+		Month:           month,
+		Uploaded:        uploaded,
+		MonthUploaded:   traffic.Uploaded,
+		MonthDownloaded: traffic.Downloaded,
+	}
+	usageCache[userId] = u
+	return u, nil
+}
+
+// GetUserUsage returns the traffic of the user together with the limits
+func GetUserUsage(user *model.User) (*model.UserUsage, error) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	u, err := getUsage(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	res := *u
+	res.UploadQuota, res.DownloadCap = user.UploadQuota, user.DownloadCap
+	return &res, nil
+}
+
+// CheckUploadQuota fails if uploading size more bytes exceeds the upload quota of the user
+func CheckUploadQuota(user *model.User, size int64) error {
+	if user == nil || user.UploadQuota <= 0 {
+		return nil
+	}
+	u, err := GetUserUsage(user)
+	if err != nil {
+		return err
+	}
+	if u.Uploaded+max(size, 0) > user.UploadQuota {
+		return errors.WithStack(errs.UploadQuotaExceeded)
+	}
+	return nil
+}
+
+// CheckDownloadCap fails if the user has used up the download traffic of the month
+func CheckDownloadCap(user *model.User) error {
+	if user == nil || user.DownloadCap <= 0 {
+		return nil
+	}
+	u, err := GetUserUsage(user)
+	if err != nil {
+		return err
+	}
+	if u.MonthDownloaded >= user.DownloadCap {
+		return errors.WithStack(errs.DownloadCapExceeded)
+	}
+	return nil
+}
+
+func addUserTraffic(user *model.User, uploaded, downloaded int64) {
+	if user == nil || (uploaded <= 0 && downloaded <= 0) {
+		return
+	}
+	uploaded, downloaded = max(uploaded, 0), max(downloaded, 0)
+	month := currentMonth()
+	usageMu.Lock()
+	if u, ok := usageCache[user.ID]; ok && u.Month == month {
+		u.Uploaded += uploaded
+		u.MonthUploaded += uploaded
+		u.MonthDownloaded += downloaded
+	}
+	usageMu.Unlock()
+	// the database is written without the lock, so that it doesn't hold up the others
+	if err := db.AddUserTraffic(user.ID, month, uploaded, downloaded); err != nil {
+		log.Errorf("failed add traffic of user %s: %+v", user.Username, err)
+	}
+}
+
+// AddUserUploaded accounts the bytes uploaded by the user
+func AddUserUploaded(user *model.User, size int64) {
+	addUserTraffic(user, size, 0)
+}
+
+// AddUserDownloaded accounts the bytes downloaded by the user
+func AddUserDownloaded(user *model.User, size int64) {
+	addUserTraffic(user, 0, size)
+}
+
+func deleteUserUsage(userId uint) error {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	delete(usageCache, userId)
+	return db.DeleteUserTraffic(userId)
+}
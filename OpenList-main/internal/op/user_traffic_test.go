@@ -0,0 +1,65 @@
+package op_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestUserTrafficLimits(t *testing.T) {
+	user := &model.User{Username: "traffic", BasePath: "/", UploadQuota: 100, DownloadCap: 50}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.CheckUploadQuota(user, 100); err != nil {
+		t.Errorf("uploading up to the quota: %v", err)
+	}
+	if err := op.CheckDownloadCap(user); err != nil {
+		t.Errorf("downloading without traffic: %v", err)
+	}
+
+	op.AddUserUploaded(user, 60)
+	op.AddUserDownloaded(user, 49)
+	if err := op.CheckUploadQuota(user, 41); !errors.Is(err, errs.UploadQuotaExceeded) {
+		t.Errorf("uploading over the quota: got %v", err)
+	}
+	if err := op.CheckUploadQuota(user, 40); err != nil {
+		t.Errorf("uploading the rest of the quota: %v", err)
+	}
+	if err := op.CheckDownloadCap(user); err != nil {
+		t.Errorf("downloading under the cap: %v", err)
+	}
+	op.AddUserDownloaded(user, 1)
+	if err := op.CheckDownloadCap(user); !errors.Is(err, errs.DownloadCapExceeded) {
+		t.Errorf("downloading with the cap used up: got %v", err)
+	}
+
+	// the traffic is persisted, not only cached
+	usage, err := op.GetUserUsage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Uploaded != 60 || usage.MonthUploaded != 60 || usage.MonthDownloaded != 50 {
+		t.Errorf("usage = %+v", usage)
+	}
+	uploaded, err := db.GetUserUploaded(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded != 60 {
+		t.Errorf("persisted uploaded = %d, want 60", uploaded)
+	}
+
+	// no limits
+	unlimited := &model.User{ID: user.ID, Username: "traffic"}
+	if err = op.CheckUploadQuota(unlimited, 1<<40); err != nil {
+		t.Errorf("uploading without quota: %v", err)
+	}
+	if err = op.CheckDownloadCap(unlimited); err != nil {
+		t.Errorf("downloading without cap: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Validate checks the job and cleans its paths before it is saved
+func Validate(job *model.ScheduledJob) error {
+	if !model.IsValidScheduledJobKind(job.Kind) {
+		return errors.Errorf("invalid job kind: %s", job.Kind)
+	}
+	if _, err := cron.ParseSchedule(job.Cron); err != nil {
+		return err
+	}
+	if job.SrcPath != "" {
+		job.SrcPath = utils.FixAndCleanPath(job.SrcPath)
+	}
+	if job.DstPath != "" {
+		job.DstPath = utils.FixAndCleanPath(job.DstPath)
+	}
+	switch job.Kind {
+	case model.ScheduledJobCopy, model.ScheduledJobMove:
+		if job.SrcPath == "" || job.DstPath == "" {
+			return errors.New("src_path and dst_path are required")
+		}
+	case model.ScheduledJobBuildIndex, model.ScheduledJobRemoveEmptyDirectory:
+		if job.SrcPath == "" {
+			return errors.New("src_path is required")
+		}
+	case model.ScheduledJobOfflineDownload:
+		if job.DstPath == "" || job.Tool == "" {
+			return errors.New("dst_path and tool are required")
+		}
+		if len(urls(job)) == 0 {
+			return errors.New("urls are required")
+		}
+	}
+	return nil
+}
+
+func urls(job *model.ScheduledJob) []string {
+	var res []string
+	for _, u := range strings.Split(job.URLs, "\n") {
+		if u = strings.TrimSpace(u); u != "" {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// FillTaskIds converts the raw task ids of runs loaded from the database
+func FillTaskIds(runs []model.ScheduledJobRun) {
+	for i := range runs {
+		if runs[i].TaskIdsRaw == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(runs[i].TaskIdsRaw), &runs[i].TaskIds); err != nil {
+			log.Warnf("failed unmarshal task ids of scheduled job run %d: %+v", runs[i].ID, err)
+		}
+	}
+}
+
+func startRun(job *model.ScheduledJob, manual bool) (*model.ScheduledJobRun, error) {
+	run := &model.ScheduledJobRun{
+		JobId:     job.ID,
+		Manual:    manual,
+		Status:    model.ScheduledJobRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := db.CreateScheduledJobRun(run); err != nil {
+		return nil, err
+	}
+	if err := db.SetScheduledJobLastRun(job.ID, run.StartedAt); err != nil {
+		log.Warnf("failed set last run time of scheduled job [%s]: %+v", job.Name, err)
+	}
+	return run, nil
+}
+
+func finishRun(job *model.ScheduledJob, run *model.ScheduledJobRun) {
+	log.Infof("run scheduled job [%s]", job.Name)
+	tasks, err := execute(job)
+	for _, t := range tasks {
+		if t != nil {
+			run.TaskIds = append(run.TaskIds, t.GetID())
+		}
+	}
+	if len(run.TaskIds) > 0 {
+		run.TaskType = job.Kind
+		raw, _ := json.Marshal(run.TaskIds)
+		run.TaskIdsRaw = string(raw)
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = model.ScheduledJobRunSucceeded
+	if err != nil {
+		log.Errorf("failed run scheduled job [%s]: %+v", job.Name, err)
+		run.Status = model.ScheduledJobRunFailed
+		run.Error = err.Error()
+	}
+	if err = db.UpdateScheduledJobRun(run); err != nil {
+		log.Errorf("failed save run of scheduled job [%s]: %+v", job.Name, err)
+	}
+	if err = db.PruneScheduledJobRuns(job.ID, runsToKeep); err != nil {
+		log.Warnf("failed prune runs of scheduled job [%s]: %+v", job.Name, err)
+	}
+}
+
+// execute runs the job as the admin, copy, move and offline download return the tasks they submitted
+func execute(job *model.ScheduledJob) ([]task.TaskExtensionInfo, error) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get admin user")
+	}
+	ctx := context.WithValue(context.Background(), conf.UserKey, admin)
+	switch job.Kind {
+	case model.ScheduledJobCopy:
+		t, err := fs.Copy(ctx, job.SrcPath, job.DstPath)
+		return []task.TaskExtensionInfo{t}, err
+	case model.ScheduledJobMove:
+		t, err := fs.Move(ctx, job.SrcPath, job.DstPath)
+		return []task.TaskExtensionInfo{t}, err
+	case model.ScheduledJobBuildIndex:
+		return nil, buildIndex(ctx, job)
+	case model.ScheduledJobRemoveEmptyDirectory:
+		return nil, fs.RemoveEmptyDirectory(ctx, job.SrcPath)
+	case model.ScheduledJobOfflineDownload:
+		return addURLs(ctx, job)
+	}
+	return nil, errors.Errorf("invalid job kind: %s", job.Kind)
+}
+
+func buildIndex(ctx context.Context, job *model.ScheduledJob) error {
+	if setting.GetStr(conf.SearchIndex) == "none" {
+		return errs.SearchNotAvailable
+	}
+	if search.Running() {
+		return errors.New("index is running")
+	}
+	if !search.Config(ctx).AutoUpdate {
+		return errors.New("update is not supported for current index")
+	}
+	maxDepth := job.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = setting.GetInt(conf.MaxIndexDepth, 20)
+	}
+	if err := search.Del(ctx, job.SrcPath); err != nil {
+		return err
+	}
+	return search.BuildIndex(ctx, []string{job.SrcPath}, conf.SlicesMap[conf.IgnorePaths], maxDepth, false)
+}
+
+// addURLs adds every url even if some of them fail
+func addURLs(ctx context.Context, job *model.ScheduledJob) ([]task.TaskExtensionInfo, error) {
+	var tasks []task.TaskExtensionInfo
+	var failed []string
+	for _, u := range urls(job) {
+		t, err := tool.AddURL(ctx, &tool.AddURLArgs{
+			URL:          u,
+			DstDirPath:   job.DstPath,
+			Tool:         job.Tool,
+			DeletePolicy: tool.DeletePolicy(job.DeletePolicy),
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", u, err.Error()))
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	if len(failed) > 0 {
+		return tasks, errors.Errorf("failed add %d of %d urls: %s", len(failed), len(failed)+len(tasks), strings.Join(failed, "; "))
+	}
+	return tasks, nil
+}
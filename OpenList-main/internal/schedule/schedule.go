@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// runsToKeep is the length of the run history of each job
+const runsToKeep = 100
+
+type entry struct {
+	job      model.ScheduledJob
+	schedule cron.Schedule
+	cancel   context.CancelFunc
+}
+
+var (
+	mu      sync.Mutex
+	entries = make(map[uint]*entry)
+	// running holds the ids of the jobs with a run in progress, by id since the entry
+	// of a job is replaced when it is updated while running
+	running = make(map[uint]struct{})
+)
+
+// tryStart marks the job as running, it fails if it is running already
+func tryStart(id uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := running[id]; ok {
+		return false
+	}
+	running[id] = struct{}{}
+	return true
+}
+
+func finish(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(running, id)
+}
+
+// Init starts the enabled jobs, it should be called after the task managers are initialized
+func Init() {
+	if err := db.FailRunningScheduledJobRuns("interrupted by restart"); err != nil {
+		log.Errorf("failed fail interrupted scheduled job runs: %+v", err)
+	}
+	jobs, err := db.GetEnabledScheduledJobs()
+	if err != nil {
+		log.Errorf("failed get scheduled jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		if err = Set(&jobs[i]); err != nil {
+			log.Errorf("failed schedule job [%s]: %+v", jobs[i].Name, err)
+		}
+	}
+	log.Infof("%d scheduled jobs started", len(entries))
+}
+
+// Set (re)starts the schedule of job, a disabled job is only stopped.
+// A run in progress is not interrupted.
+func Set(job *model.ScheduledJob) error {
+	Remove(job.ID)
+	if job.Disabled {
+		return nil
+	}
+	s, err := cron.ParseSchedule(job.Cron)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{job: *job, schedule: s, cancel: cancel}
+	mu.Lock()
+	entries[job.ID] = e
+	mu.Unlock()
+	go e.loop(ctx)
+	return nil
+}
+
+// Remove stops the schedule of the job
+func Remove(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := entries[id]; ok {
+		e.cancel()
+		delete(entries, id)
+	}
+}
+
+// Next returns the next activation time of the job, nil if it is not scheduled
+func Next(id uint) *time.Time {
+	mu.Lock()
+	e, ok := entries[id]
+	mu.Unlock()
+	if !ok {
+		return nil
+	}
+	next := e.schedule.Next(time.Now())
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// RunNow starts a run of the job in background and returns it, it fails if the job is already running
+func RunNow(job *model.ScheduledJob) (*model.ScheduledJobRun, error) {
+	mu.Lock()
+	e, ok := entries[job.ID]
+	mu.Unlock()
+	if !ok {
+		// disabled jobs can still be run manually
+		e = &entry{job: *job}
+	}
+	if !tryStart(job.ID) {
+		return nil, errors.New("job is already running")
+	}
+	run, err := startRun(&e.job, true)
+	if err != nil {
+		finish(job.ID)
+		return nil, err
+	}
+	go func() {
+		defer finish(job.ID)
+		finishRun(&e.job, run)
+	}()
+	return run, nil
+}
+
+func (e *entry) loop(ctx context.Context) {
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Warnf("scheduled job [%s] will never run", e.job.Name)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// runs never overlap, the activation is skipped if the previous run is too long
+		if !tryStart(e.job.ID) {
+			log.Warnf("skip scheduled job [%s] as the previous run is not finished", e.job.Name)
+			continue
+		}
+		run, err := startRun(&e.job, false)
+		if err != nil {
+			log.Errorf("failed start scheduled job [%s]: %+v", e.job.Name, err)
+		} else {
+			finishRun(&e.job, run)
+		}
+		finish(e.job.ID)
+	}
+}
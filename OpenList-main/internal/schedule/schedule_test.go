@@ -0,0 +1,36 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestRunNotOverlapAfterSet(t *testing.T) {
+	job := &model.ScheduledJob{ID: 1, Name: "test", Cron: "0 0 * * *"}
+	if err := Set(job); err != nil {
+		t.Fatalf("failed set job: %+v", err)
+	}
+	defer Remove(job.ID)
+	// a run of the job is in progress
+	if !tryStart(job.ID) {
+		t.Fatal("expected the job not running")
+	}
+	defer finish(job.ID)
+
+	job.Cron = "30 0 * * *"
+	if err := Set(job); err != nil {
+		t.Fatalf("failed update job: %+v", err)
+	}
+	if _, err := RunNow(job); err == nil {
+		t.Error("expected the updated job still running")
+	}
+
+	job.Disabled = true
+	if err := Set(job); err != nil {
+		t.Fatalf("failed disable job: %+v", err)
+	}
+	if _, err := RunNow(job); err == nil {
+		t.Error("expected the disabled job still running")
+	}
+}
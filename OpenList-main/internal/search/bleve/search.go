@@ -3,11 +3,13 @@ package bleve
 import (
 	"context"
 	"os"
+	stdpath "path"
+	"strings"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
-	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -27,17 +29,42 @@ func (b *Bleve) Config() searcher.Config {
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if strings.TrimSpace(req.Keywords) != "" {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		queries = append(queries, query)
+	} else {
+		queries = append(queries, bleve.NewMatchAllQuery())
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
+		isDirQuery.SetField("is_dir")
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
+	if req.Name != "" {
+		// names are indexed case-sensitively and globs are case-insensitive, so they are matched in memory
+		nodes, err := b.all(reqQuery)
+		if err != nil {
+			log.Errorf("search error: %+v", err)
+			return nil, 0, err
+		}
+		res, total := req.Filter(nodes)
+		return res, total, nil
+	}
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	order := []string{req.OrderBy}
+	if req.OrderBy != model.SearchOrderByName {
+		order = append(order, model.SearchOrderByName)
+	}
+	if req.OrderDirection == "desc" {
+		for i := range order {
+			order[i] = "-" + order[i]
+		}
+	}
+	search.SortBy(order)
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
 	search.Fields = []string{"*"}
@@ -47,16 +74,89 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
-			Parent: src.Fields["parent"].(string),
-			Name:   src.Fields["name"].(string),
-			IsDir:  src.Fields["is_dir"].(bool),
-			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		return nodeFromFields(src.Fields), nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+// filterQueries returns the queries of the filters of the request, except the name
+func filterQueries(req model.SearchReq) []query2.Query {
+	var queries []query2.Query
+	inclusive := true
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var minSize, maxSize *float64
+		if req.MinSize > 0 {
+			v := float64(req.MinSize)
+			minSize = &v
+		}
+		if req.MaxSize > 0 {
+			v := float64(req.MaxSize)
+			maxSize = &v
+		}
+		q := bleve.NewNumericRangeInclusiveQuery(minSize, maxSize, &inclusive, &inclusive)
+		q.SetField("size")
+		queries = append(queries, q)
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		var start, end time.Time
+		if req.ModifiedAfter != nil {
+			start = *req.ModifiedAfter
+		}
+		if req.ModifiedBefore != nil {
+			end = *req.ModifiedBefore
+		}
+		q := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		q.SetField("modified")
+		queries = append(queries, q)
+	}
+	if len(req.Exts) > 0 {
+		exts := bleve.NewDisjunctionQuery()
+		for _, ext := range req.Exts {
+			q := bleve.NewTermQuery(ext)
+			q.SetField("ext")
+			exts.AddQuery(q)
+		}
+		queries = append(queries, exts)
+	}
+	if len(req.MediaTypes) > 0 {
+		types := bleve.NewDisjunctionQuery()
+		for _, t := range req.MediaTypes {
+			v := float64(t)
+			q := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			q.SetField("media_type")
+			types.AddQuery(q)
+		}
+		queries = append(queries, types)
+	}
+	return queries
+}
+
+// nodeFromFields converts the stored fields of a hit, empty audio tags are not stored at all
+func nodeFromFields(fields map[string]any) model.SearchNode {
+	node := model.SearchNode{
+		Parent: fields["parent"].(string),
+		Name:   fields["name"].(string),
+		IsDir:  fields["is_dir"].(bool),
+		Size:   int64(fields["size"].(float64)),
+	}
+	node.Title, _ = fields["title"].(string)
+	node.Artist, _ = fields["artist"].(string)
+	node.AlbumArtist, _ = fields["album_artist"].(string)
+	node.Album, _ = fields["album"].(string)
+	node.Genre, _ = fields["genre"].(string)
+	year, _ := fields["year"].(float64)
+	node.Year = int(year)
+	track, _ := fields["track"].(float64)
+	node.Track = int(track)
+	if modified, ok := fields["modified"].(string); ok {
+		node.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+	}
+	node.Ext, _ = fields["ext"].(string)
+	mediaType, _ := fields["media_type"].(float64)
+	node.MediaType = int(mediaType)
+	return node
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
 	return b.BIndex.Index(uuid.NewString(), node)
 }
@@ -69,12 +169,55 @@ func (b *Bleve) BatchIndex(ctx context.Context, nodes []model.SearchNode) error
 	return b.BIndex.Batch(batch)
 }
 
+// inPath returns a query of the nodes under the path,
+// parent is analyzed so the nodes still have to be checked in memory
+func (b *Bleve) inPath(path string) query2.Query {
+	if path == "/" {
+		return bleve.NewMatchAllQuery()
+	}
+	m := b.BIndex.Mapping()
+	if len(m.AnalyzerNamed(m.AnalyzerNameForPath("parent")).Analyze([]byte(path))) == 0 {
+		// the path is made of stop words only, nothing would match the phrase
+		return bleve.NewMatchAllQuery()
+	}
+	q := bleve.NewMatchPhraseQuery(path)
+	q.SetField("parent")
+	return q
+}
+
 func (b *Bleve) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
-	return nil, errs.NotSupport
+	parent = utils.FixAndCleanPath(parent)
+	nodes, err := b.all(b.inPath(parent))
+	if err != nil {
+		return nil, err
+	}
+	var res []model.SearchNode
+	for i := range nodes {
+		if nodes[i].Parent == parent {
+			res = append(res, nodes[i])
+		}
+	}
+	return res, nil
 }
 
 func (b *Bleve) Del(ctx context.Context, prefix string) error {
-	return errs.NotSupport
+	prefix = utils.FixAndCleanPath(prefix)
+	dir, name := stdpath.Split(prefix)
+	dir = utils.FixAndCleanPath(dir)
+	nameQuery := bleve.NewTermQuery(name)
+	nameQuery.SetField("name")
+	hits, err := b.hits(bleve.NewDisjunctionQuery(b.inPath(prefix), nameQuery))
+	if err != nil {
+		return err
+	}
+	batch := b.BIndex.NewBatch()
+	for _, hit := range hits {
+		node := nodeFromFields(hit.Fields)
+		if utils.IsSubPath(prefix, node.Parent) || (node.Parent == dir && node.Name == name) {
+			batch.Delete(hit.ID)
+		}
+	}
+	return b.BIndex.Batch(batch)
 }
 
 func (b *Bleve) Release(ctx context.Context) error {
@@ -102,4 +245,107 @@ func (b *Bleve) Clear(ctx context.Context) error {
 	return nil
 }
 
+// libraryBatchSize is the page size used to load all the tracks of the library,
+// bleve cannot group by field so tracks are grouped in memory
+const libraryBatchSize = 10000
+
+func (b *Bleve) tracks(req model.LibraryReq) ([]model.SearchNode, error) {
+	isFile := bleve.NewBoolFieldQuery(false)
+	isFile.SetField("is_dir")
+	hasTags := bleve.NewDisjunctionQuery()
+	for _, field := range []string{"artist", "album"} {
+		q := bleve.NewRegexpQuery(".+")
+		q.SetField(field)
+		hasTags.AddQuery(q)
+	}
+	queries := []query2.Query{isFile, hasTags}
+	for _, filter := range [][2]string{
+		{"artist", req.Artist},
+		{"album_artist", req.AlbumArtist},
+		{"album", req.Album},
+		{"genre", req.Genre},
+	} {
+		if filter[1] != "" {
+			// fields are analyzed, the exact value is checked by req.Match
+			q := bleve.NewMatchPhraseQuery(filter[1])
+			q.SetField(filter[0])
+			queries = append(queries, q)
+		}
+	}
+	if req.Year != 0 {
+		year, inclusive := float64(req.Year), true
+		q := bleve.NewNumericRangeInclusiveQuery(&year, &year, &inclusive, &inclusive)
+		q.SetField("year")
+		queries = append(queries, q)
+	}
+	nodes, err := b.all(bleve.NewConjunctionQuery(queries...))
+	if err != nil {
+		return nil, err
+	}
+	var tracks []model.SearchNode
+	for i := range nodes {
+		// parent is analyzed, so it is filtered here
+		if req.Match(&nodes[i]) {
+			tracks = append(tracks, nodes[i])
+		}
+	}
+	return tracks, nil
+}
+
+// all returns all the nodes matching the query, for the searches done in memory
+func (b *Bleve) all(q query2.Query) ([]model.SearchNode, error) {
+	hits, err := b.hits(q)
+	if err != nil {
+		return nil, err
+	}
+	return utils.MustSliceConvert(hits, func(src *search2.DocumentMatch) model.SearchNode {
+		return nodeFromFields(src.Fields)
+	}), nil
+}
+
+// hits returns all the hits of the query with their stored fields
+func (b *Bleve) hits(q query2.Query) ([]*search2.DocumentMatch, error) {
+	var hits []*search2.DocumentMatch
+	for from := 0; ; from += libraryBatchSize {
+		search := bleve.NewSearchRequestOptions(q, libraryBatchSize, from, false)
+		search.Fields = []string{"*"}
+		searchResults, err := b.BIndex.Search(search)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, searchResults.Hits...)
+		if len(searchResults.Hits) < libraryBatchSize {
+			return hits, nil
+		}
+	}
+}
+
+func (b *Bleve) Artists(ctx context.Context, req model.LibraryReq) ([]model.LibraryArtist, int64, error) {
+	tracks, err := b.tracks(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, total := model.Paginate(searcher.GroupArtists(tracks), req.PageReq)
+	return res, total, nil
+}
+
+func (b *Bleve) Albums(ctx context.Context, req model.LibraryReq) ([]model.LibraryAlbum, int64, error) {
+	tracks, err := b.tracks(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, total := model.Paginate(searcher.GroupAlbums(tracks), req.PageReq)
+	return res, total, nil
+}
+
+func (b *Bleve) Tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, int64, error) {
+	tracks, err := b.tracks(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	searcher.SortTracks(tracks)
+	res, total := model.Paginate(tracks, req.PageReq)
+	return res, total, nil
+}
+
 var _ searcher.Searcher = (*Bleve)(nil)
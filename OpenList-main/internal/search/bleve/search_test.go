@@ -0,0 +1,56 @@
+package bleve
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestBleveGetDel(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bleve")
+	index, err := Init(&dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bleve{BIndex: index}
+	defer b.Release(context.Background())
+	ctx := context.Background()
+	err = b.BatchIndex(ctx, []model.SearchNode{
+		{Parent: "/", Name: "a", IsDir: true},
+		{Parent: "/a", Name: "b", IsDir: true},
+		{Parent: "/a", Name: "x.txt"},
+		{Parent: "/a/b", Name: "y.txt"},
+		{Parent: "/ab", Name: "z.txt"},
+		{Parent: "/", Name: "a b", IsDir: true},
+		{Parent: "/a b", Name: "w.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(parent string) []string {
+		nodes, err := b.Get(ctx, parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, node := range nodes {
+			res = append(res, node.Name)
+		}
+		sort.Strings(res)
+		return res
+	}
+	if got := names("/a"); len(got) != 2 || got[0] != "b" || got[1] != "x.txt" {
+		t.Fatalf("Get(/a) = %v", got)
+	}
+	if err = b.Del(ctx, "/a"); err != nil {
+		t.Fatal(err)
+	}
+	for parent, want := range map[string]int{"/": 1, "/a": 0, "/a/b": 0, "/ab": 1, "/a b": 1} {
+		if got := names(parent); len(got) != want {
+			t.Errorf("Get(%s) after Del(/a) = %v, want %d nodes", parent, got, want)
+		}
+	}
+}
@@ -42,4 +42,16 @@ func (D DB) Clear(ctx context.Context) error {
 	return db.ClearSearchNodes()
 }
 
+func (D DB) Artists(ctx context.Context, req model.LibraryReq) ([]model.LibraryArtist, int64, error) {
+	return db.LibraryArtists(req)
+}
+
+func (D DB) Albums(ctx context.Context, req model.LibraryReq) ([]model.LibraryAlbum, int64, error) {
+	return db.LibraryAlbums(req)
+}
+
+func (D DB) Tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, int64, error) {
+	return db.LibraryTracks(req)
+}
+
 var _ searcher.Searcher = (*DB)(nil)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,8 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Unix time of modified, dates can only be filtered and sorted as numbers.
+	ModifiedTs int64 `json:"modified_ts"`
 	model.SearchNode
 }
 
@@ -33,6 +36,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 }
 
 func (m *Meilisearch) Config() searcher.Config {
@@ -45,6 +49,11 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		Page:                 int64(req.Page),
 		HitsPerPage:          int64(req.PerPage),
 	}
+	if req.Name != "" {
+		// filtering strings is case-insensitive on meilisearch and cannot match patterns,
+		// so all the hits are loaded and the name is matched in memory
+		mReq.Page, mReq.HitsPerPage = 1, int64(model.MaxInt)
+	}
 	var filters []string
 	if req.Scope != 0 {
 		filters = append(filters, fmt.Sprintf("is_dir = %v", req.Scope == 1))
@@ -54,29 +63,66 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	filters = append(filters, searchFilters(req)...)
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
+	orderBy := req.OrderBy
+	if orderBy == model.SearchOrderByModified {
+		orderBy = "modified_ts"
+	}
+	mReq.Sort = []string{orderBy + ":" + req.OrderDirection}
+	if orderBy != model.SearchOrderByName {
+		mReq.Sort = append(mReq.Sort, model.SearchOrderByName+":"+req.OrderDirection)
+	}
 
 	search, err := m.Client.Index(m.IndexUid).SearchWithContext(ctx, req.Keywords, mReq)
 	if err != nil {
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		return buildSearchNodeFromResults(src.(map[string]any)), nil
 	})
 	if err != nil {
 		return nil, 0, err
 	}
+	if req.Name != "" {
+		res, total := req.Filter(nodes)
+		return res, total, nil
+	}
 	return nodes, search.TotalHits, nil
 }
 
+// searchFilters returns the filter expressions of the filters of the request, except the name
+func searchFilters(req model.SearchReq) []string {
+	var filters []string
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_ts >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_ts <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Exts) > 0 {
+		exts, _ := utils.SliceConvert(req.Exts, func(ext string) (string, error) {
+			return quoteFilter(ext), nil
+		})
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ", ")))
+	}
+	if len(req.MediaTypes) > 0 {
+		types, _ := utils.SliceConvert(req.MediaTypes, func(t int) (string, error) {
+			return strconv.Itoa(t), nil
+		})
+		filters = append(filters, fmt.Sprintf("media_type IN [%s]", strings.Join(types, ", ")))
+	}
+	return filters
+}
+
 func (m *Meilisearch) Index(ctx context.Context, node model.SearchNode) error {
 	return m.BatchIndex(ctx, []model.SearchNode{node})
 }
@@ -95,6 +141,7 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedTs:       src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -219,3 +266,69 @@ func (m *Meilisearch) getTaskStatus(ctx context.Context, taskUID int64) (meilise
 	}
 	return forTask.Status, nil
 }
+
+// tracks loads all the matching tracks, meilisearch cannot group by field so tracks are grouped in memory
+func (m *Meilisearch) tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, error) {
+	// empty tags are omitted from the documents
+	filters := []string{"is_dir = false", "(artist EXISTS OR album EXISTS)"}
+	if req.Parent != "" && req.Parent != "/" {
+		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", hashPath(req.Parent)))
+	}
+	for _, filter := range [][2]string{
+		{"artist", req.Artist},
+		{"album_artist", req.AlbumArtist},
+		{"album", req.Album},
+		{"genre", req.Genre},
+	} {
+		if filter[1] != "" {
+			filters = append(filters, fmt.Sprintf("%s = %s", filter[0], quoteFilter(filter[1])))
+		}
+	}
+	if req.Year != 0 {
+		filters = append(filters, fmt.Sprintf("year = %d", req.Year))
+	}
+	var result meilisearch.DocumentsResult
+	err := m.Client.Index(m.IndexUid).GetDocumentsWithContext(ctx, &meilisearch.DocumentsQuery{
+		Limit:  int64(model.MaxInt),
+		Filter: strings.Join(filters, " AND "),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]model.SearchNode, 0, len(result.Results))
+	for _, src := range result.Results {
+		// filtering strings is case-insensitive on meilisearch
+		if node := buildSearchNodeFromResults(src); req.Match(&node) {
+			tracks = append(tracks, node)
+		}
+	}
+	return tracks, nil
+}
+
+func (m *Meilisearch) Artists(ctx context.Context, req model.LibraryReq) ([]model.LibraryArtist, int64, error) {
+	tracks, err := m.tracks(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, total := model.Paginate(searcher.GroupArtists(tracks), req.PageReq)
+	return res, total, nil
+}
+
+func (m *Meilisearch) Albums(ctx context.Context, req model.LibraryReq) ([]model.LibraryAlbum, int64, error) {
+	tracks, err := m.tracks(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, total := model.Paginate(searcher.GroupAlbums(tracks), req.PageReq)
+	return res, total, nil
+}
+
+func (m *Meilisearch) Tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, int64, error) {
+	tracks, err := m.tracks(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	searcher.SortTracks(tracks)
+	res, total := model.Paginate(tracks, req.PageReq)
+	return res, total, nil
+}
@@ -1,6 +1,10 @@
 package meilisearch
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
@@ -12,20 +16,55 @@ func hashPath(path string) string {
 	return utils.HashData(utils.SHA1, []byte(path))
 }
 
-func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
-	searchNode := model.SearchNode{}
-	document := &searchDocument{
-		SearchNode: searchNode,
+// number converts a numeric document field, which may be decoded as any numeric type
+func number(v any) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return i
 	}
+	return 0
+}
 
+func buildSearchNodeFromResults(results map[string]any) model.SearchNode {
+	searchNode := model.SearchNode{}
 	// use assertion test to avoid panic
 	searchNode.Parent, _ = results["parent"].(string)
 	searchNode.Name, _ = results["name"].(string)
 	searchNode.IsDir, _ = results["is_dir"].(bool)
-	searchNode.Size, _ = results["size"].(int64)
+	searchNode.Size = number(results["size"])
+	searchNode.Title, _ = results["title"].(string)
+	searchNode.Artist, _ = results["artist"].(string)
+	searchNode.AlbumArtist, _ = results["album_artist"].(string)
+	searchNode.Album, _ = results["album"].(string)
+	searchNode.Genre, _ = results["genre"].(string)
+	searchNode.Year = int(number(results["year"]))
+	searchNode.Track = int(number(results["track"]))
+	if modified, ok := results["modified"].(string); ok {
+		searchNode.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+	}
+	searchNode.Ext, _ = results["ext"].(string)
+	searchNode.MediaType = int(number(results["media_type"]))
+	return searchNode
+}
 
+func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
+	document := &searchDocument{
+		SearchNode: buildSearchNodeFromResults(results),
+	}
 	document.ID, _ = results["id"].(string)
 	document.ParentHash, _ = results["parent_hash"].(string)
 	document.ParentPathHashes, _ = results["parent_path_hashes"].([]string)
 	return document
 }
+
+// quoteFilter quotes a string value of a filter expression
+func quoteFilter(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
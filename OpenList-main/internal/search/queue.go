@@ -0,0 +1,185 @@
+package search
+
+import (
+	"context"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// queueDebounce is how long the writes have to settle before the index is updated
+	queueDebounce = 2 * time.Second
+	// queueMaxDelay bounds how long a change waits while the writes keep coming
+	queueMaxDelay = 30 * time.Second
+)
+
+// mutation is a pending change of the index at a path,
+// obj is got again when applied if unknown
+type mutation struct {
+	seq     uint64
+	path    string
+	obj     model.Obj
+	removed bool
+}
+
+// updateQueue collects the changes made by the writes and applies them in batches,
+// only the last change of a path is kept
+type updateQueue struct {
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]mutation
+	timer   *time.Timer
+	first   time.Time
+	// applying serializes the batches
+	applying sync.Mutex
+}
+
+var queue = &updateQueue{pending: make(map[string]mutation)}
+
+func autoUpdate() bool {
+	return instance != nil && instance.Config().AutoUpdate && setting.GetBool(conf.AutoUpdateIndex)
+}
+
+func (q *updateQueue) push(m mutation) {
+	if !autoUpdate() || isIgnorePath(m.path) {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if m.removed {
+		// the changes inside a removed folder are meaningless now
+		for p := range q.pending {
+			if utils.IsSubPath(m.path, p) {
+				delete(q.pending, p)
+			}
+		}
+	}
+	q.seq++
+	m.seq = q.seq
+	q.pending[m.path] = m
+	now := time.Now()
+	if q.timer == nil {
+		q.first = now
+		q.timer = time.AfterFunc(queueDebounce, q.flush)
+	} else if now.Sub(q.first) < queueMaxDelay {
+		q.timer.Reset(queueDebounce)
+	}
+}
+
+func (q *updateQueue) flush() {
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = make(map[string]mutation)
+	q.timer = nil
+	q.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	mutations := make([]mutation, 0, len(pending))
+	for _, m := range pending {
+		mutations = append(mutations, m)
+	}
+	sort.Slice(mutations, func(i, j int) bool {
+		return mutations[i].seq < mutations[j].seq
+	})
+	q.applying.Lock()
+	defer q.applying.Unlock()
+	q.apply(context.Background(), mutations)
+}
+
+func (q *updateQueue) apply(ctx context.Context, mutations []mutation) {
+	if !autoUpdate() || Running() {
+		return
+	}
+	// only update when index have built
+	progress, err := Progress()
+	if err != nil {
+		log.Errorf("update search index error while get progress: %+v", err)
+		return
+	}
+	if !progress.IsDone {
+		return
+	}
+	var rebuilt []string
+	for _, m := range mutations {
+		if slices.ContainsFunc(rebuilt, func(dir string) bool { return utils.IsSubPath(dir, m.path) }) {
+			// the folder has been indexed again as it is now
+			continue
+		}
+		isDir, err := q.applyOne(ctx, m)
+		if err != nil {
+			log.Errorf("update search index of %s error: %+v", m.path, err)
+		} else if isDir {
+			rebuilt = append(rebuilt, m.path)
+		}
+	}
+}
+
+// applyOne replaces the nodes of the path with the object there, a folder is indexed with its content
+func (q *updateQueue) applyOne(ctx context.Context, m mutation) (bool, error) {
+	log.Debugf("update index: %s", m.path)
+	if err := instance.Del(ctx, m.path); err != nil {
+		return false, err
+	}
+	if m.removed {
+		return false, nil
+	}
+	// the rest of the depth under the path, the full build doesn't go deeper either
+	depth := setting.GetInt(conf.MaxIndexDepth, 20) - strings.Count(m.path, "/")
+	if depth < 0 {
+		return false, nil
+	}
+	obj := m.obj
+	if obj == nil {
+		var err error
+		obj, err = fs.Get(ctx, m.path, &fs.GetArgs{NoLog: true})
+		if errs.IsObjectNotFound(err) {
+			// removed since
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if !obj.IsDir() {
+		return false, Index(ctx, path.Dir(m.path), obj)
+	}
+	return true, BuildIndex(ctx, []string{m.path}, conf.SlicesMap[conf.IgnorePaths], depth, false)
+}
+
+// pushChange queues the change of the object at the actual path of the storage
+func pushChange(storage driver.Driver, actualPath string, obj model.Obj, removed bool) {
+	if storage.GetStorage().DisableIndex {
+		return
+	}
+	queue.push(mutation{
+		path:    utils.GetFullPath(storage.GetStorage().MountPath, actualPath),
+		obj:     obj,
+		removed: removed,
+	})
+}
+
+func init() {
+	op.RegisterObjChangeHook(func(ctx context.Context, change op.ObjChange) {
+		pushChange(change.Storage, change.Path, change.Obj, change.Removed)
+	})
+	op.RegisterObjMoveHook(func(ctx context.Context, move op.ObjMove) {
+		pushChange(move.SrcStorage, move.SrcPath, nil, true)
+		pushChange(move.DstStorage, move.DstPath, move.DstObj, false)
+	})
+}
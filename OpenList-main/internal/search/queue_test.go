@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:search_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+// fakeSearcher records the changes of the index
+type fakeSearcher struct {
+	searcher.Searcher
+	deleted []string
+	indexed []model.SearchNode
+}
+
+func (s *fakeSearcher) Config() searcher.Config {
+	return searcher.Config{Name: "fake", AutoUpdate: true}
+}
+
+func (s *fakeSearcher) Del(_ context.Context, prefix string) error {
+	s.deleted = append(s.deleted, prefix)
+	return nil
+}
+
+func (s *fakeSearcher) Index(_ context.Context, node model.SearchNode) error {
+	s.indexed = append(s.indexed, node)
+	return nil
+}
+
+func useFakeSearcher(t *testing.T) *fakeSearcher {
+	s := &fakeSearcher{}
+	old := instance
+	instance = s
+	t.Cleanup(func() { instance = old })
+	if err := op.SaveSettingItems([]model.SettingItem{
+		{Key: conf.AutoUpdateIndex, Value: "true", Type: conf.TypeBool, Group: model.INDEX},
+		{Key: conf.MaxIndexDepth, Value: "2", Type: conf.TypeNumber, Group: model.INDEX},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestQueue(t *testing.T) *updateQueue {
+	q := &updateQueue{pending: make(map[string]mutation)}
+	t.Cleanup(func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.timer != nil {
+			q.timer.Stop()
+		}
+	})
+	return q
+}
+
+func TestQueueCoalesces(t *testing.T) {
+	useFakeSearcher(t)
+	q := newTestQueue(t)
+	q.push(mutation{path: "/a/x.txt", obj: &model.Object{Name: "x.txt"}})
+	timer, first := q.timer, q.first
+	q.push(mutation{path: "/a/y.txt"})
+	q.push(mutation{path: "/a/x.txt", removed: true})
+
+	if q.timer != timer || !q.first.Equal(first) {
+		t.Error("the writes should wait for the same batch")
+	}
+	if len(q.pending) != 2 {
+		t.Fatalf("expected a pending change per path, got %v", q.pending)
+	}
+	if m := q.pending["/a/x.txt"]; !m.removed || m.seq <= q.pending["/a/y.txt"].seq {
+		t.Errorf("expected only the last change of the path to be kept, got %+v", m)
+	}
+}
+
+func TestQueueRemoveDropsChildren(t *testing.T) {
+	useFakeSearcher(t)
+	q := newTestQueue(t)
+	q.push(mutation{path: "/a/b/c.txt"})
+	q.push(mutation{path: "/a/d.txt"})
+	q.push(mutation{path: "/ab.txt"})
+	q.push(mutation{path: "/a", removed: true})
+
+	if len(q.pending) != 2 {
+		t.Fatalf("expected the changes inside the removed folder to be dropped, got %v", q.pending)
+	}
+	if _, ok := q.pending["/ab.txt"]; !ok {
+		t.Error("the change of a sibling sharing the prefix was dropped")
+	}
+	if !q.pending["/a"].removed {
+		t.Error("the removal is not pending")
+	}
+}
+
+func TestQueueDepthCutoff(t *testing.T) {
+	s := useFakeSearcher(t)
+	q := newTestQueue(t)
+	for _, m := range []mutation{
+		{path: "/a/b/deep", obj: &model.Object{Name: "deep", IsFolder: true}},
+		{path: "/a/b/c.txt", obj: &model.Object{Name: "c.txt"}},
+	} {
+		isDir, err := q.applyOne(context.Background(), m)
+		if err != nil || isDir {
+			t.Errorf("applyOne(%s) = %v, %v", m.path, isDir, err)
+		}
+	}
+	if _, err := q.applyOne(context.Background(), mutation{path: "/a/b.txt", obj: &model.Object{Name: "b.txt"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.deleted) != 3 {
+		t.Errorf("expected the old nodes to be deleted at any depth, got %v", s.deleted)
+	}
+	if len(s.indexed) != 1 || s.indexed[0].Parent != "/a" || s.indexed[0].Name != "b.txt" {
+		t.Errorf("expected only the object within the max depth to be indexed, got %+v", s.indexed)
+	}
+}
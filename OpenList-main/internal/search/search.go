@@ -3,12 +3,16 @@ package search
 import (
 	"context"
 	"fmt"
+	"path"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -55,12 +59,75 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
+	return instance.Index(ctx, toSearchNode(ctx, parent, obj))
+}
+
+// audioTagsTimeout bounds the time spent reading the tags of a single file while indexing
+const audioTagsTimeout = 10 * time.Second
+
+func toSearchNode(ctx context.Context, parent string, obj model.Obj) model.SearchNode {
+	node := model.SearchNode{
 		Parent: parent,
 		Name:   obj.GetName(),
 		IsDir:  obj.IsDir(),
 		Size:   obj.GetSize(),
-	})
+		// the modified time is kept in utc so that all the searchers compare it the same way
+		Modified:  obj.ModTime().UTC(),
+		MediaType: utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}
+	if !obj.IsDir() {
+		node.Ext = utils.Ext(node.Name)
+	}
+	if !obj.IsDir() && utils.GetFileType(node.Name) == conf.AUDIO && setting.GetBool(conf.IndexAudioTags) {
+		fillAudioTags(ctx, &node)
+	}
+	return node
+}
+
+func fillAudioTags(ctx context.Context, node *model.SearchNode) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path.Join(node.Parent, node.Name))
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, audioTagsTimeout)
+	defer cancel()
+	// the tags are not cached, indexing reads every file once
+	meta, err := op.ReadAudioMeta(ctx, storage, actualPath)
+	if err != nil {
+		log.Debugf("failed read audio tags of %s: %+v", path.Join(node.Parent, node.Name), err)
+		return
+	}
+	node.Title = meta.Title
+	node.Artist = meta.Artist
+	node.AlbumArtist = meta.AlbumArtist
+	if node.AlbumArtist == "" {
+		node.AlbumArtist = meta.Artist
+	}
+	node.Album = meta.Album
+	node.Genre = meta.Genre
+	node.Year = meta.Year
+	node.Track = meta.Track
+}
+
+func Artists(ctx context.Context, req model.LibraryReq) ([]model.LibraryArtist, int64, error) {
+	if instance == nil {
+		return nil, 0, errs.SearchNotAvailable
+	}
+	return instance.Artists(ctx, req)
+}
+
+func Albums(ctx context.Context, req model.LibraryReq) ([]model.LibraryAlbum, int64, error) {
+	if instance == nil {
+		return nil, 0, errs.SearchNotAvailable
+	}
+	return instance.Albums(ctx, req)
+}
+
+func Tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, int64, error) {
+	if instance == nil {
+		return nil, 0, errs.SearchNotAvailable
+	}
+	return instance.Tracks(ctx, req)
 }
 
 type ObjWithParent struct {
@@ -77,12 +144,7 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	}
 	var searchNodes []model.SearchNode
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		searchNodes = append(searchNodes, toSearchNode(ctx, objs[i].Parent, objs[i].Obj))
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
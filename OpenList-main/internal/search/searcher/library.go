@@ -0,0 +1,89 @@
+package searcher
+
+import (
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// The helpers below group tracks in memory, for searchers that cannot aggregate by themselves.
+// The tracks must already be filtered by model.LibraryReq.Match.
+
+func SortTracks(tracks []model.SearchNode) {
+	sort.SliceStable(tracks, func(i, j int) bool {
+		a, b := &tracks[i], &tracks[j]
+		if a.AlbumArtist != b.AlbumArtist {
+			return a.AlbumArtist < b.AlbumArtist
+		}
+		if a.Album != b.Album {
+			return a.Album < b.Album
+		}
+		if a.Track != b.Track {
+			return a.Track < b.Track
+		}
+		return a.Name < b.Name
+	})
+}
+
+func GroupArtists(tracks []model.SearchNode) []model.LibraryArtist {
+	albums := make(map[string]mapset.Set[string])
+	res := make(map[string]*model.LibraryArtist)
+	for i := range tracks {
+		name := tracks[i].Artist
+		if name == "" {
+			continue
+		}
+		artist, ok := res[name]
+		if !ok {
+			artist = &model.LibraryArtist{Name: name}
+			res[name] = artist
+			albums[name] = mapset.NewThreadUnsafeSet[string]()
+		}
+		artist.TrackCount++
+		if tracks[i].Album != "" {
+			albums[name].Add(tracks[i].Album)
+		}
+	}
+	artists := make([]model.LibraryArtist, 0, len(res))
+	for name, artist := range res {
+		artist.AlbumCount = int64(albums[name].Cardinality())
+		artists = append(artists, *artist)
+	}
+	sort.Slice(artists, func(i, j int) bool {
+		return artists[i].Name < artists[j].Name
+	})
+	return artists
+}
+
+func GroupAlbums(tracks []model.SearchNode) []model.LibraryAlbum {
+	type key struct{ album, artist string }
+	res := make(map[key]*model.LibraryAlbum)
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Album == "" {
+			continue
+		}
+		k := key{t.Album, t.AlbumArtist}
+		album, ok := res[k]
+		if !ok {
+			album = &model.LibraryAlbum{Name: t.Album, Artist: t.AlbumArtist, Parent: t.Parent}
+			res[k] = album
+		}
+		album.TrackCount++
+		album.Year = max(album.Year, t.Year)
+		album.Genre = max(album.Genre, t.Genre)
+		album.Parent = min(album.Parent, t.Parent)
+	}
+	albums := make([]model.LibraryAlbum, 0, len(res))
+	for _, album := range res {
+		albums = append(albums, *album)
+	}
+	sort.Slice(albums, func(i, j int) bool {
+		if albums[i].Name != albums[j].Name {
+			return albums[i].Name < albums[j].Name
+		}
+		return albums[i].Artist < albums[j].Artist
+	})
+	return albums
+}
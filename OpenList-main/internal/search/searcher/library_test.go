@@ -0,0 +1,44 @@
+package searcher
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+var testTracks = []model.SearchNode{
+	{Parent: "/music/b", Name: "2.flac", Artist: "B", AlbumArtist: "Various", Album: "Mix", Year: 2010, Track: 2},
+	{Parent: "/music/a", Name: "1.flac", Artist: "A", AlbumArtist: "Various", Album: "Mix", Year: 2011, Track: 1},
+	{Parent: "/music/a", Name: "3.mp3", Artist: "A", AlbumArtist: "A", Album: "Solo", Genre: "Jazz"},
+	{Parent: "/music/c", Name: "4.mp3", Artist: "C"},
+}
+
+func TestGroupAlbums(t *testing.T) {
+	albums := GroupAlbums(testTracks)
+	if len(albums) != 2 {
+		t.Fatalf("GroupAlbums() = %+v", albums)
+	}
+	mix := albums[0]
+	if mix.Name != "Mix" || mix.Artist != "Various" || mix.TrackCount != 2 || mix.Year != 2011 || mix.Parent != "/music/a" {
+		t.Errorf("GroupAlbums()[0] = %+v", mix)
+	}
+	artists := GroupArtists(testTracks)
+	if len(artists) != 3 || artists[0].Name != "A" || artists[0].AlbumCount != 2 || artists[0].TrackCount != 2 {
+		t.Errorf("GroupArtists() = %+v", artists)
+	}
+}
+
+func TestSortTracks(t *testing.T) {
+	tracks := append([]model.SearchNode(nil), testTracks...)
+	SortTracks(tracks)
+	want := []string{"4.mp3", "3.mp3", "1.flac", "2.flac"}
+	for i, name := range want {
+		if tracks[i].Name != name {
+			t.Fatalf("SortTracks()[%d] = %s, want %s", i, tracks[i].Name, name)
+		}
+	}
+	page, total := model.Paginate(tracks, model.PageReq{Page: 2, PerPage: 3})
+	if total != 4 || len(page) != 1 || page[0].Name != "2.flac" {
+		t.Errorf("Paginate() = %+v, %d", page, total)
+	}
+}
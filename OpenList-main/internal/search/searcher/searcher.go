@@ -28,4 +28,10 @@ type Searcher interface {
 	Release(ctx context.Context) error
 	// Clear all index
 	Clear(ctx context.Context) error
+	// Artists groups the indexed tracks by artist
+	Artists(ctx context.Context, req model.LibraryReq) ([]model.LibraryArtist, int64, error)
+	// Albums groups the indexed tracks by album and album artist
+	Albums(ctx context.Context, req model.LibraryReq) ([]model.LibraryAlbum, int64, error)
+	// Tracks lists the indexed tracks in album order
+	Tracks(ctx context.Context, req model.LibraryReq) ([]model.SearchNode, int64, error)
 }
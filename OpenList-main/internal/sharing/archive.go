@@ -15,14 +15,17 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
+	if !op.SharingValid(sharing) {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing, err = resolveFiles(sharing); err != nil {
+		return nil, nil, err
+	}
 	path = utils.FixAndCleanPath(path)
-	if len(sharing.Files) == 1 || path != "/" {
+	if sharing.SingleRoot() || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
@@ -42,14 +45,17 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
+	if !op.SharingValid(sharing) {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing, err = resolveFiles(sharing); err != nil {
+		return nil, nil, err
+	}
 	path = utils.FixAndCleanPath(path)
-	if len(sharing.Files) == 1 || path != "/" {
+	if sharing.SingleRoot() || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
@@ -0,0 +1,51 @@
+package sharing
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// resolveFiles returns the sharing with the files of its source filled in. The favorites of a
+// favorite folder sharing are read on every access so that the recipient always sees the
+// current content of the folder, the cached sharing itself is left untouched.
+func resolveFiles(sharing *model.Sharing) (*model.Sharing, error) {
+	if !sharing.FromFavoriteFolder() {
+		return sharing, nil
+	}
+	folder, err := db.GetFavoriteFolderById(sharing.SourceId, sharing.CreatorId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithStack(errs.InvalidSharing)
+		}
+		return nil, err
+	}
+	favorites, err := db.ListFavoritesByFolder(folder.ID, sharing.CreatorId)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(favorites))
+	names := make(map[string]struct{}, len(favorites))
+	for _, favorite := range favorites {
+		path, err := sharing.Creator.JoinPath(favorite.OriginalPath)
+		// the access rules may deny sharing some of the favorites, even after the sharing is created
+		if err != nil || !op.CanShare(sharing.Creator, path) {
+			continue
+		}
+		// children of a sharing are addressed by name, only the newest favorite of a name is reachable
+		name := stdpath.Base(path)
+		if _, ok := names[name]; ok {
+			continue
+		}
+		names[name] = struct{}{}
+		files = append(files, path)
+	}
+	res := *sharing
+	res.Files = files
+	return &res, nil
+}
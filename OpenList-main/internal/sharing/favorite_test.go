@@ -0,0 +1,50 @@
+package sharing
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:sharing_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestResolveFilesChecksShareACL(t *testing.T) {
+	creator := &model.User{ID: 1, Username: "bob", Permission: 1 << 14}
+	folder := &model.FavoriteFolder{UserId: creator.ID, Name: "videos"}
+	if err := db.CreateFavoriteFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/public/a.mp4", "/private/b.mp4"} {
+		if err := db.CreateFavorite(&model.Favorite{UserId: creator.ID, FolderId: folder.ID, OriginalPath: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := op.CreateMeta(&model.Meta{Path: "/private", ASub: true,
+		ACL: []model.ACLRule{{User: "bob", Deny: true, Actions: []string{model.ACLShare}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{SourceType: model.SharingSourceFavoriteFolder, SourceId: folder.ID, CreatorId: creator.ID},
+		Creator:   creator,
+	}
+	res, err := resolveFiles(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 1 || res.Files[0] != "/public/a.mp4" {
+		t.Errorf("resolved files = %v, want only /public/a.mp4", res.Files)
+	}
+}
@@ -17,14 +17,17 @@ func link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing
 	if err != nil {
 		return nil, nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
+	if !op.SharingValid(sharing) {
 		return sharing, nil, nil, errors.WithStack(errs.InvalidSharing)
 	}
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing, err = resolveFiles(sharing); err != nil {
+		return nil, nil, nil, err
+	}
 	path = utils.FixAndCleanPath(path)
-	if len(sharing.Files) == 1 || path != "/" {
+	if sharing.SingleRoot() || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
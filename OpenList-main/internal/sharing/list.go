@@ -3,6 +3,7 @@ package sharing
 import (
 	"context"
 	stdpath "path"
+	"slices"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -16,14 +17,17 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
+	if !op.SharingValid(sharing) {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing, err = resolveFiles(sharing); err != nil {
+		return nil, nil, err
+	}
 	path = utils.FixAndCleanPath(path)
-	if len(sharing.Files) == 1 || path != "/" {
+	if sharing.SingleRoot() || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
@@ -45,12 +49,18 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 		}
 		om := model.NewObjMerge()
 		objs = om.Merge(objs, virtualFiles...)
+		objs = slices.DeleteFunc(objs, func(obj model.Obj) bool {
+			return op.SharingPathDenied(sharing, stdpath.Join(unwrapPath, obj.GetName()))
+		})
 		model.SortFiles(objs, sharing.OrderBy, sharing.OrderDirection)
 		model.ExtractFolder(objs, sharing.ExtractFolder)
 		return sharing, objs, nil
 	}
 	objs := make([]model.Obj, 0, len(sharing.Files))
 	for _, f := range sharing.Files {
+		if op.SharingPathDenied(sharing, f) {
+			continue
+		}
 		if f != "/" {
 			isVf := false
 			virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(f))
@@ -43,6 +43,16 @@ func ArchiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	return sharing, res, nil
 }
 
+// ResolveFiles fills in the files of sharings that are not made of paths, such as favorite folders
+func ResolveFiles(sharing *model.Sharing) (*model.Sharing, error) {
+	res, err := resolveFiles(sharing)
+	if err != nil {
+		log.Warnf("failed resolve sharing files %s: %s", sharing.ID, err)
+		return nil, err
+	}
+	return res, nil
+}
+
 type LinkArgs struct {
 	model.SharingListArgs
 	model.LinkArgs
@@ -1,6 +1,9 @@
 package sign
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,8 +35,35 @@ func NotExpired(data string) string {
 }
 
 func Verify(data string, sign string) error {
+	_, err := VerifyUser(data, sign)
+	return err
+}
+
+// SignUser signs data for the user, the downloads of a link signed so are accounted to the user
+func SignUser(data string, userId uint) string {
+	return fmt.Sprintf("%d.%s", userId, Sign(userData(data, userId)))
+}
+
+func WithDurationUser(data string, userId uint, d time.Duration) string {
+	return fmt.Sprintf("%d.%s", userId, WithDuration(userData(data, userId), d))
+}
+
+// VerifyUser verifies the sign of data and returns the user it is signed for, 0 if it is not signed for a user
+func VerifyUser(data string, s string) (uint, error) {
 	once.Do(Instance)
-	return instance.Verify(data, sign)
+	id, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return 0, instance.Verify(data, s)
+	}
+	userId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, sign.ErrSignInvalid
+	}
+	return uint(userId), instance.Verify(userData(data, uint(userId)), rest)
+}
+
+func userData(data string, userId uint) string {
+	return fmt.Sprintf("%s:user:%d", data, userId)
 }
 
 func Instance() {
@@ -63,11 +63,20 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 		return errors.WithMessagef(err, "failed get dst [%s] file", path.Join(dstStorage.GetStorage().MountPath, dstObjPath))
 	}
 
+	move := op.ObjMove{
+		SrcStorage: srcStorage,
+		SrcPath:    srcPath,
+		SrcObj:     model.UnwrapObj(srcObj),
+		DstStorage: dstStorage,
+		DstPath:    dstObjPath,
+		DstObj:     model.UnwrapObj(dstObj),
+	}
 	if !dstObj.IsDir() {
-		err = op.Remove(ctx, srcStorage, srcPath)
+		err = op.RemovePermanently(ctx, srcStorage, srcPath)
 		if err != nil {
 			return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
 		}
+		op.HandleObjMoveHook(ctx, move)
 		return nil
 	}
 
@@ -92,10 +101,11 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	if hasErr {
 		return errors.Errorf("some subitems of [%s] failed to verify and remove", path.Join(srcStorage.GetStorage().MountPath, srcPath))
 	}
-	err = op.Remove(ctx, srcStorage, srcPath)
+	err = op.RemovePermanently(ctx, srcStorage, srcPath)
 	if err != nil {
 		return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
 	}
+	op.HandleObjMoveHook(ctx, move)
 	return nil
 }
 
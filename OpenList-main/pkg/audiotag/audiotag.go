@@ -0,0 +1,77 @@
+// Package audiotag reads the embedded tags and the duration of audio files.
+package audiotag
+
+import (
+	"io"
+
+	"github.com/dhowden/tag"
+	"github.com/pkg/errors"
+)
+
+type Picture struct {
+	MIMEType string
+	Ext      string
+	Data     []byte
+}
+
+type Tags struct {
+	// Format is the tag format, such as ID3v2.4, VORBIS or MP4
+	Format      string
+	FileType    string
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	Track       int
+	TrackTotal  int
+	Disc        int
+	DiscTotal   int
+	// Duration in seconds, 0 if it could not be determined
+	Duration float64
+	Picture  *Picture
+}
+
+// ErrNotAudio is returned when neither tags nor a duration could be read
+var ErrNotAudio = errors.New("no audio tags found")
+
+// Read reads the tags and the duration of the audio file of the given size.
+// Only the parts holding the tags are read, which are at the head or the tail of the file.
+func Read(r io.ReadSeeker, size int64) (*Tags, error) {
+	res := &Tags{}
+	// broken tags are ignored like missing ones, the duration may still be read
+	m, err := tag.ReadFrom(r)
+	if err == nil && m != nil {
+		res.Format = string(m.Format())
+		res.FileType = string(m.FileType())
+		res.Title = m.Title()
+		res.Artist = m.Artist()
+		res.Album = m.Album()
+		res.AlbumArtist = m.AlbumArtist()
+		res.Genre = m.Genre()
+		res.Year = m.Year()
+		res.Track, res.TrackTotal = m.Track()
+		res.Disc, res.DiscTotal = m.Disc()
+		if p := m.Picture(); p != nil && len(p.Data) > 0 {
+			res.Picture = &Picture{MIMEType: p.MIMEType, Ext: p.Ext, Data: p.Data}
+		}
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// untagged mp3 files cannot be identified, they are recognized by their frames
+	_, fileType, _ := tag.Identify(r)
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res.FileType == "" {
+		res.FileType = string(fileType)
+	}
+	// the duration is best effort, a broken stream header should not hide the tags
+	res.Duration, _ = readDuration(r, size, fileType)
+	if res.Format == "" && res.Duration == 0 {
+		return nil, ErrNotAudio
+	}
+	return res, nil
+}
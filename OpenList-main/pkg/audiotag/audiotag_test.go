@@ -0,0 +1,110 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// mp3 builds a MPEG1 layer 3 128kbps 44.1kHz stereo stream of the given frames with an ID3v1 tag
+func mp3(frames int) []byte {
+	var buf bytes.Buffer
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	for i := 0; i < frames; i++ {
+		buf.Write(frame)
+	}
+	id3v1 := make([]byte, 128)
+	copy(id3v1, "TAG")
+	copy(id3v1[3:], "Title")
+	copy(id3v1[33:], "Artist")
+	copy(id3v1[63:], "Album")
+	copy(id3v1[93:], "2001")
+	id3v1[126] = 7 // track
+	buf.Write(id3v1)
+	return buf.Bytes()
+}
+
+func flac() []byte {
+	b := []byte("fLaC")
+	b = append(b, 0x80, 0, 0, 34) // last STREAMINFO block
+	info := make([]byte, 34)
+	// 44100Hz, 2 channels, 16 bits, 441000 samples
+	v := uint64(44100)<<44 | uint64(1)<<41 | uint64(15)<<36 | 441000
+	binary.BigEndian.PutUint64(info[10:18], v)
+	return append(b, info...)
+}
+
+func mp4() []byte {
+	atom := func(name string, body []byte) []byte {
+		b := make([]byte, 8, 8+len(body))
+		binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+		copy(b[4:], name)
+		return append(b, body...)
+	}
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)   // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 185500) // duration
+	ftyp := atom("ftyp", []byte("M4A \x00\x00\x00\x00M4A mp42isom"))
+	return append(append(ftyp, atom("free", make([]byte, 16))...), atom("moov", atom("mvhd", mvhd))...)
+}
+
+func ogg() []byte {
+	page := func(granule uint64, packet []byte) []byte {
+		h := make([]byte, 27)
+		copy(h, "OggS")
+		binary.LittleEndian.PutUint64(h[6:14], granule)
+		h[26] = 1
+		h = append(h, byte(len(packet)))
+		return append(h, packet...)
+	}
+	id := make([]byte, 30)
+	copy(id, "\x01vorbis")
+	id[11] = 2
+	binary.LittleEndian.PutUint32(id[12:16], 48000)
+	b := page(0, id)
+	b = append(b, make([]byte, 1000)...)
+	return append(b, page(48000*90, []byte("data"))...)
+}
+
+func TestRead(t *testing.T) {
+	testCases := map[string]struct {
+		data     []byte
+		duration float64
+		title    string
+	}{
+		"mp3":  {data: mp3(100), duration: 100 * 417 * 8 / 128000.0, title: "Title"},
+		"flac": {data: flac(), duration: 10},
+		"mp4":  {data: mp4(), duration: 185.5},
+		"ogg":  {data: ogg(), duration: 90},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tags, err := Read(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tags.Duration-tc.duration) > 0.01 {
+				t.Errorf("Duration = %v, want %v", tags.Duration, tc.duration)
+			}
+			if tags.Title != tc.title {
+				t.Errorf("Title = %q, want %q", tags.Title, tc.title)
+			}
+		})
+	}
+	if _, err := Read(bytes.NewReader(make([]byte, 4096)), 4096); err != ErrNotAudio {
+		t.Errorf("Read() of zeros = %v, want ErrNotAudio", err)
+	}
+}
+
+func TestMP3Tags(t *testing.T) {
+	data := mp3(10)
+	tags, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Format != "ID3v1" || tags.Artist != "Artist" || tags.Album != "Album" || tags.Year != 2001 || tags.Track != 7 {
+		t.Errorf("Read() = %+v", tags)
+	}
+}
@@ -0,0 +1,291 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/dhowden/tag"
+	"github.com/pkg/errors"
+)
+
+// how far the stream headers are searched, so that non audio files are not read entirely
+const (
+	maxFrameSearch = 64 << 10
+	oggTailSize    = 64 << 10
+	maxAtomDepth   = 4
+)
+
+var errNoDuration = errors.New("no duration found")
+
+func readDuration(r io.ReadSeeker, size int64, fileType tag.FileType) (float64, error) {
+	switch fileType {
+	case tag.FLAC:
+		return flacDuration(r)
+	case tag.OGG:
+		return oggDuration(r, size)
+	case tag.M4A, tag.M4B, tag.M4P, tag.ALAC:
+		return mp4Duration(r, size)
+	case tag.MP3, tag.UnknownFileType:
+		return mp3Duration(r, size)
+	default:
+		return 0, errNoDuration
+	}
+}
+
+func readAt(r io.ReadSeeker, off int64, n int) ([]byte, error) {
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	n, err := io.ReadFull(r, buf)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:n], err
+}
+
+// flacDuration reads the sample rate and the total samples of the STREAMINFO block
+func flacDuration(r io.ReadSeeker) (float64, error) {
+	b, err := readAt(r, 0, 4+4+18)
+	if err != nil || len(b) < 26 || string(b[:4]) != "fLaC" || b[4]&0x7f != 0 {
+		return 0, errNoDuration
+	}
+	v := binary.BigEndian.Uint64(b[18:26])
+	sampleRate := v >> 44
+	samples := v & (1<<36 - 1)
+	if sampleRate == 0 || samples == 0 {
+		return 0, errNoDuration
+	}
+	return float64(samples) / float64(sampleRate), nil
+}
+
+// oggDuration divides the granule position of the last page by the sample rate of the
+// vorbis or opus identification header
+func oggDuration(r io.ReadSeeker, size int64) (float64, error) {
+	head, err := readAt(r, 0, 28+19)
+	if err != nil || len(head) < 28+19 || string(head[:4]) != "OggS" {
+		return 0, errNoDuration
+	}
+	// the identification packet starts after the header and the segment table of the first page
+	packet := head[27+int(head[26]):]
+	var rate, preSkip float64
+	switch {
+	case len(packet) >= 16 && string(packet[:7]) == "\x01vorbis":
+		rate = float64(binary.LittleEndian.Uint32(packet[12:16]))
+	case len(packet) >= 12 && string(packet[:8]) == "OpusHead":
+		// opus granule positions always count at 48kHz
+		rate = 48000
+		preSkip = float64(binary.LittleEndian.Uint16(packet[10:12]))
+	default:
+		return 0, errNoDuration
+	}
+	if rate == 0 {
+		return 0, errNoDuration
+	}
+	off := max(size-oggTailSize, 0)
+	tail, err := readAt(r, off, int(size-off))
+	if err != nil {
+		return 0, err
+	}
+	idx := bytes.LastIndex(tail, []byte("OggS"))
+	if idx < 0 || idx+14 > len(tail) {
+		return 0, errNoDuration
+	}
+	granule := int64(binary.LittleEndian.Uint64(tail[idx+6 : idx+14]))
+	if granule <= 0 {
+		return 0, errNoDuration
+	}
+	return max(float64(granule)-preSkip, 0) / rate, nil
+}
+
+// mp4Duration reads the time scale and the duration of the mvhd atom in moov
+func mp4Duration(r io.ReadSeeker, size int64) (float64, error) {
+	return findMvhd(r, 0, size, 0)
+}
+
+func findMvhd(r io.ReadSeeker, start, end int64, depth int) (float64, error) {
+	for off := start; off+8 <= end; {
+		h, err := readAt(r, off, 16)
+		if err != nil || len(h) < 8 {
+			return 0, errNoDuration
+		}
+		atomSize := int64(binary.BigEndian.Uint32(h[:4]))
+		headerSize := int64(8)
+		switch atomSize {
+		case 0:
+			atomSize = end - off
+		case 1:
+			if len(h) < 16 {
+				return 0, errNoDuration
+			}
+			atomSize = int64(binary.BigEndian.Uint64(h[8:16]))
+			headerSize = 16
+		}
+		if atomSize < headerSize {
+			return 0, errNoDuration
+		}
+		switch string(h[4:8]) {
+		case "moov":
+			if depth < maxAtomDepth {
+				return findMvhd(r, off+headerSize, min(off+atomSize, end), depth+1)
+			}
+		case "mvhd":
+			b, err := readAt(r, off+headerSize, 32)
+			if err != nil || len(b) < 20 {
+				return 0, errNoDuration
+			}
+			var timescale, duration uint64
+			if b[0] == 1 {
+				if len(b) < 32 {
+					return 0, errNoDuration
+				}
+				timescale = uint64(binary.BigEndian.Uint32(b[20:24]))
+				duration = binary.BigEndian.Uint64(b[24:32])
+			} else {
+				timescale = uint64(binary.BigEndian.Uint32(b[12:16]))
+				duration = uint64(binary.BigEndian.Uint32(b[16:20]))
+			}
+			if timescale == 0 {
+				return 0, errNoDuration
+			}
+			return float64(duration) / float64(timescale), nil
+		}
+		off += atomSize
+	}
+	return 0, errNoDuration
+}
+
+var mp3Bitrates = [5][15]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448}, // MPEG1 layer 1
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},    // MPEG1 layer 2
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},     // MPEG1 layer 3
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},    // MPEG2 layer 1
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},         // MPEG2 layer 2 and 3
+}
+
+var mp3SampleRates = [3][3]int{
+	{44100, 48000, 32000}, // MPEG1
+	{22050, 24000, 16000}, // MPEG2
+	{11025, 12000, 8000},  // MPEG2.5
+}
+
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int // in kbps
+	sampleRate int
+	samples    int // per frame
+	size       int
+}
+
+func parseMP3Frame(h []byte) (mp3Frame, bool) {
+	var f mp3Frame
+	if len(h) < 4 || h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return f, false
+	}
+	version := (h[1] >> 3) & 3 // 0: MPEG2.5, 2: MPEG2, 3: MPEG1
+	layer := 4 - int((h[1]>>1)&3)
+	bitrateIdx := h[2] >> 4
+	rateIdx := (h[2] >> 2) & 3
+	if version == 1 || layer == 4 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return f, false
+	}
+	f.mpeg1 = version == 3
+	f.mono = h[3]>>6 == 3
+	switch {
+	case f.mpeg1:
+		f.bitrate = mp3Bitrates[layer-1][bitrateIdx]
+		f.sampleRate = mp3SampleRates[0][rateIdx]
+	case layer == 1:
+		f.bitrate = mp3Bitrates[3][bitrateIdx]
+	default:
+		f.bitrate = mp3Bitrates[4][bitrateIdx]
+	}
+	if version == 2 {
+		f.sampleRate = mp3SampleRates[1][rateIdx]
+	} else if version == 0 {
+		f.sampleRate = mp3SampleRates[2][rateIdx]
+	}
+	padding := int((h[2] >> 1) & 1)
+	switch {
+	case layer == 1:
+		f.samples = 384
+		f.size = (12*f.bitrate*1000/f.sampleRate + padding) * 4
+	case layer == 2 || f.mpeg1:
+		f.samples = 1152
+		f.size = 144*f.bitrate*1000/f.sampleRate + padding
+	default:
+		f.samples = 576
+		f.size = 72*f.bitrate*1000/f.sampleRate + padding
+	}
+	return f, f.size > 4
+}
+
+// id3v2Size returns the size of the ID3v2 tag at the head of the file, 0 if there is none
+func id3v2Size(r io.ReadSeeker) int64 {
+	h, err := readAt(r, 0, 10)
+	if err != nil || len(h) < 10 || string(h[:3]) != "ID3" {
+		return 0
+	}
+	size := int64(h[6]&0x7f)<<21 | int64(h[7]&0x7f)<<14 | int64(h[8]&0x7f)<<7 | int64(h[9]&0x7f)
+	size += 10
+	if h[5]&0x10 != 0 {
+		// footer present
+		size += 10
+	}
+	return size
+}
+
+// mp3Duration uses the frame count of a Xing/Info or VBRI header if present,
+// otherwise assumes a constant bitrate
+func mp3Duration(r io.ReadSeeker, size int64) (float64, error) {
+	start := id3v2Size(r)
+	buf, err := readAt(r, start, maxFrameSearch)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i+4 <= len(buf); i++ {
+		f, ok := parseMP3Frame(buf[i:])
+		if !ok {
+			continue
+		}
+		// a valid frame must be followed by another one, sync words also appear in random data
+		if i+f.size+4 <= len(buf) {
+			if _, ok := parseMP3Frame(buf[i+f.size:]); !ok {
+				continue
+			}
+		}
+		frame := buf[i:]
+		if frames := vbrFrames(frame, f); frames > 0 {
+			return float64(frames) * float64(f.samples) / float64(f.sampleRate), nil
+		}
+		audioSize := size - start - int64(i)
+		if tail, err := readAt(r, size-128, 3); err == nil && string(tail) == "TAG" {
+			audioSize -= 128
+		}
+		return float64(audioSize) * 8 / float64(f.bitrate*1000), nil
+	}
+	return 0, errNoDuration
+}
+
+func vbrFrames(frame []byte, f mp3Frame) uint32 {
+	// the Xing header follows the side information of the first frame
+	xing := 4 + 17
+	switch {
+	case f.mpeg1 && !f.mono:
+		xing = 4 + 32
+	case !f.mpeg1 && f.mono:
+		xing = 4 + 9
+	}
+	if len(frame) >= xing+12 {
+		id := string(frame[xing : xing+4])
+		if (id == "Xing" || id == "Info") && frame[xing+7]&1 != 0 {
+			return binary.BigEndian.Uint32(frame[xing+8 : xing+12])
+		}
+	}
+	if len(frame) >= 4+32+18 && string(frame[36:40]) == "VBRI" {
+		return binary.BigEndian.Uint32(frame[36+14 : 36+18])
+	}
+	return 0
+}
@@ -0,0 +1,262 @@
+// Package chapters reads and writes chapter lists as WebVTT and FFmetadata files.
+package chapters
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Chapter struct {
+	Start   float64 // in seconds
+	Title   string
+	Content string
+}
+
+// ends returns the end time of every chapter, which is the start of the next one.
+// The last chapter ends at duration, or one second after its start if duration is unknown.
+func ends(chapters []Chapter, duration float64) []float64 {
+	res := make([]float64, len(chapters))
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			res[i] = chapters[i+1].Start
+		} else if duration > chapters[i].Start {
+			res[i] = duration
+		} else {
+			res[i] = chapters[i].Start + 1
+		}
+	}
+	return res
+}
+
+func sorted(chapters []Chapter) []Chapter {
+	res := append([]Chapter(nil), chapters...)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Start < res[j].Start })
+	return res
+}
+
+// WebVTT
+
+func formatVTTTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func parseVTTTime(s string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errors.Errorf("invalid timestamp: %s", s)
+	}
+	var res float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, errors.Errorf("invalid timestamp: %s", s)
+		}
+		res += v * math.Pow(60, float64(len(parts)-1-i))
+	}
+	return res, nil
+}
+
+// vttLine makes sure a text line does not end the cue or look like a timing line
+func vttLine(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "-->", "->")
+}
+
+// WriteWebVTT writes the chapters as a WebVTT chapters track. The title is the
+// first line of a cue and the content, if any, follows on the next lines.
+func WriteWebVTT(w io.Writer, chapters []Chapter, duration float64) error {
+	chapters = sorted(chapters)
+	end := ends(chapters, duration)
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n")
+	for i, c := range chapters {
+		fmt.Fprintf(&buf, "\n%d\n%s --> %s\n", i+1, formatVTTTime(c.Start), formatVTTTime(end[i]))
+		buf.WriteString(vttLine(c.Title))
+		buf.WriteByte('\n')
+		for _, line := range strings.Split(c.Content, "\n") {
+			if line = vttLine(line); line != "" {
+				buf.WriteString(line)
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ParseWebVTT reads the cues of a WebVTT file as chapters
+func ParseWebVTT(r io.Reader) ([]Chapter, error) {
+	scanner := bufio.NewScanner(r)
+	var res []Chapter
+	var cur *Chapter
+	var text []string
+	flush := func() {
+		if cur != nil {
+			if len(text) > 0 {
+				cur.Title = text[0]
+				cur.Content = strings.Join(text[1:], "\n")
+			}
+			res = append(res, *cur)
+		}
+		cur, text = nil, nil
+	}
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if first {
+			first = false
+			if !strings.HasPrefix(strings.TrimPrefix(line, "\ufeff"), "WEBVTT") {
+				return nil, errors.New("not a WebVTT file")
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if cur == nil {
+			if idx := strings.Index(line, "-->"); idx >= 0 {
+				start, err := parseVTTTime(line[:idx])
+				if err != nil {
+					return nil, err
+				}
+				cur = &Chapter{Start: start}
+			}
+			// cue identifiers, NOTE and STYLE blocks are skipped
+			continue
+		}
+		text = append(text, strings.TrimSpace(line))
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// FFmetadata
+
+var ffEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+// WriteFFMetadata writes the chapters in the FFmetadata format understood by ffmpeg,
+// the content is stored as the comment of the chapter
+func WriteFFMetadata(w io.Writer, chapters []Chapter, duration float64) error {
+	chapters = sorted(chapters)
+	end := ends(chapters, duration)
+	var buf bytes.Buffer
+	buf.WriteString(";FFMETADATA1\n")
+	for i, c := range chapters {
+		buf.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&buf, "START=%d\nEND=%d\n", int64(math.Round(c.Start*1000)), int64(math.Round(end[i]*1000)))
+		fmt.Fprintf(&buf, "title=%s\n", ffEscaper.Replace(c.Title))
+		if c.Content != "" {
+			fmt.Fprintf(&buf, "comment=%s\n", ffEscaper.Replace(c.Content))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// splitFFLine splits an unescaped key=value line
+func splitFFLine(line string) (string, string, bool) {
+	var key strings.Builder
+	var value strings.Builder
+	cur := &key
+	found := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+		case line[i] == '=' && !found:
+			found = true
+			cur = &value
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return key.String(), value.String(), found
+}
+
+// ParseFFMetadata reads the [CHAPTER] sections of a FFmetadata file
+func ParseFFMetadata(r io.Reader) ([]Chapter, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(strings.TrimPrefix(text, "\ufeff"), ";FFMETADATA1") {
+		return nil, errors.New("not a FFmetadata file")
+	}
+	// join escaped line breaks
+	var lines []string
+	var pending string
+	for _, line := range strings.Split(text, "\n") {
+		line = pending + line
+		pending = ""
+		if trailing := len(line) - len(strings.TrimRight(line, `\`)); trailing%2 == 1 {
+			pending = line[:len(line)-1] + "\\\n"
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var res []Chapter
+	var cur *Chapter
+	num, den := int64(1), int64(1)
+	var start int64
+	flush := func() {
+		if cur != nil {
+			cur.Start = float64(start) * float64(num) / float64(den)
+			res = append(res, *cur)
+		}
+	}
+	for _, line := range lines[1:] {
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			flush()
+			cur = nil
+			if strings.TrimSpace(line) == "[CHAPTER]" {
+				cur = &Chapter{}
+				num, den, start = 1, 1, 0
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		key, value, ok := splitFFLine(line)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "timebase":
+			n, d, found := strings.Cut(value, "/")
+			num, _ = strconv.ParseInt(n, 10, 64)
+			den, _ = strconv.ParseInt(d, 10, 64)
+			if !found || num <= 0 || den <= 0 {
+				return nil, errors.Errorf("invalid timebase: %s", value)
+			}
+		case "start":
+			if start, err = strconv.ParseInt(value, 10, 64); err != nil || start < 0 {
+				return nil, errors.Errorf("invalid chapter start: %s", value)
+			}
+		case "title":
+			cur.Title = value
+		case "comment":
+			cur.Content = value
+		}
+	}
+	flush()
+	return res, nil
+}
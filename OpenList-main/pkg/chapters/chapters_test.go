@@ -0,0 +1,71 @@
+package chapters
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testChapters = []Chapter{
+	{Start: 0, Title: "Intro"},
+	{Start: 61.5, Title: "Verse; #1 = a\\b", Content: "first line\nsecond line"},
+	{Start: 3725.042, Title: "Outro"},
+}
+
+func TestWebVTT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteWebVTT(&buf, testChapters, 3800); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "00:01:01.500 --> 01:02:05.042\n") {
+		t.Errorf("unexpected cue timing:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "01:02:05.042 --> 01:03:20.000\n") {
+		t.Errorf("last chapter should end at the duration:\n%s", buf.String())
+	}
+	res, err := ParseWebVTT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, testChapters) {
+		t.Errorf("ParseWebVTT() = %+v, want %+v", res, testChapters)
+	}
+}
+
+func TestFFMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFFMetadata(&buf, testChapters, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "START=3725042\nEND=3726042\n") {
+		t.Errorf("last chapter should last one second without duration:\n%s", buf.String())
+	}
+	res, err := ParseFFMetadata(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, testChapters) {
+		t.Errorf("ParseFFMetadata() = %+v, want %+v", res, testChapters)
+	}
+}
+
+func TestParseFFMetadataTimebase(t *testing.T) {
+	src := ";FFMETADATA1\ntitle=album\n\n[CHAPTER]\nTIMEBASE=1/90000\nSTART=900000\nEND=1800000\ntitle=Ten\n\n[STREAM]\ntitle=ignored\n"
+	res, err := ParseFFMetadata(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Chapter{{Start: 10, Title: "Ten"}}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("ParseFFMetadata() = %+v, want %+v", res, want)
+	}
+}
+
+func TestParseVTTTimeInvalid(t *testing.T) {
+	for _, s := range []string{"NaN:00", "00:Inf", "00:00:+Inf", "-1:00", "1", "0:0:0:0"} {
+		if v, err := parseVTTTime(s); err == nil {
+			t.Errorf("parseVTTTime(%q) = %v, want an error", s, v)
+		}
+	}
+}
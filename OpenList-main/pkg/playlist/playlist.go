@@ -0,0 +1,125 @@
+// Package playlist writes media playlists as M3U8, PLS and XSPF files.
+package playlist
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatM3U8 = "m3u8"
+	FormatPLS  = "pls"
+	FormatXSPF = "xspf"
+)
+
+type Entry struct {
+	Title string
+	URL   string
+	// Duration in seconds, 0 if unknown
+	Duration float64
+}
+
+func IsValidFormat(format string) bool {
+	return format == FormatM3U8 || format == FormatPLS || format == FormatXSPF
+}
+
+// ContentType returns the mime type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatPLS:
+		return "audio/x-scpls"
+	case FormatXSPF:
+		return "application/xspf+xml"
+	default:
+		return "audio/x-mpegurl"
+	}
+}
+
+// Write writes the entries as a playlist of the given format
+func Write(w io.Writer, format, title string, entries []Entry) error {
+	switch format {
+	case FormatM3U8:
+		return WriteM3U8(w, title, entries)
+	case FormatPLS:
+		return WritePLS(w, entries)
+	case FormatXSPF:
+		return WriteXSPF(w, title, entries)
+	default:
+		return errors.Errorf("unsupported playlist format: %s", format)
+	}
+}
+
+// line breaks would end the line based formats early
+var lineEscaper = strings.NewReplacer("\r", " ", "\n", " ")
+
+// seconds returns the duration as an integer, -1 if unknown as used by M3U and PLS
+func seconds(d float64) int64 {
+	if d <= 0 {
+		return -1
+	}
+	return int64(d + 0.5)
+}
+
+func WriteM3U8(w io.Writer, title string, entries []Entry) error {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	if title != "" {
+		fmt.Fprintf(&buf, "#PLAYLIST:%s\n", lineEscaper.Replace(title))
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "#EXTINF:%d,%s\n%s\n", seconds(e.Duration), lineEscaper.Replace(e.Title), lineEscaper.Replace(e.URL))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func WritePLS(w io.Writer, entries []Entry) error {
+	var buf bytes.Buffer
+	buf.WriteString("[playlist]\n")
+	for i, e := range entries {
+		fmt.Fprintf(&buf, "File%d=%s\nTitle%d=%s\nLength%d=%d\n",
+			i+1, lineEscaper.Replace(e.URL), i+1, lineEscaper.Replace(e.Title), i+1, seconds(e.Duration))
+	}
+	fmt.Fprintf(&buf, "NumberOfEntries=%d\nVersion=2\n", len(entries))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	// Duration in milliseconds
+	Duration int64 `xml:"duration,omitempty"`
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int         `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+func WriteXSPF(w io.Writer, title string, entries []Entry) error {
+	p := xspfPlaylist{Version: 1, Title: title, Tracks: make([]xspfTrack, len(entries))}
+	for i, e := range entries {
+		p.Tracks[i] = xspfTrack{Location: e.URL, Title: e.Title}
+		if e.Duration > 0 {
+			p.Tracks[i].Duration = int64(e.Duration*1000 + 0.5)
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(p); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
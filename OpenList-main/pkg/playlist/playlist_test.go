@@ -0,0 +1,48 @@
+package playlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testEntries = []Entry{
+	{Title: "One & Two", URL: "http://localhost:5244/d/music/1.mp3?sign=a", Duration: 61.4},
+	{Title: "Three\nFour", URL: "http://localhost:5244/d/music/3.flac?sign=b"},
+}
+
+func TestWrite(t *testing.T) {
+	testCases := map[string][]string{
+		FormatM3U8: {
+			"#EXTM3U\n#PLAYLIST:music\n",
+			"#EXTINF:61,One & Two\nhttp://localhost:5244/d/music/1.mp3?sign=a\n",
+			"#EXTINF:-1,Three Four\n",
+		},
+		FormatPLS: {
+			"[playlist]\nFile1=http://localhost:5244/d/music/1.mp3?sign=a\nTitle1=One & Two\nLength1=61\n",
+			"Length2=-1\nNumberOfEntries=2\nVersion=2\n",
+		},
+		FormatXSPF: {
+			`<playlist xmlns="http://xspf.org/ns/0/" version="1">`,
+			"<location>http://localhost:5244/d/music/1.mp3?sign=a</location>",
+			"<title>One &amp; Two</title>",
+			"<duration>61400</duration>",
+		},
+	}
+	for format, contains := range testCases {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, "music", testEntries); err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range contains {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("%s playlist does not contain %q:\n%s", format, s, buf.String())
+				}
+			}
+		})
+	}
+	if err := Write(&bytes.Buffer{}, "wpl", "", testEntries); err == nil {
+		t.Errorf("Write() with an unknown format should fail")
+	}
+}
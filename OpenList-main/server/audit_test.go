@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	ftpserver "github.com/fclairamb/ftpserverlib"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:server_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	conf.URL = &url.URL{}
+	db.Init(dB)
+}
+
+type testFtpClient struct {
+	ftpserver.ClientContext
+}
+
+func (testFtpClient) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 2121}
+}
+
+type testSshConn struct {
+	ssh.ConnMetadata
+}
+
+func (testSshConn) User() string {
+	return "sftp-intruder"
+}
+
+func (testSshConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 3), Port: 2222}
+}
+
+// TestAuditClientInfo checks the servers put the client ip and the protocol to the recorded events
+func TestAuditClientInfo(t *testing.T) {
+	audit.SetOptions(true, 0)
+	defer audit.SetOptions(false, 0)
+	audit.Init()
+
+	r := gin.New()
+	WebDav(r.Group("/dav"))
+	req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.SetBasicAuth("dav-intruder", "wrong")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	_, _ = (&FtpMainDriver{}).AuthUser(testFtpClient{}, "ftp-intruder", "wrong")
+	(&SftpDriver{}).AuthLogCallback(testSshConn{}, "password", errors.New("wrong password"))
+
+	for username, want := range map[string]model.AuditEvent{
+		"dav-intruder":  {IP: "10.0.0.1", Protocol: model.ProtocolWebDAV},
+		"ftp-intruder":  {IP: "10.0.0.2:2121", Protocol: model.ProtocolFTP},
+		"sftp-intruder": {IP: "10.0.0.3:2222", Protocol: model.ProtocolSFTP},
+	} {
+		q := &model.AuditQuery{PageReq: model.PageReq{Page: 1, PerPage: 10}, Username: username}
+		var events []model.AuditEvent
+		for deadline := time.Now().Add(3 * time.Second); len(events) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			var err error
+			if events, _, err = db.GetAuditEvents(q); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(events) != 1 {
+			t.Errorf("got %d events of %s, want 1", len(events), username)
+			continue
+		}
+		if e := events[0]; e.Event != model.AuditLoginFailed || e.IP != want.IP || e.Protocol != want.Protocol {
+			t.Errorf("unexpected event of %s: %+v", username, e)
+		}
+	}
+}
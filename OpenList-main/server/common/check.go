@@ -17,13 +17,26 @@ func IsStorageSignEnabled(rawPath string) bool {
 	return storage != nil && storage.GetStorage().EnableSign
 }
 
-func CanWrite(meta *model.Meta, path string) bool {
+// CanWrite tells if the user can write path without the write permission,
+// by the access rules of path or by the meta
+func CanWrite(user *model.User, meta *model.Meta, path string) bool {
+	if allowed, decided := op.CheckACL(user, path, model.ACLWrite); decided {
+		return allowed
+	}
 	if meta == nil || !meta.Write {
 		return false
 	}
 	return meta.WSub || meta.Path == path
 }
 
+// CanRemove tells if the user can remove path, the access rules of path take precedence over the permission
+func CanRemove(user *model.User, path string) bool {
+	if allowed, decided := op.CheckACL(user, path, model.ACLDelete); decided {
+		return allowed
+	}
+	return user.CanRemove()
+}
+
 func IsApply(metaPath, reqPath string, applySub bool) bool {
 	if utils.PathEqual(metaPath, reqPath) {
 		return true
@@ -32,6 +45,14 @@ func IsApply(metaPath, reqPath string, applySub bool) bool {
 }
 
 func CanAccess(user *model.User, meta *model.Meta, reqPath string, password string) bool {
+	// the groups of the user can restrict the storages it can access
+	if !user.CanAccessStorageOf(reqPath) {
+		return false
+	}
+	// the access rules can only deny reading, everyone can read without them
+	if allowed, decided := op.CheckACL(user, reqPath, model.ACLRead); decided && !allowed {
+		return false
+	}
 	// if the reqPath is in hide (only can check the nearest meta) and user can't see hides, can't access
 	if meta != nil && !user.CanSeeHides() && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) { // the meta should apply to the parent of current path
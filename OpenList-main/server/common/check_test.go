@@ -1,6 +1,23 @@
 package common
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:common_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
 
 func TestIsApply(t *testing.T) {
 	datas := []struct {
@@ -22,3 +39,21 @@ func TestIsApply(t *testing.T) {
 		}
 	}
 }
+
+func TestCanAccessAllowedStorages(t *testing.T) {
+	user := &model.User{
+		BasePath: "/",
+		Groups:   []model.Group{{BasePath: "/", AllowedStorages: "/team"}},
+	}
+	for path, want := range map[string]bool{
+		"/":               true,
+		"/team/a.mp3":     true,
+		"/private":        false,
+		"/private/a.mp3":  false,
+		"/teammate/a.mp3": false,
+	} {
+		if got := CanAccess(user, nil, path, ""); got != want {
+			t.Errorf("CanAccess(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -9,9 +9,21 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 )
 
-func Sign(obj model.Obj, parent string, encrypt bool) string {
-	if obj.IsDir() || (!encrypt && !setting.GetBool(conf.SignAll)) {
+// Sign signs the object in parent, folders are signed as well since they can be downloaded as zip
+func Sign(obj model.Obj, parent string, encrypt bool, user *model.User) string {
+	return SignPath(stdpath.Join(parent, obj.GetName()), encrypt, user)
+}
+
+// SignPath signs path for the user, so that the downloads of the link are accounted to the user
+// without its token. The paths are always signed for a user with a download cap, the downloads
+// of the links without a sign are accounted to the guest.
+func SignPath(path string, encrypt bool, user *model.User) string {
+	signed := user != nil && !user.IsGuest()
+	if !encrypt && !setting.GetBool(conf.SignAll) && (!signed || user.DownloadCap <= 0) {
 		return ""
 	}
-	return sign.Sign(stdpath.Join(parent, obj.GetName()))
+	if !signed {
+		return sign.Sign(path)
+	}
+	return sign.SignUser(path, user.ID)
 }
@@ -26,7 +26,7 @@ func Mkdir(ctx context.Context, path string) error {
 				return err
 			}
 		}
-		if !common.CanWrite(meta, reqPath) {
+		if !common.CanWrite(user, meta, reqPath) {
 			return errs.PermissionDenied
 		}
 	}
@@ -35,13 +35,16 @@ func Mkdir(ctx context.Context, path string) error {
 
 func Remove(ctx context.Context, path string) error {
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() || !user.CanFTPManage() {
+	if !user.CanFTPManage() {
 		return errs.PermissionDenied
 	}
 	reqPath, err := user.JoinPath(path)
 	if err != nil {
 		return err
 	}
+	if !common.CanRemove(user, reqPath) {
+		return errs.PermissionDenied
+	}
 	if err = RemoveStage(reqPath); !errors.Is(err, errs.ObjectNotFound) {
 		return err
 	}
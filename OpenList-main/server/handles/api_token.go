@@ -0,0 +1,86 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAPITokenReq struct {
+	Name       string     `json:"name" binding:"required"`
+	PathPrefix string     `json:"path_prefix"`
+	Permission int32      `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type CreateAPITokenResp struct {
+	model.APIToken
+	// Token and the s3 secret access key are only returned once, on creation
+	Token             string `json:"token"`
+	S3AccessKeyId     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+}
+
+func ListMyAPITokens(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUserId(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var req CreateAPITokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t := model.APIToken{
+		Name:       req.Name,
+		PathPrefix: req.PathPrefix,
+		Permission: req.Permission,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	token, err := op.CreateAPIToken(user, &t)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, CreateAPITokenResp{
+		APIToken:          t,
+		Token:             token,
+		S3AccessKeyId:     t.S3AccessKeyId(),
+		S3SecretAccessKey: op.APITokenS3Secret(&t),
+	})
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err := op.DeleteAPITokenByIdAndUserId(uint(id), user.ID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	common.SuccessResp(c)
+}
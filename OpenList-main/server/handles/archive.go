@@ -313,6 +313,104 @@ func FsArchiveDecompress(c *gin.Context) {
 	})
 }
 
+type ArchiveCompressReq struct {
+	SrcDir      string        `json:"src_dir" form:"src_dir"`
+	DstDir      string        `json:"dst_dir" form:"dst_dir"`
+	Name        StringOrArray `json:"name" form:"name" binding:"required"`
+	ArchiveName string        `json:"archive_name" form:"archive_name" binding:"required"`
+	Format      string        `json:"format" form:"format"`
+	ArchivePass string        `json:"archive_pass" form:"archive_pass"`
+	// Password is the one of the meta of the sources, not of the archive
+	Password string `json:"password" form:"password"`
+}
+
+func FsArchiveCompress(c *gin.Context) {
+	var req ArchiveCompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = model.ArchiveFormatZip
+	}
+	if req.Format != model.ArchiveFormatZip && req.Format != model.ArchiveFormatTarGz && req.Format != model.ArchiveFormat7zStore {
+		common.ErrorStrResp(c, fmt.Sprintf("unsupported archive format: %s", req.Format), 400)
+		return
+	}
+	if req.ArchivePass != "" && req.Format != model.ArchiveFormatZip {
+		common.ErrorStrResp(c, "only zip archives can be encrypted", 400)
+		return
+	}
+	if strings.Contains(req.ArchiveName, "/") {
+		common.ErrorStrResp(c, "archive name can not contain '/'", 400)
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(req.ArchiveName), "."+req.Format) {
+		req.ArchiveName += "." + req.Format
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !user.CanWrite() {
+		meta, err := op.GetNearestMeta(dstDir)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(user, meta, dstDir) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	for _, name := range req.Name {
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid name: %s", name), 400)
+			return
+		}
+		srcPath, err := user.JoinPath(stdpath.Join(req.SrcDir, name))
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		meta, err := op.GetNearestMeta(stdpath.Dir(srcPath))
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CanAccess(user, meta, srcPath, req.Password) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	t, err := fs.ArchiveCompress(c.Request.Context(), srcDir, dstDir, model.ArchiveCompressArgs{
+		Names:       req.Name,
+		ArchiveName: req.ArchiveName,
+		Format:      req.Format,
+		Password:    req.ArchivePass,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	var tasks []task.TaskExtensionInfo
+	if t != nil {
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfos(tasks),
+	})
+}
+
 func ArchiveDown(c *gin.Context) {
 	archiveRawPath := c.Request.Context().Value(conf.PathKey).(string)
 	innerPath := utils.FixAndCleanPath(c.Query("inner"))
@@ -0,0 +1,104 @@
+package handles
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// FsGet does not wait longer than this for the tags of a file that is not cached yet
+const fsGetAudioMetaTimeout = 5 * time.Second
+
+type AudioMetaReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Cover returns the embedded cover art instead of the tags
+	Cover bool `json:"cover" form:"cover"`
+}
+
+func getAudioMeta(ctx context.Context, reqPath string) (*model.AudioMeta, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	return op.GetAudioMeta(ctx, storage, actualPath)
+}
+
+func getAudioCover(ctx context.Context, reqPath string) (*model.AudioMeta, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	return op.GetAudioCover(ctx, storage, actualPath)
+}
+
+// getAudioMetaOrNil is used to enrich responses, a file whose tags cannot be read is still returned
+func getAudioMetaOrNil(ctx context.Context, reqPath string) *model.AudioMeta {
+	if utils.GetFileType(reqPath) != conf.AUDIO {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, fsGetAudioMetaTimeout)
+	defer cancel()
+	meta, err := getAudioMeta(ctx, reqPath)
+	if err != nil {
+		log.Debugf("failed get audio meta of %s: %+v", reqPath, err)
+		return nil
+	}
+	return meta
+}
+
+func FsAudioMeta(c *gin.Context) {
+	var req AudioMetaReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := getMetaOrNil(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.GinWithValue(c, conf.MetaKey, meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if req.Cover {
+		audioMeta, err := getAudioCover(c.Request.Context(), reqPath)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		if !audioMeta.HasCover {
+			common.ErrorStrResp(c, "no cover art", 404)
+			return
+		}
+		contentType := audioMeta.CoverType
+		if contentType == "" {
+			contentType = http.DetectContentType(audioMeta.Cover)
+		}
+		c.Header("Cache-Control", "private, max-age=86400")
+		c.Data(http.StatusOK, contentType, audioMeta.Cover)
+		return
+	}
+	audioMeta, err := getAudioMeta(c.Request.Context(), reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, audioMeta)
+}
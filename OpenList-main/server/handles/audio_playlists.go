@@ -0,0 +1,375 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type AudioPlaylistItemReq struct {
+	StorageId    uint   `json:"storage_id"` // Optional, will be auto-detected from path
+	OriginalPath string `json:"original_path" binding:"required"`
+	FileName     string `json:"file_name" binding:"required"`
+	Fingerprint  string `json:"fingerprint"`
+}
+
+type CreateAudioPlaylistReq struct {
+	Name         string                 `json:"name" binding:"required"`
+	PlayMode     string                 `json:"play_mode"`
+	CurrentIndex *int                   `json:"current_index"`
+	Items        []AudioPlaylistItemReq `json:"items" binding:"dive"`
+}
+
+type UpdateAudioPlaylistReq struct {
+	ID       uint   `json:"id" binding:"required"`
+	Name     string `json:"name"`
+	PlayMode string `json:"play_mode"`
+}
+
+type AudioPlaylistItemsReq struct {
+	ID    uint                   `json:"id" binding:"required"`
+	Items []AudioPlaylistItemReq `json:"items" binding:"required,dive"`
+}
+
+type AudioPlaylistItemIdsReq struct {
+	ID      uint   `json:"id" binding:"required"`
+	ItemIds []uint `json:"item_ids" binding:"required"`
+}
+
+type SetAudioPlaylistCurrentReq struct {
+	ID           uint   `json:"id" binding:"required"`
+	CurrentIndex int    `json:"current_index"`
+	PlayMode     string `json:"play_mode"`
+}
+
+type AudioPlaylistResp struct {
+	model.AudioPlaylist
+	Items []model.AudioPlaylistItem `json:"items"`
+}
+
+// toAudioPlaylistItems converts the request items, auto-detecting storage_id from path if not provided
+func toAudioPlaylistItems(user *model.User, reqs []AudioPlaylistItemReq) []model.AudioPlaylistItem {
+	items := make([]model.AudioPlaylistItem, len(reqs))
+	for i, req := range reqs {
+		storageId := req.StorageId
+		if storageId == 0 {
+			reqPath, err := user.JoinPath(req.OriginalPath)
+			if err == nil {
+				storage, _, err := op.GetStorageAndActualPath(reqPath)
+				if err == nil {
+					storageId = storage.GetStorage().ID
+				}
+			}
+		}
+		items[i] = model.AudioPlaylistItem{
+			StorageId:    storageId,
+			OriginalPath: req.OriginalPath,
+			FileName:     req.FileName,
+			Fingerprint:  req.Fingerprint,
+		}
+	}
+	return items
+}
+
+func getAudioPlaylistResp(playlist *model.AudioPlaylist) (*AudioPlaylistResp, error) {
+	items, err := db.ListAudioPlaylistItems(playlist.ID, playlist.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &AudioPlaylistResp{AudioPlaylist: *playlist, Items: items}, nil
+}
+
+func ListAudioPlaylists(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access playlists", 403)
+		return
+	}
+
+	playlists, err := db.ListAudioPlaylistsByUser(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, playlists)
+}
+
+func GetAudioPlaylist(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access playlists", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(uint(id), user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	resp, err := getAudioPlaylistResp(playlist)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, resp)
+}
+
+func CreateAudioPlaylist(c *gin.Context) {
+	var req CreateAudioPlaylistReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied: guest or disabled users cannot create playlists", 403)
+		return
+	}
+
+	if req.PlayMode == "" {
+		req.PlayMode = model.AudioPlayModeList
+	}
+	if !model.IsValidAudioPlayMode(req.PlayMode) {
+		common.ErrorStrResp(c, "invalid play mode", 400)
+		return
+	}
+
+	currentIndex := -1
+	if req.CurrentIndex != nil && *req.CurrentIndex >= 0 && *req.CurrentIndex < len(req.Items) {
+		currentIndex = *req.CurrentIndex
+	}
+
+	playlist := &model.AudioPlaylist{
+		UserId:       user.ID,
+		Name:         req.Name,
+		PlayMode:     req.PlayMode,
+		CurrentIndex: currentIndex,
+	}
+
+	items := toAudioPlaylistItems(user, req.Items)
+	if err := db.CreateAudioPlaylist(playlist, items); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, AudioPlaylistResp{AudioPlaylist: *playlist, Items: items})
+}
+
+func UpdateAudioPlaylist(c *gin.Context) {
+	var req UpdateAudioPlaylistReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	if req.Name != "" {
+		playlist.Name = req.Name
+	}
+	if req.PlayMode != "" {
+		if !model.IsValidAudioPlayMode(req.PlayMode) {
+			common.ErrorStrResp(c, "invalid play mode", 400)
+			return
+		}
+		playlist.PlayMode = req.PlayMode
+	}
+
+	if err := db.UpdateAudioPlaylist(playlist); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, playlist)
+}
+
+func DeleteAudioPlaylist(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	if err := db.DeleteAudioPlaylistById(uint(id), user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, "playlist deleted successfully")
+}
+
+func SetAudioPlaylistCurrent(c *gin.Context) {
+	var req SetAudioPlaylistCurrentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	items, err := db.ListAudioPlaylistItems(playlist.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if req.CurrentIndex < -1 || req.CurrentIndex >= len(items) {
+		common.ErrorStrResp(c, "current index out of range", 400)
+		return
+	}
+	playlist.CurrentIndex = req.CurrentIndex
+	if req.PlayMode != "" {
+		if !model.IsValidAudioPlayMode(req.PlayMode) {
+			common.ErrorStrResp(c, "invalid play mode", 400)
+			return
+		}
+		playlist.PlayMode = req.PlayMode
+	}
+
+	if err := db.UpdateAudioPlaylist(playlist); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, playlist)
+}
+
+// AudioPlaylistItem handlers
+
+func AppendAudioPlaylistItems(c *gin.Context) {
+	var req AudioPlaylistItemsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	if err := db.AppendAudioPlaylistItems(playlist, toAudioPlaylistItems(user, req.Items)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	resp, err := getAudioPlaylistResp(playlist)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, resp)
+}
+
+func RemoveAudioPlaylistItems(c *gin.Context) {
+	var req AudioPlaylistItemIdsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	if err := db.RemoveAudioPlaylistItems(playlist, req.ItemIds); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	resp, err := getAudioPlaylistResp(playlist)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, resp)
+}
+
+func ReorderAudioPlaylistItems(c *gin.Context) {
+	var req AudioPlaylistItemIdsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	playlist, err := db.GetAudioPlaylistById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+
+	if err := db.ReorderAudioPlaylistItems(playlist, req.ItemIds); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	resp, err := getAudioPlaylistResp(playlist)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, resp)
+}
@@ -0,0 +1,48 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListAuditEvents(c *gin.Context) {
+	var req model.AuditQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	events, total, err := db.GetAuditEvents(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: events,
+		Total:   total,
+	})
+}
+
+// PruneAuditEvents deletes the events older than the days of the query,
+// the retention days in settings by default
+func PruneAuditEvents(c *gin.Context) {
+	days := audit.RetentionDays()
+	if d := c.Query("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days <= 0 {
+			common.ErrorStrResp(c, "days must be a positive integer", 400)
+			return
+		}
+	}
+	deleted, err := audit.Prune(days)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"deleted": deleted})
+}
@@ -3,14 +3,17 @@ package handles
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"image/png"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
 )
 
 type LoginReq struct {
@@ -54,12 +57,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		model.LoginCache.Set(ip, count+1)
+		audit.RecordLogin(c.Request.Context(), nil, req.Username, err)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
 		model.LoginCache.Set(ip, count+1)
+		audit.RecordLogin(c.Request.Context(), user, "", err)
 		return
 	}
 	// check 2FA
@@ -67,6 +72,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
 			model.LoginCache.Set(ip, count+1)
+			audit.RecordLogin(c.Request.Context(), user, "", errors.New("invalid 2FA code"))
 			return
 		}
 	}
@@ -77,12 +83,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	audit.RecordLogin(c.Request.Context(), user, "", nil)
 	model.LoginCache.Del(ip)
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp   bool             `json:"otp"`
+	Usage *model.UserUsage `json:"usage,omitempty"`
 }
 
 // CurrentUser get current user by token
@@ -93,9 +101,19 @@ func CurrentUser(c *gin.Context) {
 		User: *user,
 	}
 	userResp.Password = ""
+	// the frontend shows what the user can do with its groups
+	userResp.Permission = user.EffectivePermission()
+	userResp.BasePath = user.GetBasePath()
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	if !user.IsGuest() {
+		usage, err := op.GetUserUsage(user)
+		if err != nil {
+			log.Warnf("failed get usage of user %s: %+v", user.Username, err)
+		}
+		userResp.Usage = usage
+	}
 	common.SuccessResp(c, userResp)
 }
 
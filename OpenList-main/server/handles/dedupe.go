@@ -0,0 +1,92 @@
+package handles
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateDedupe(c *gin.Context) {
+	var req model.DedupeArgs
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		common.ErrorStrResp(c, "paths are required", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	for i := range req.Paths {
+		p, err := user.JoinPath(req.Paths[i])
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		req.Paths[i] = p
+	}
+	t, err := fs.Dedupe(c.Request.Context(), req)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	var tasks []task.TaskExtensionInfo
+	if t != nil {
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfos(tasks),
+	})
+}
+
+type ResolveDuplicatesReq struct {
+	TaskID string   `json:"tid" binding:"required"`
+	Keep   string   `json:"keep" binding:"required"`
+	Paths  []string `json:"paths" binding:"required"`
+	Action string   `json:"action"`
+}
+
+// ResolveDuplicates keeps one file of a group of duplicates found by a dedupe task,
+// the others are removed or overwritten with it
+func ResolveDuplicates(c *gin.Context) {
+	var req ResolveDuplicatesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Action == "" {
+		req.Action = model.DedupeDelete
+	}
+	if req.Action != model.DedupeDelete && req.Action != model.DedupeReplace {
+		common.ErrorStrResp(c, fmt.Sprintf("unknown action: %s", req.Action), 400)
+		return
+	}
+	t, ok := fs.DedupeTaskManager.GetByID(req.TaskID)
+	if !ok {
+		common.ErrorStrResp(c, "task not found", 404)
+		return
+	}
+	// only the files confirmed to be the same as the kept one can be resolved
+	if _, err := fs.ResolvableGroup(t.Report, req.Keep, req.Paths); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for _, p := range req.Paths {
+		var err error
+		if req.Action == model.DedupeReplace {
+			err = fs.ReplaceWith(c.Request.Context(), req.Keep, p)
+		} else {
+			err = fs.Remove(c.Request.Context(), p)
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
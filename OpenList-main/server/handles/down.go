@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/http"
 	stdpath "path"
 	"strconv"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
@@ -13,7 +15,9 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
@@ -34,7 +38,11 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	} else {
-		link, _, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{
+		user := downUser(c)
+		if !checkDownCap(c, user) {
+			return
+		}
+		link, file, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -45,6 +53,7 @@ func Down(c *gin.Context) {
 			return
 		}
 		redirect(c, link)
+		accountRedirectDown(c, user, file)
 	}
 }
 
@@ -57,9 +66,19 @@ func Proxy(c *gin.Context) {
 		return
 	}
 	if canProxy(storage, filename) {
+		user := downUser(c)
+		if !checkDownCap(c, user) {
+			return
+		}
 		if _, ok := c.GetQuery("d"); !ok {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), rawPath); url != "" {
+				file, err := fs.Get(c.Request.Context(), rawPath, &fs.GetArgs{NoLog: true})
+				if err != nil {
+					common.ErrorPage(c, err, 500)
+					return
+				}
 				c.Redirect(302, url)
+				accountRedirectDown(c, user, file)
 				return
 			}
 		}
@@ -72,12 +91,63 @@ func Proxy(c *gin.Context) {
 			return
 		}
 		proxy(c, link, file, storage.GetStorage().ProxyRange)
+		accountProxyDown(c, user)
 	} else {
 		common.ErrorPage(c, errors.New("proxy not allowed"), 403)
 		return
 	}
 }
 
+// downUser returns the user a download is accounted to, the one of the token
+// of the request or the one the link is signed for if any, the guest otherwise
+func downUser(c *gin.Context) *model.User {
+	if user, ok := c.Request.Context().Value(conf.UserKey).(*model.User); ok {
+		return user
+	}
+	if token := c.GetHeader("Authorization"); token != "" {
+		if claims, err := common.ParseToken(token); err == nil {
+			if user, err := op.GetUserByName(claims.Username); err == nil && user.PwdTS == claims.PwdTS {
+				return user
+			}
+		}
+	}
+	rawPath := c.Request.Context().Value(conf.PathKey).(string)
+	if userId, err := sign.VerifyUser(rawPath, strings.TrimSuffix(c.Query("sign"), "/")); err == nil && userId != 0 {
+		if user, err := op.GetUserById(userId); err == nil {
+			return user
+		}
+	}
+	guest, err := op.GetGuest()
+	if err != nil {
+		return nil
+	}
+	return guest
+}
+
+func checkDownCap(c *gin.Context, user *model.User) bool {
+	if err := op.CheckDownloadCap(user); err != nil {
+		common.ErrorPage(c, err, 403)
+		return false
+	}
+	return true
+}
+
+// accountRedirectDown accounts the size of the file as the download happens elsewhere
+func accountRedirectDown(c *gin.Context, user *model.User, file model.Obj) {
+	if c.Request.Method == http.MethodHead || c.Writer.Status() != http.StatusFound || file == nil {
+		return
+	}
+	op.AddUserDownloaded(user, file.GetSize())
+}
+
+// accountProxyDown accounts the bytes written to the client
+func accountProxyDown(c *gin.Context, user *model.User) {
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	op.AddUserDownloaded(user, int64(c.Writer.Size()))
+}
+
 func redirect(c *gin.Context, link *model.Link) {
 	defer link.Close()
 	var err error
@@ -0,0 +1,42 @@
+package handles
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDownUserOfSignedLink(t *testing.T) {
+	user := &model.User{Username: "signed-down", BasePath: "/", Role: model.GENERAL, DownloadCap: 100}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	s := common.SignPath("/files/a.mp4", false, user)
+	if s == "" {
+		t.Fatal("the paths are not signed for a user with a download cap")
+	}
+	for _, tt := range []struct {
+		path, sign string
+		charged    bool
+	}{
+		{path: "/files/a.mp4", sign: s, charged: true},
+		{path: "/files/b.mp4", sign: s},
+		// the user of the sign can't be changed
+		{path: "/files/a.mp4", sign: "1" + s},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/d"+tt.path+"?sign="+url.QueryEscape(tt.sign), nil)
+		common.GinWithValue(c, conf.PathKey, tt.path)
+		u := downUser(c)
+		if charged := u != nil && u.ID == user.ID; charged != tt.charged {
+			t.Errorf("downUser(%s, %s) charged the user: %v, want %v", tt.path, tt.sign, charged, tt.charged)
+		}
+	}
+}
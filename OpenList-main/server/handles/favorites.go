@@ -0,0 +1,663 @@
+package handles
+
+import (
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const favoriteMediaTypeKey = "favorite_media_type"
+
+// FavoriteMediaType pins the media type of the legacy
+// /video_favorites, /audio_favorites and /image_favorites routes
+func FavoriteMediaType(mediaType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(favoriteMediaTypeKey, mediaType)
+		c.Next()
+	}
+}
+
+// favoriteMediaType returns the media type pinned by a legacy route,
+// or the requested one for /favorites. "" means all media types.
+func favoriteMediaType(c *gin.Context, requested string) (string, bool) {
+	if fixed := c.GetString(favoriteMediaTypeKey); fixed != "" {
+		return fixed, true
+	}
+	if requested == "" {
+		return "", true
+	}
+	return requested, model.IsValidFavoriteMediaType(requested)
+}
+
+// favoriteVisible reports whether an entry of the media type can be reached through the current route
+func favoriteVisible(c *gin.Context, mediaType string) bool {
+	fixed := c.GetString(favoriteMediaTypeKey)
+	return fixed == "" || fixed == mediaType
+}
+
+// FavoriteFolder handlers
+
+type CreateFavoriteFolderReq struct {
+	Name        string `json:"name" binding:"required"`
+	MediaType   string `json:"media_type"`
+	Description string `json:"description"`
+	Order       int    `json:"order"`
+}
+
+type UpdateFavoriteFolderReq struct {
+	ID          uint   `json:"id" binding:"required"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Order       int    `json:"order"`
+}
+
+func ListFavoriteFolders(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access favorites", 403)
+		return
+	}
+
+	mediaType, ok := favoriteMediaType(c, c.Query("media_type"))
+	if !ok {
+		common.ErrorStrResp(c, "invalid media type", 400)
+		return
+	}
+
+	folders, err := db.ListFavoriteFoldersByUser(user.ID, mediaType)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, folders)
+}
+
+func GetFavoriteFolder(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access favorites", 403)
+		return
+	}
+
+	folder, err := db.GetFavoriteFolderById(uint(id), user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if !favoriteVisible(c, folder.MediaType) {
+		common.ErrorStrResp(c, "favorite folder not found", 404)
+		return
+	}
+
+	common.SuccessResp(c, folder)
+}
+
+func CreateFavoriteFolder(c *gin.Context) {
+	var req CreateFavoriteFolderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied: guest or disabled users cannot create folders", 403)
+		return
+	}
+
+	mediaType, ok := favoriteMediaType(c, req.MediaType)
+	if !ok {
+		common.ErrorStrResp(c, "invalid media type", 400)
+		return
+	}
+	if mediaType == "" {
+		mediaType = model.FavoriteMediaAny
+	}
+
+	folder := &model.FavoriteFolder{
+		UserId:      user.ID,
+		MediaType:   mediaType,
+		Name:        req.Name,
+		Description: req.Description,
+		Order:       req.Order,
+	}
+
+	if err := db.CreateFavoriteFolder(folder); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, folder)
+}
+
+func UpdateFavoriteFolder(c *gin.Context) {
+	var req UpdateFavoriteFolderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	folder, err := db.GetFavoriteFolderById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if !favoriteVisible(c, folder.MediaType) {
+		common.ErrorStrResp(c, "favorite folder not found", 404)
+		return
+	}
+
+	if req.Name != "" {
+		folder.Name = req.Name
+	}
+	folder.Description = req.Description
+	folder.Order = req.Order
+
+	if err := db.UpdateFavoriteFolder(folder); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, folder)
+}
+
+func DeleteFavoriteFolder(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	folder, err := db.GetFavoriteFolderById(uint(id), user.ID)
+	if err != nil || !favoriteVisible(c, folder.MediaType) {
+		common.ErrorStrResp(c, "favorite folder not found or not owned by user", 404)
+		return
+	}
+
+	if err := db.DeleteFavoriteFolderById(folder.ID, user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, "folder deleted successfully")
+}
+
+// Favorite handlers
+
+type CreateFavoriteReq struct {
+	FolderId     uint   `json:"folder_id" binding:"required"`
+	StorageId    uint   `json:"storage_id"` // Optional, will be auto-detected from path
+	OriginalPath string `json:"original_path" binding:"required"`
+	FileName     string `json:"file_name" binding:"required"`
+	Note         string `json:"note"`
+	Fingerprint  string `json:"fingerprint"`
+}
+
+type UpdateFavoriteReq struct {
+	ID   uint   `json:"id" binding:"required"`
+	Note string `json:"note"`
+}
+
+func ListFavorites(c *gin.Context) {
+	idStr := c.Query("id")
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access favorites", 403)
+		return
+	}
+
+	var favorites []model.Favorite
+	var err error
+
+	if idStr == "" || idStr == "0" {
+		// List all favorites for user
+		mediaType, ok := favoriteMediaType(c, c.Query("media_type"))
+		if !ok {
+			common.ErrorStrResp(c, "invalid media type", 400)
+			return
+		}
+		favorites, err = db.ListAllFavoritesByUser(user.ID, mediaType)
+	} else {
+		// List favorites in specific folder
+		id, parseErr := strconv.Atoi(idStr)
+		if parseErr != nil {
+			common.ErrorResp(c, parseErr, 400)
+			return
+		}
+		folder, getErr := db.GetFavoriteFolderById(uint(id), user.ID)
+		if getErr != nil || !favoriteVisible(c, folder.MediaType) {
+			common.ErrorStrResp(c, "folder not found or not owned by user", 404)
+			return
+		}
+		favorites, err = db.ListFavoritesByFolder(folder.ID, user.ID)
+	}
+
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, favorites)
+}
+
+func CreateFavorite(c *gin.Context) {
+	var req CreateFavoriteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	// Verify folder exists and belongs to user
+	folder, err := db.GetFavoriteFolderById(req.FolderId, user.ID)
+	if err != nil || !favoriteVisible(c, folder.MediaType) {
+		common.ErrorStrResp(c, "folder not found or not owned by user", 404)
+		return
+	}
+
+	// Check if file already exists in this folder
+	exists, err := db.CheckFavoriteExists(user.ID, req.FolderId, req.OriginalPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if exists {
+		common.ErrorStrResp(c, "file already exists in this folder", 400)
+		return
+	}
+
+	// Auto-detect storage_id from path if not provided
+	storageId := req.StorageId
+	if storageId == 0 {
+		// Try to get storage from path
+		reqPath, err := user.JoinPath(req.OriginalPath)
+		if err == nil {
+			storage, _, err := op.GetStorageAndActualPath(reqPath)
+			if err == nil {
+				storageId = storage.GetStorage().ID
+			}
+		}
+	}
+
+	favorite := &model.Favorite{
+		UserId:       user.ID,
+		FolderId:     req.FolderId,
+		MediaType:    folder.MediaType,
+		StorageId:    storageId,
+		OriginalPath: req.OriginalPath,
+		FileName:     req.FileName,
+		Note:         req.Note,
+		Fingerprint:  req.Fingerprint,
+	}
+
+	if err := db.CreateFavorite(favorite); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, favorite)
+}
+
+func UpdateFavorite(c *gin.Context) {
+	var req UpdateFavoriteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	favorite, err := db.GetFavoriteById(req.ID, user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if !favoriteVisible(c, favorite.MediaType) {
+		common.ErrorStrResp(c, "favorite not found", 404)
+		return
+	}
+
+	favorite.Note = req.Note
+
+	if err := db.UpdateFavorite(favorite); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, favorite)
+}
+
+func DeleteFavorite(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	favorite, err := db.GetFavoriteById(uint(id), user.ID)
+	if err != nil || !favoriteVisible(c, favorite.MediaType) {
+		common.ErrorStrResp(c, "favorite not found or not owned by user", 404)
+		return
+	}
+
+	if err := db.DeleteFavoriteById(favorite.ID, user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	common.SuccessResp(c, "file removed from favorites")
+}
+
+// Get all media marks grouped by file for all favorites
+type FavoriteWithMarksResp struct {
+	FolderId     uint                 `json:"folder_id"`
+	FolderName   string               `json:"folder_name"`
+	FavoriteId   uint                 `json:"favorite_id"`
+	FileName     string               `json:"file_name"`
+	OriginalPath string               `json:"original_path"`
+	StorageId    uint                 `json:"storage_id"`
+	Marks        []model.MediaMarkDTO `json:"marks"`
+}
+
+// VideoWithMarksResp and AudioWithMarksResp keep the responses of the legacy all_marks routes
+type VideoWithMarksResp struct {
+	FavoriteWithMarksResp
+	VideoId uint `json:"video_id"`
+}
+
+type AudioWithMarksResp struct {
+	FavoriteWithMarksResp
+	AudioId uint `json:"audio_id"`
+}
+
+// favoriteFileTypes maps the favorite media types to the file types of marked files
+var favoriteFileTypes = map[string]int{
+	model.FavoriteMediaVideo:    conf.VIDEO,
+	model.FavoriteMediaAudio:    conf.AUDIO,
+	model.FavoriteMediaImage:    conf.IMAGE,
+	model.FavoriteMediaDocument: conf.TEXT,
+}
+
+func listFavoriteMarks(user *model.User, mediaType string) ([]FavoriteWithMarksResp, error) {
+	// Get all user's media marks
+	allMarks, err := db.ListAllMediaMarksByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all user's favorites for additional info
+	favorites, _ := db.ListAllFavoritesByUser(user.ID, mediaType)
+	favoriteMap := make(map[string]*model.Favorite)
+	for i := range favorites {
+		if favorites[i].Fingerprint != "" {
+			favoriteMap[favorites[i].Fingerprint] = &favorites[i]
+		}
+	}
+
+	// Get all folders for folder name mapping
+	folders, _ := db.ListFavoriteFoldersByUser(user.ID, mediaType)
+	folderMap := make(map[uint]string)
+	for _, folder := range folders {
+		folderMap[folder.ID] = folder.Name
+	}
+
+	// Group marks by fingerprint (file)
+	marksGrouped := make(map[string][]model.MediaMark)
+	for _, mark := range allMarks {
+		if mark.Fingerprint == "" {
+			continue
+		}
+		marksGrouped[mark.Fingerprint] = append(marksGrouped[mark.Fingerprint], mark)
+	}
+
+	fileType, filterType := favoriteFileTypes[mediaType]
+
+	// Build result
+	result := []FavoriteWithMarksResp{}
+	for fingerprint, marks := range marksGrouped {
+		if len(marks) == 0 {
+			continue
+		}
+
+		// Filter: only include files of the requested media type
+		firstMark := marks[0]
+		if filterType && utils.GetFileType(firstMark.OriginalPath) != fileType {
+			continue
+		}
+
+		// Convert marks to DTOs
+		markDTOs := make([]model.MediaMarkDTO, len(marks))
+		for i, mark := range marks {
+			markDTOs[i] = mark.ToDTO()
+		}
+
+		// Try to get info from favorites first
+		if fav, exists := favoriteMap[fingerprint]; exists {
+			result = append(result, FavoriteWithMarksResp{
+				FolderId:     fav.FolderId,
+				FolderName:   folderMap[fav.FolderId],
+				FavoriteId:   fav.ID,
+				FileName:     fav.FileName,
+				OriginalPath: fav.OriginalPath,
+				StorageId:    fav.StorageId,
+				Marks:        markDTOs,
+			})
+			continue
+		}
+
+		// Use info from the first mark
+		// Fix old paths that don't include mount path
+		originalPath := firstMark.OriginalPath
+		if firstMark.StorageId > 0 {
+			storage, err := db.GetStorageById(firstMark.StorageId)
+			if err == nil && storage.MountPath != "" {
+				// Check if path already includes mount path
+				if storage.MountPath != "/" && !strings.HasPrefix(originalPath, storage.MountPath) {
+					// Path doesn't include mount path, add it
+					originalPath = storage.MountPath + "/" + originalPath
+				} else if storage.MountPath == "/" && !strings.HasPrefix(originalPath, "/") {
+					originalPath = "/" + originalPath
+				}
+			}
+		}
+
+		// Extract filename from path
+		fileName := originalPath
+		if lastSlash := strings.LastIndex(fileName, "/"); lastSlash >= 0 {
+			fileName = fileName[lastSlash+1:]
+		}
+		result = append(result, FavoriteWithMarksResp{
+			FolderId:     0,
+			FolderName:   "未收藏",
+			FavoriteId:   0,
+			FileName:     fileName,
+			OriginalPath: originalPath,
+			StorageId:    firstMark.StorageId,
+			Marks:        markDTOs,
+		})
+	}
+
+	return result, nil
+}
+
+func ListAllFavoriteMarks(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access favorites", 403)
+		return
+	}
+
+	mediaType, ok := favoriteMediaType(c, c.Query("media_type"))
+	if !ok {
+		common.ErrorStrResp(c, "invalid media type", 400)
+		return
+	}
+
+	result, err := listFavoriteMarks(user, mediaType)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	switch c.GetString(favoriteMediaTypeKey) {
+	case model.FavoriteMediaVideo:
+		common.SuccessResp(c, utils.MustSliceConvert(result, func(r FavoriteWithMarksResp) VideoWithMarksResp {
+			return VideoWithMarksResp{FavoriteWithMarksResp: r, VideoId: r.FavoriteId}
+		}))
+	case model.FavoriteMediaAudio:
+		common.SuccessResp(c, utils.MustSliceConvert(result, func(r FavoriteWithMarksResp) AudioWithMarksResp {
+			return AudioWithMarksResp{FavoriteWithMarksResp: r, AudioId: r.FavoriteId}
+		}))
+	default:
+		common.SuccessResp(c, result)
+	}
+}
+
+type RepairFavoritesResp struct {
+	Checked  int              `json:"checked"`
+	Repaired []model.Favorite `json:"repaired"`
+	Orphaned []model.Favorite `json:"orphaned"`
+}
+
+// RepairFavorites re-resolves the favorites whose files no longer exist,
+// looking for files with the same name and fingerprint in the search index
+func RepairFavorites(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+
+	mediaType, ok := favoriteMediaType(c, c.Query("media_type"))
+	if !ok {
+		common.ErrorStrResp(c, "invalid media type", 400)
+		return
+	}
+
+	favorites, err := db.ListAllFavoritesByUser(user.ID, mediaType)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+
+	resp := RepairFavoritesResp{
+		Checked:  len(favorites),
+		Repaired: []model.Favorite{},
+		Orphaned: []model.Favorite{},
+	}
+	for i := range favorites {
+		favorite := &favorites[i]
+		reqPath, err := user.JoinPath(favorite.OriginalPath)
+		if err == nil {
+			_, err = fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{NoLog: true})
+			if err == nil || !errs.IsObjectNotFound(err) {
+				// the file is still there, or its storage is unavailable for now
+				continue
+			}
+		}
+		if favorite.Fingerprint == "" {
+			resp.Orphaned = append(resp.Orphaned, *favorite)
+			continue
+		}
+		fullPath, storageId, found := findFavoriteByFingerprint(c, user, favorite)
+		if !found {
+			resp.Orphaned = append(resp.Orphaned, *favorite)
+			continue
+		}
+		newPath := utils.FixAndCleanPath(strings.TrimPrefix(fullPath, user.GetBasePath()))
+		if err := db.UpdateFavoriteLocation(favorite, newPath, storageId); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		resp.Repaired = append(resp.Repaired, *favorite)
+	}
+
+	common.SuccessResp(c, resp)
+}
+
+// findFavoriteByFingerprint searches the index for files named like the favorite
+// whose server-side or frontend fingerprint equals the favorite's one
+func findFavoriteByFingerprint(c *gin.Context, user *model.User, favorite *model.Favorite) (string, uint, bool) {
+	nodes, _, err := search.Search(c.Request.Context(), model.SearchReq{
+		Parent:   user.GetBasePath(),
+		Keywords: favorite.FileName,
+		Scope:    2,
+		PageReq:  model.PageReq{Page: 1, PerPage: 100},
+	})
+	if err != nil {
+		return "", 0, false
+	}
+	for _, node := range nodes {
+		if node.IsDir || node.Name != favorite.FileName {
+			continue
+		}
+		fullPath := stdpath.Join(node.Parent, node.Name)
+		if !utils.IsSubPath(user.GetBasePath(), fullPath) {
+			continue
+		}
+		storage, actualPath, err := op.GetStorageAndActualPath(fullPath)
+		if err != nil {
+			continue
+		}
+		if op.BuildClientMediaFingerprint(node.Name, node.Size) == favorite.Fingerprint {
+			return fullPath, storage.GetStorage().ID, true
+		}
+		obj, err := op.GetUnwrap(c.Request.Context(), storage, actualPath)
+		if err != nil {
+			continue
+		}
+		if op.BuildMediaFingerprint(storage, obj) == favorite.Fingerprint {
+			return fullPath, storage.GetStorage().ID, true
+		}
+	}
+	return "", 0, false
+}
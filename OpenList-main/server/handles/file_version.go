@@ -0,0 +1,152 @@
+package handles
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsVersionsReq struct {
+	Path     string `json:"path" form:"path" binding:"required"`
+	Password string `json:"password" form:"password"`
+}
+
+func FsVersionList(c *gin.Context) {
+	var req FsVersionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	versions, err := op.GetFileVersions(storage, actualPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, versions)
+}
+
+type FsVersionReq struct {
+	Path string `json:"path" binding:"required"`
+	Ids  []uint `json:"ids" binding:"required"`
+}
+
+// getFileVersions returns the versions of the request, which must be versions of the file of the path
+func getFileVersions(c *gin.Context, req *FsVersionReq, reqPath string) ([]*model.FileVersion, bool) {
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	versions := make([]*model.FileVersion, 0, len(req.Ids))
+	for _, id := range req.Ids {
+		v, err := op.GetFileVersionById(id)
+		if err != nil || v.StorageId != storage.GetStorage().ID || v.ActualPath != actualPath {
+			common.ErrorStrResp(c, "file version not found", 404)
+			return nil, false
+		}
+		versions = append(versions, v)
+	}
+	return versions, true
+}
+
+// FsVersionRestore puts a version back in place of the file, the current file becomes a version
+func FsVersionRestore(c *gin.Context) {
+	var req FsVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Ids) != 1 {
+		common.ErrorStrResp(c, "only one version can be restored", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if allowed, decided := op.CheckACL(user, reqPath, model.ACLWrite); decided && !allowed {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !user.CanWrite() {
+		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(user, meta, reqPath) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	versions, ok := getFileVersions(c, &req, reqPath)
+	if !ok {
+		return
+	}
+	if err := op.RestoreFileVersion(c.Request.Context(), versions[0]); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func FsVersionDelete(c *gin.Context) {
+	var req FsVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CanRemove(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	versions, ok := getFileVersions(c, &req, reqPath)
+	if !ok {
+		return
+	}
+	for _, v := range versions {
+		if err := op.DeleteFileVersion(c.Request.Context(), v); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
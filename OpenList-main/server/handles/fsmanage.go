@@ -13,7 +13,6 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
-	"github.com/OpenListTeam/OpenList/v4/pkg/generic"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
@@ -44,7 +43,7 @@ func FsMkdir(c *gin.Context) {
 				return
 			}
 		}
-		if !common.CanWrite(meta, reqPath) {
+		if !common.CanWrite(user, meta, reqPath) {
 			common.ErrorResp(c, errs.PermissionDenied, 403)
 			return
 		}
@@ -187,6 +186,62 @@ func FsCopy(c *gin.Context) {
 	}
 }
 
+type SyncReq struct {
+	SrcDir string `json:"src_dir" binding:"required"`
+	DstDir string `json:"dst_dir" binding:"required"`
+	model.SyncArgs
+	// DryRun returns the plan of the sync instead of running it
+	DryRun bool `json:"dry_run"`
+}
+
+func FsSync(c *gin.Context) {
+	var req SyncReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.TwoWay && req.Delete {
+		common.ErrorStrResp(c, "delete is not supported in two-way sync", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanCopy() || (req.Delete && !user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if utils.IsSubPath(srcDir, dstDir) || utils.IsSubPath(dstDir, srcDir) {
+		common.ErrorStrResp(c, "the source and destination directories overlap", 400)
+		return
+	}
+	if req.DryRun {
+		plan, err := fs.SyncPlan(c.Request.Context(), srcDir, dstDir, req.SyncArgs)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		common.SuccessResp(c, gin.H{"plan": plan})
+		return
+	}
+	t, err := fs.Sync(c.Request.Context(), srcDir, dstDir, req.SyncArgs)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"tasks": getTaskInfos([]task.TaskExtensionInfo{t}),
+	})
+}
+
 type RenameReq struct {
 	Path      string `json:"path"`
 	Name      string `json:"name"`
@@ -251,15 +306,17 @@ func FsRemove(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqDir, err := user.JoinPath(req.Dir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	for _, name := range req.Names {
+		if !common.CanRemove(user, stdpath.Join(reqDir, name)) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
 	for _, name := range req.Names {
 		err := fs.Remove(c.Request.Context(), stdpath.Join(reqDir, name))
 		if err != nil {
@@ -302,71 +359,11 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 	common.GinWithValue(c, conf.MetaKey, meta)
 
-	rootFiles, err := fs.List(c.Request.Context(), srcDir, &fs.ListArgs{})
-	if err != nil {
+	if err := fs.RemoveEmptyDirectory(c.Request.Context(), srcDir); err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
 
-	// record the file path
-	filePathMap := make(map[model.Obj]string)
-	// record the parent file
-	fileParentMap := make(map[model.Obj]model.Obj)
-	// removing files
-	removingFiles := generic.NewQueue[model.Obj]()
-	// removed files
-	removedFiles := make(map[string]bool)
-	for _, file := range rootFiles {
-		if !file.IsDir() {
-			continue
-		}
-		removingFiles.Push(file)
-		filePathMap[file] = srcDir
-	}
-
-	for !removingFiles.IsEmpty() {
-
-		removingFile := removingFiles.Pop()
-		removingFilePath := fmt.Sprintf("%s/%s", filePathMap[removingFile], removingFile.GetName())
-
-		if removedFiles[removingFilePath] {
-			continue
-		}
-
-		subFiles, err := fs.List(c.Request.Context(), removingFilePath, &fs.ListArgs{Refresh: true})
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
-		}
-
-		if len(subFiles) == 0 {
-			// remove empty directory
-			err = fs.Remove(c.Request.Context(), removingFilePath)
-			removedFiles[removingFilePath] = true
-			if err != nil {
-				common.ErrorResp(c, err, 500)
-				return
-			}
-			// recheck parent folder
-			parentFile, exist := fileParentMap[removingFile]
-			if exist {
-				removingFiles.Push(parentFile)
-			}
-
-		} else {
-			// recursive remove
-			for _, subFile := range subFiles {
-				if !subFile.IsDir() {
-					continue
-				}
-				removingFiles.Push(subFile)
-				filePathMap[subFile] = removingFilePath
-				fileParentMap[subFile] = removingFile
-			}
-		}
-
-	}
-
 	common.SuccessResp(c)
 }
 
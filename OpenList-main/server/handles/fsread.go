@@ -12,7 +12,6 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
-	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
@@ -56,6 +55,8 @@ type FsListResp struct {
 	Write             bool      `json:"write"`
 	Provider          string    `json:"provider"`
 	DirectUploadTools []string  `json:"direct_upload_tools,omitempty"`
+	// Sign of the folder, to download the selected objects as zip
+	Sign string `json:"sign"`
 }
 
 func FsListSplit(c *gin.Context) {
@@ -96,7 +97,7 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+	if !user.CanWrite() && !common.CanWrite(user, meta, reqPath) && req.Refresh {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
@@ -117,13 +118,14 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		}
 	}
 	common.SuccessResp(c, FsListResp{
-		Content:           toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
+		Content:           toObjsResp(objs, reqPath, isEncrypt(meta, reqPath), user),
 		Total:             int64(total),
 		Readme:            getReadme(meta, reqPath),
 		Header:            getHeader(meta, reqPath),
-		Write:             user.CanWrite() || common.CanWrite(meta, reqPath),
+		Write:             user.CanWrite() || common.CanWrite(user, meta, reqPath),
 		Provider:          provider,
 		DirectUploadTools: directUploadTools,
+		Sign:              common.SignPath(reqPath, isEncrypt(meta, reqPath), user),
 	})
 }
 
@@ -228,7 +230,7 @@ func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 	return total, objs[start:end]
 }
 
-func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
+func toObjsResp(objs []model.Obj, parent string, encrypt bool, user *model.User) []ObjResp {
 	var resp []ObjResp
 	for _, obj := range objs {
 		thumb, _ := model.GetThumb(obj)
@@ -243,7 +245,7 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parent, encrypt),
+			Sign:         common.Sign(obj, parent, encrypt, user),
 			Thumb:        thumb,
 			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
 			MountDetails: mountDetails,
@@ -264,6 +266,8 @@ type FsGetResp struct {
 	Header   string    `json:"header"`
 	Provider string    `json:"provider"`
 	Related  []ObjResp `json:"related"`
+	// AudioMeta is the embedded tags of audio files
+	AudioMeta *model.AudioMeta `json:"audio_meta,omitempty"`
 }
 
 func FsGetSplit(c *gin.Context) {
@@ -326,8 +330,8 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			rawURL = common.GenerateDownProxyURL(storage.GetStorage(), reqPath)
 			if rawURL == "" {
 				query := ""
-				if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
-					query = "?sign=" + sign.Sign(reqPath)
+				if s := common.SignPath(reqPath, isEncrypt(meta, reqPath), user); s != "" {
+					query = "?sign=" + s
 				}
 				rawURL = fmt.Sprintf("%s/p%s%s",
 					common.GetApiUrl(c),
@@ -354,6 +358,10 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			}
 		}
 	}
+	var audioMeta *model.AudioMeta
+	if !obj.IsDir() {
+		audioMeta = getAudioMetaOrNil(c.Request.Context(), reqPath)
+	}
 	var related []model.Obj
 	parentPath := stdpath.Dir(reqPath)
 	sameLevelFiles, err := fs.List(c.Request.Context(), parentPath, &fs.ListArgs{})
@@ -374,16 +382,17 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
+			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath), user),
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			MountDetails: mountDetails,
 		},
-		RawURL:   rawURL,
-		Readme:   getReadme(meta, reqPath),
-		Header:   getHeader(meta, reqPath),
-		Provider: provider,
-		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath)),
+		RawURL:    rawURL,
+		Readme:    getReadme(meta, reqPath),
+		Header:    getHeader(meta, reqPath),
+		Provider:  provider,
+		Related:   toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath), user),
+		AudioMeta: audioMeta,
 	})
 }
 
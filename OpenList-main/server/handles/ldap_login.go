@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -49,6 +50,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
 	ldapUserSearchBase := setting.GetStr(conf.LdapUserSearchBase)
 	ldapUserSearchFilter := setting.GetStr(conf.LdapUserSearchFilter) // (uid=%s)
+	ldapGroupAttribute := setting.GetStr(conf.LdapGroupAttribute, "memberOf")
 
 	// Connect to LdapServer
 	l, err := dial(ldapServer)
@@ -73,7 +75,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		ldapUserSearchBase,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		fmt.Sprintf(ldapUserSearchFilter, req.Username),
-		[]string{"dn"},
+		[]string{"dn", ldapGroupAttribute},
 		nil,
 	)
 	sr, err := l.Search(searchRequest)
@@ -88,6 +90,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		return
 	}
 	userDN := sr.Entries[0].DN
+	ldapGroups := ldapGroupNames(sr.Entries[0].GetAttributeValues(ldapGroupAttribute))
 
 	// Bind as the user to verify their password
 	err = l.Bind(userDN, req.Password)
@@ -95,6 +98,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		utils.Log.Errorf("Failed to auth. %v", err)
 		common.ErrorResp(c, err, 400)
 		model.LoginCache.Set(ip, count+1)
+		audit.RecordLogin(c.Request.Context(), nil, req.Username, err)
 		return
 	} else {
 		utils.Log.Infof("Auth successful username:%s", req.Username)
@@ -107,9 +111,13 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 			model.LoginCache.Set(ip, count+1)
+			audit.RecordLogin(c.Request.Context(), nil, req.Username, err)
 			return
 		}
 	}
+	if err := op.SyncExternalGroups(user, ldapGroups); err != nil {
+		utils.Log.Errorf("failed to sync LDAP groups of %s: %+v", user.Username, err)
+	}
 
 	// generate token
 	token, err := common.GenerateToken(user)
@@ -118,9 +126,29 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	audit.RecordLogin(c.Request.Context(), user, "", nil)
 	model.LoginCache.Del(ip)
 }
 
+// ldapGroupNames returns the common names of the group DNs together with the values
+// themselves, so that a group can be mapped by either of them
+func ldapGroupNames(values []string) []string {
+	names := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		names = append(names, v)
+		dn, err := ldap.ParseDN(v)
+		if err != nil || len(dn.RDNs) == 0 {
+			continue
+		}
+		for _, attr := range dn.RDNs[0].Attributes {
+			if strings.EqualFold(attr.Type, "cn") {
+				names = append(names, attr.Value)
+			}
+		}
+	}
+	return names
+}
+
 func ladpRegister(username string) (*model.User, error) {
 	if username == "" {
 		return nil, errors.New("cannot get username from ldap provider")
@@ -0,0 +1,115 @@
+package handles
+
+import (
+	"path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type LibraryReq struct {
+	model.LibraryReq
+	Password string `json:"password" form:"password"`
+}
+
+func bindLibraryReq(c *gin.Context) (*LibraryReq, *model.User, bool) {
+	var req LibraryReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var err error
+	req.Parent, err = user.JoinPath(req.Parent)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	if err := req.Validate(); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	return &req, user, true
+}
+
+// canAccessLibraryPath is the access check of the tracks found by the searchers
+func canAccessLibraryPath(user *model.User, reqPath, password string) bool {
+	meta, err := getMetaOrNil(path.Dir(reqPath))
+	if err != nil {
+		return false
+	}
+	return common.CanAccess(user, meta, reqPath, password)
+}
+
+// accessibleTracks lists all the tracks matching req that the user can access, the albums and the artists
+// are grouped from them and all are paginated after filtering, so that the pages and the totals
+// only count what the user can access
+func accessibleTracks(c *gin.Context, req *LibraryReq, user *model.User) ([]model.SearchNode, bool) {
+	tracksReq := req.LibraryReq
+	tracksReq.PageReq = model.PageReq{Page: 1, PerPage: model.MaxInt}
+	tracks, _, err := search.Tracks(c.Request.Context(), tracksReq)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return nil, false
+	}
+	filtered := make([]model.SearchNode, 0, len(tracks))
+	for _, track := range tracks {
+		if canAccessLibraryPath(user, path.Join(track.Parent, track.Name), req.Password) {
+			filtered = append(filtered, track)
+		}
+	}
+	return filtered, true
+}
+
+func LibraryArtists(c *gin.Context) {
+	req, user, ok := bindLibraryReq(c)
+	if !ok {
+		return
+	}
+	tracks, ok := accessibleTracks(c, req, user)
+	if !ok {
+		return
+	}
+	artists, total := model.Paginate(searcher.GroupArtists(tracks), req.PageReq)
+	common.SuccessResp(c, common.PageResp{
+		Content: artists,
+		Total:   total,
+	})
+}
+
+func LibraryAlbums(c *gin.Context) {
+	req, user, ok := bindLibraryReq(c)
+	if !ok {
+		return
+	}
+	tracks, ok := accessibleTracks(c, req, user)
+	if !ok {
+		return
+	}
+	albums, total := model.Paginate(searcher.GroupAlbums(tracks), req.PageReq)
+	common.SuccessResp(c, common.PageResp{
+		Content: albums,
+		Total:   total,
+	})
+}
+
+func LibraryTracks(c *gin.Context) {
+	req, user, ok := bindLibraryReq(c)
+	if !ok {
+		return
+	}
+	tracks, ok := accessibleTracks(c, req, user)
+	if !ok {
+		return
+	}
+	tracks, total := model.Paginate(tracks, req.PageReq)
+	common.SuccessResp(c, common.PageResp{
+		Content: utils.MustSliceConvert(tracks, nodeToSearchResp),
+		Total:   total,
+	})
+}
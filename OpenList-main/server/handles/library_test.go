@@ -0,0 +1,70 @@
+package handles
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	_ "github.com/OpenListTeam/OpenList/v4/internal/search/db_non_full_text"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLibraryPagesOnlyCountAccessibleTracks(t *testing.T) {
+	if err := search.Init("database_non_full_text"); err != nil {
+		t.Fatal(err)
+	}
+	defer search.Init("none")
+	nodes := []model.SearchNode{
+		{Parent: "/library/open", Name: "1.mp3", Artist: "A", AlbumArtist: "A", Album: "Open", Track: 1},
+		{Parent: "/library/open", Name: "2.mp3", Artist: "A", AlbumArtist: "A", Album: "Open", Track: 2},
+		{Parent: "/library/open2", Name: "1.mp3", Artist: "B", AlbumArtist: "B", Album: "Other", Track: 1},
+		{Parent: "/library/private", Name: "1.mp3", Artist: "A", AlbumArtist: "A", Album: "Hidden", Track: 1},
+		{Parent: "/library/private", Name: "2.mp3", Artist: "C", AlbumArtist: "C", Album: "Hidden", Track: 2},
+	}
+	if err := db.BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.CreateMeta(&model.Meta{
+		Path: "/library/private",
+		ACL:  []model.ACLRule{{User: "library-user", Deny: true, Actions: []string{model.ACLRead}}},
+		ASub: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 1000, Username: "library-user", BasePath: "/", Role: model.GENERAL}
+
+	for _, tt := range []struct {
+		name    string
+		handler gin.HandlerFunc
+		total   int64
+	}{
+		{"artists", LibraryArtists, 2},
+		{"albums", LibraryAlbums, 2},
+		{"tracks", LibraryTracks, 3},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/fs/library/"+tt.name+"?parent=/library&page=1&per_page=1", nil)
+		common.GinWithValue(c, conf.UserKey, user)
+		tt.handler(c)
+		var resp struct {
+			Code int
+			Data struct {
+				Content []json.RawMessage
+				Total   int64
+			}
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != 200 {
+			t.Fatalf("library %s failed: %s", tt.name, w.Body.String())
+		}
+		if resp.Data.Total != tt.total || len(resp.Data.Content) != 1 {
+			t.Errorf("library %s: got %d of %d, want 1 of %d", tt.name, len(resp.Data.Content), resp.Data.Total, tt.total)
+		}
+	}
+}
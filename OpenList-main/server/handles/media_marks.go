@@ -0,0 +1,133 @@
+package handles
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ExportMediaMarksReq struct {
+	Path     string  `json:"path" form:"path"`
+	Password string  `json:"password" form:"password"`
+	Format   string  `json:"format" form:"format"`
+	Duration float64 `json:"duration" form:"duration"`
+}
+
+type ImportMediaMarksReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Format is one of vtt, ffmetadata and json, detected from the content if empty
+	Format  string `json:"format" form:"format"`
+	Content string `json:"content" form:"content" binding:"required"`
+}
+
+// getMediaMarksObj resolves the file the marks belong to, checking the access like FsOther does
+func getMediaMarksObj(c *gin.Context, path, password string) (driver.Driver, model.Obj, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return nil, nil, false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return nil, nil, false
+		}
+	}
+	common.GinWithValue(c, conf.MetaKey, meta)
+	if !common.CanAccess(user, meta, reqPath, password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return nil, nil, false
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	obj, err := op.GetUnwrap(c.Request.Context(), storage, actualPath)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil, nil, false
+	}
+	if obj.IsDir() {
+		common.ErrorStrResp(c, "media marks can only be attached to files", 400)
+		return nil, nil, false
+	}
+	return storage, obj, true
+}
+
+func ExportMediaMarks(c *gin.Context) {
+	var req ExportMediaMarksReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = op.MediaMarksFormatJSON
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot export media marks", 403)
+		return
+	}
+	storage, obj, ok := getMediaMarksObj(c, req.Path, req.Password)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := op.ExportMediaMarks(&buf, storage, obj, user, req.Format, req.Duration); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	contentType := "application/json; charset=utf-8"
+	switch req.Format {
+	case op.MediaMarksFormatVTT:
+		contentType = "text/vtt; charset=utf-8"
+	case op.MediaMarksFormatFFMetadata:
+		contentType = "text/plain; charset=utf-8"
+	}
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(op.MediaMarksExportName(obj, req.Format)))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func ImportMediaMarks(c *gin.Context) {
+	var req ImportMediaMarksReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() || user.Disabled {
+		common.ErrorStrResp(c, "permission denied: guest or disabled users cannot import media marks", 403)
+		return
+	}
+	marks, err := op.ParseMediaMarks(req.Format, []byte(req.Content))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, obj, ok := getMediaMarksObj(c, req.Path, req.Password)
+	if !ok {
+		return
+	}
+	created, err := op.ImportMediaMarks(c.Request.Context(), storage, obj, user, marks)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"parsed":  len(marks),
+		"created": created,
+	})
+}
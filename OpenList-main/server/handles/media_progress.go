@@ -0,0 +1,104 @@
+package handles
+
+import (
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultContinueWatchingLimit = 20
+	maxContinueWatchingLimit     = 100
+)
+
+type ContinueWatchingResp struct {
+	Path      string    `json:"path"` // relative to the user's base path
+	Name      string    `json:"name"`
+	StorageId uint      `json:"storage_id"`
+	Type      int       `json:"type"`
+	Position  float64   `json:"position"`
+	Duration  float64   `json:"duration"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListContinueWatching returns recently played but unfinished video and audio files
+func ListContinueWatching(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users cannot access playback progress", 403)
+		return
+	}
+
+	limit := defaultContinueWatchingLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			common.ErrorStrResp(c, "invalid limit", 400)
+			return
+		}
+		limit = min(l, maxContinueWatchingLimit)
+	}
+
+	result, err := continueWatching(user, limit)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, result)
+}
+
+// continueWatching returns up to limit progresses of the user, the progresses are read in pages
+// until enough of them are left after leaving out the ones the user can't access any more
+func continueWatching(user *model.User, limit int) ([]ContinueWatchingResp, error) {
+	basePath := user.GetBasePath()
+	result := []ContinueWatchingResp{}
+	for offset := 0; len(result) < limit; offset += maxContinueWatchingLimit {
+		progresses, err := db.ListUnfinishedPlaybackProgress(user.ID, offset, maxContinueWatchingLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, progress := range progresses {
+			if len(result) == limit {
+				break
+			}
+			fileType := utils.GetFileType(progress.OriginalPath)
+			if fileType != conf.VIDEO && fileType != conf.AUDIO {
+				continue
+			}
+			if !utils.IsSubPath(basePath, progress.OriginalPath) {
+				continue
+			}
+			meta, err := op.GetNearestMeta(stdpath.Dir(progress.OriginalPath))
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				continue
+			}
+			if !common.CanAccess(user, meta, progress.OriginalPath, "") {
+				continue
+			}
+			result = append(result, ContinueWatchingResp{
+				Path:      utils.FixAndCleanPath(strings.TrimPrefix(progress.OriginalPath, basePath)),
+				Name:      stdpath.Base(progress.OriginalPath),
+				StorageId: progress.StorageId,
+				Type:      fileType,
+				Position:  progress.Position,
+				Duration:  progress.Duration,
+				UpdatedAt: progress.UpdatedAt,
+			})
+		}
+		if len(progresses) < maxContinueWatchingLimit {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package handles
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestContinueWatchingFiltersBeforeLimit(t *testing.T) {
+	conf.SlicesMap[conf.VideoTypes] = []string{"mp4"}
+	conf.SlicesMap[conf.AudioTypes] = []string{"mp3"}
+	user := &model.User{Username: "watching", BasePath: "/media"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	save := func(path string, finished bool) {
+		err := db.SavePlaybackProgress(&model.PlaybackProgress{UserId: user.ID, Fingerprint: path,
+			OriginalPath: path, Position: 10, Finished: finished})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("/media/a.mp4", false)
+	save("/media/b.mp3", false)
+	save("/media/c.mp4", true)
+	// more recent ones the user can't see, more than a page of them
+	for i := 0; i <= maxContinueWatchingLimit; i++ {
+		save(fmt.Sprintf("/other/%d.mp4", i), false)
+	}
+	save("/media/d.txt", false)
+
+	result, err := continueWatching(user, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0].Path != "/b.mp3" || result[1].Path != "/a.mp4" {
+		t.Errorf("continueWatching() = %+v, want /b.mp3 and /a.mp4", result)
+	}
+	if result, err = continueWatching(user, 1); err != nil || len(result) != 1 || result[0].Path != "/b.mp3" {
+		t.Errorf("continueWatching() with limit 1 = %+v, %v", result, err)
+	}
+}
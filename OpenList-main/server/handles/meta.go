@@ -2,6 +2,7 @@ package handles
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -43,6 +44,10 @@ func CreateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
+	if err := validACL(req.ACL); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if err := op.CreateMeta(&req); err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
@@ -61,6 +66,10 @@ func UpdateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
+	if err := validACL(req.ACL); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if err := op.UpdateMeta(&req); err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
@@ -79,6 +88,20 @@ func validHide(hide string) (string, error) {
 	return "", nil
 }
 
+func validACL(rules []model.ACLRule) error {
+	for _, r := range rules {
+		if r.User != "" && r.Group != "" {
+			return fmt.Errorf("a rule can't be about both user %s and group %s", r.User, r.Group)
+		}
+		for _, a := range r.Actions {
+			if !slices.Contains([]string{model.ACLRead, model.ACLWrite, model.ACLDelete, model.ACLShare}, a) {
+				return fmt.Errorf("unknown action %s", a)
+			}
+		}
+	}
+	return nil
+}
+
 func DeleteMeta(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
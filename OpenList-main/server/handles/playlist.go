@@ -0,0 +1,322 @@
+package handles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/playlist"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxPlaylistEntries = 5000
+	maxPlaylistDepth   = 16
+)
+
+type PlaylistReq struct {
+	Path      string `json:"path" form:"path"`
+	Password  string `json:"password" form:"password"`
+	Format    string `json:"format" form:"format"`
+	Recursive bool   `json:"recursive" form:"recursive"`
+	// render a favorite folder or a saved audio playlist instead of a directory
+	FavoriteFolderId uint `json:"favorite_folder_id" form:"favorite_folder_id"`
+	PlaylistId       uint `json:"playlist_id" form:"playlist_id"`
+}
+
+type PlaylistResp struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"`
+	// URL is a signed link to the playlist for players that cannot log in
+	URL string `json:"url"`
+}
+
+// playlistSource is what a playlist is made of, it is signed as a whole in /pl links
+type playlistSource struct {
+	userId           uint
+	path             string
+	recursive        bool
+	favoriteFolderId uint
+	playlistId       uint
+	// password of the meta of path, it is part of the signature but never of the link,
+	// so links stop working once the password changes
+	password string
+}
+
+func (s playlistSource) signData() string {
+	return fmt.Sprintf("playlist:%d:%d:%d:%t:%s:%s", s.userId, s.favoriteFolderId, s.playlistId, s.recursive, s.path, s.password)
+}
+
+func (s playlistSource) url(ctx context.Context, format string, d time.Duration) string {
+	query := url.Values{}
+	query.Set("uid", strconv.FormatUint(uint64(s.userId), 10))
+	query.Set("format", format)
+	if s.recursive {
+		query.Set("recursive", "true")
+	}
+	if s.favoriteFolderId != 0 {
+		query.Set("favorite_folder_id", strconv.FormatUint(uint64(s.favoriteFolderId), 10))
+	}
+	if s.playlistId != 0 {
+		query.Set("playlist_id", strconv.FormatUint(uint64(s.playlistId), 10))
+	}
+	query.Set("sign", sign.WithDuration(s.signData(), d))
+	return fmt.Sprintf("%s/pl%s?%s", common.GetApiUrl(ctx), utils.EncodePath(s.path, true), query.Encode())
+}
+
+// playlistLinkDuration is how long the links of a playlist stay valid. Playlists are opened
+// outside the browser long after they were created, so they always expire even if links don't.
+func playlistLinkDuration() time.Duration {
+	if expire := setting.GetInt(conf.LinkExpiration, 0); expire > 0 {
+		return time.Duration(expire) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func isPlaylistMedia(name string) bool {
+	t := utils.GetFileType(name)
+	return t == conf.AUDIO || t == conf.VIDEO
+}
+
+func getMetaOrNil(path string) (*model.Meta, error) {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// walkPlaylistDir collects the media files of the directory, sub directories that cannot be
+// accessed or listed are skipped
+func walkPlaylistDir(ctx context.Context, user *model.User, path, password string, depth int, recursive bool, paths *[]string) error {
+	meta, err := getMetaOrNil(path)
+	if err != nil {
+		return err
+	}
+	if !common.CanAccess(user, meta, path, password) {
+		return errors.New("password is incorrect or you have no permission")
+	}
+	objs, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.ListArgs{NoLog: depth > 0})
+	if err != nil {
+		return err
+	}
+	model.SortFiles(objs, "name", "asc")
+	for _, obj := range objs {
+		if len(*paths) >= maxPlaylistEntries {
+			return nil
+		}
+		objPath := stdpath.Join(path, obj.GetName())
+		if !obj.IsDir() {
+			if isPlaylistMedia(obj.GetName()) {
+				*paths = append(*paths, objPath)
+			}
+		} else if recursive && depth < maxPlaylistDepth {
+			_ = walkPlaylistDir(ctx, user, objPath, password, depth+1, recursive, paths)
+		}
+	}
+	return nil
+}
+
+// accessiblePlaylistPaths converts the stored base path relative paths of favorites and
+// saved playlists, dropping the ones the user cannot access anymore
+func accessiblePlaylistPaths(user *model.User, originalPaths []string) []string {
+	res := make([]string, 0, len(originalPaths))
+	for _, p := range originalPaths {
+		if len(res) >= maxPlaylistEntries {
+			break
+		}
+		reqPath, err := user.JoinPath(p)
+		if err != nil || !isPlaylistMedia(reqPath) {
+			continue
+		}
+		meta, err := getMetaOrNil(reqPath)
+		if err != nil || !common.CanAccess(user, meta, reqPath, "") {
+			continue
+		}
+		res = append(res, reqPath)
+	}
+	return res
+}
+
+// buildPlaylist renders the playlist of src and returns its file name, src.path must already be joined with the base path
+func buildPlaylist(ctx context.Context, user *model.User, src playlistSource, format string) (string, []byte, error) {
+	var title string
+	var paths []string
+	switch {
+	case src.favoriteFolderId != 0:
+		folder, err := db.GetFavoriteFolderById(src.favoriteFolderId, user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		favorites, err := db.ListFavoritesByFolder(folder.ID, user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		title = folder.Name
+		paths = accessiblePlaylistPaths(user, utils.MustSliceConvert(favorites, func(f model.Favorite) string {
+			return f.OriginalPath
+		}))
+	case src.playlistId != 0:
+		saved, err := db.GetAudioPlaylistById(src.playlistId, user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		items, err := db.ListAudioPlaylistItems(saved.ID, user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		title = saved.Name
+		paths = accessiblePlaylistPaths(user, utils.MustSliceConvert(items, func(i model.AudioPlaylistItem) string {
+			return i.OriginalPath
+		}))
+	default:
+		title = stdpath.Base(src.path)
+		ctx = context.WithValue(ctx, conf.UserKey, user)
+		if err := walkPlaylistDir(ctx, user, src.path, src.password, 0, src.recursive, &paths); err != nil {
+			return "", nil, err
+		}
+	}
+	d := playlistLinkDuration()
+	api := common.GetApiUrl(ctx)
+	entries := make([]playlist.Entry, len(paths))
+	for i, p := range paths {
+		// the players fetch the links without the token, the downloads are accounted to the user by the sign
+		s := sign.WithDuration(p, d)
+		if !user.IsGuest() {
+			s = sign.WithDurationUser(p, user.ID, d)
+		}
+		entries[i] = playlist.Entry{
+			Title: stdpath.Base(p),
+			URL:   fmt.Sprintf("%s/d%s?sign=%s", api, utils.EncodePath(p, true), s),
+		}
+	}
+	var buf bytes.Buffer
+	if err := playlist.Write(&buf, format, title, entries); err != nil {
+		return "", nil, err
+	}
+	if title == "" || title == "/" {
+		title = "playlist"
+	}
+	return title + "." + format, buf.Bytes(), nil
+}
+
+func FsPlaylist(c *gin.Context) {
+	var req PlaylistReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = playlist.FormatM3U8
+	}
+	if !playlist.IsValidFormat(req.Format) {
+		common.ErrorStrResp(c, "invalid playlist format", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if (req.FavoriteFolderId != 0 || req.PlaylistId != 0) && user.IsGuest() {
+		common.ErrorStrResp(c, "permission denied: guest users have no favorites or playlists", 403)
+		return
+	}
+	src := playlistSource{
+		userId:           user.ID,
+		path:             "/",
+		recursive:        req.Recursive,
+		favoriteFolderId: req.FavoriteFolderId,
+		playlistId:       req.PlaylistId,
+	}
+	if req.FavoriteFolderId == 0 && req.PlaylistId == 0 {
+		reqPath, err := user.JoinPath(req.Path)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		meta, err := getMetaOrNil(reqPath)
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CanAccess(user, meta, reqPath, req.Password) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+		src.path = reqPath
+		if meta != nil {
+			src.password = meta.Password
+		}
+	}
+	name, content, err := buildPlaylist(c.Request.Context(), user, src, req.Format)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, PlaylistResp{
+		Name:        name,
+		ContentType: playlist.ContentType(req.Format),
+		Content:     string(content),
+		URL:         src.url(c.Request.Context(), req.Format, playlistLinkDuration()),
+	})
+}
+
+// PlaylistDown serves the playlist of a signed /pl link on behalf of the user that created it
+func PlaylistDown(c *gin.Context) {
+	rawPath := c.Request.Context().Value(conf.PathKey).(string)
+	format := c.DefaultQuery("format", playlist.FormatM3U8)
+	if !playlist.IsValidFormat(format) {
+		common.ErrorPage(c, errors.New("invalid playlist format"), 400)
+		return
+	}
+	uid, _ := strconv.ParseUint(c.Query("uid"), 10, 64)
+	favoriteFolderId, _ := strconv.ParseUint(c.Query("favorite_folder_id"), 10, 64)
+	playlistId, _ := strconv.ParseUint(c.Query("playlist_id"), 10, 64)
+	recursive, _ := strconv.ParseBool(c.Query("recursive"))
+	src := playlistSource{
+		userId:           uint(uid),
+		path:             rawPath,
+		recursive:        recursive,
+		favoriteFolderId: uint(favoriteFolderId),
+		playlistId:       uint(playlistId),
+	}
+	meta, err := getMetaOrNil(rawPath)
+	if err != nil {
+		common.ErrorPage(c, err, 500, true)
+		return
+	}
+	if meta != nil && src.favoriteFolderId == 0 && src.playlistId == 0 {
+		src.password = meta.Password
+	}
+	if err := sign.Verify(src.signData(), c.Query("sign")); err != nil {
+		common.ErrorPage(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(src.userId)
+	if err != nil || user.Disabled {
+		common.ErrorPage(c, errors.New("the user of the playlist is not available"), 401)
+		return
+	}
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, user)
+	name, content, err := buildPlaylist(ctx, user, src, format)
+	if err != nil {
+		common.ErrorPage(c, err, 500)
+		return
+	}
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(name))
+	c.Data(http.StatusOK, playlist.ContentType(format), content)
+}
@@ -0,0 +1,164 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/schedule"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduledJobResp struct {
+	model.ScheduledJob
+	NextRunAt *time.Time `json:"next_run_at"`
+}
+
+func toScheduledJobResp(job model.ScheduledJob) ScheduledJobResp {
+	return ScheduledJobResp{ScheduledJob: job, NextRunAt: schedule.Next(job.ID)}
+}
+
+func ListScheduledJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := db.GetScheduledJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]ScheduledJobResp, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, toScheduledJobResp(job))
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   total,
+	})
+}
+
+func GetScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	job, err := db.GetScheduledJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toScheduledJobResp(*job))
+}
+
+func CreateScheduledJob(c *gin.Context) {
+	var req model.ScheduledJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := schedule.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID, req.LastRunAt = 0, nil
+	if err := db.CreateScheduledJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if err := schedule.Set(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toScheduledJobResp(req))
+}
+
+func UpdateScheduledJob(c *gin.Context) {
+	var req model.ScheduledJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := schedule.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	old, err := db.GetScheduledJobById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.LastRunAt, req.CreatedAt = old.LastRunAt, old.CreatedAt
+	if err = db.UpdateScheduledJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if err = schedule.Set(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toScheduledJobResp(req))
+}
+
+func DeleteScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	schedule.Remove(uint(id))
+	if err := db.DeleteScheduledJobById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunScheduledJob runs the job immediately, the returned run can be followed in the run history
+func RunScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	job, err := db.GetScheduledJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	run, err := schedule.RunNow(job)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, run)
+}
+
+type ListScheduledJobRunsReq struct {
+	model.PageReq
+	JobId uint `json:"job_id" form:"job_id" binding:"required"`
+}
+
+func ListScheduledJobRuns(c *gin.Context) {
+	var req ListScheduledJobRunsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	runs, total, err := db.GetScheduledJobRuns(req.JobId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	schedule.FillTaskIds(runs)
+	common.SuccessResp(c, common.PageResp{
+		Content: runs,
+		Total:   total,
+	})
+}
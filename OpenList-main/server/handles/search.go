@@ -51,7 +51,7 @@ func Search(c *gin.Context) {
 	}
 	var filteredNodes []model.SearchNode
 	for _, node := range nodes {
-		if !strings.HasPrefix(node.Parent, user.BasePath) {
+		if !strings.HasPrefix(node.Parent, user.GetBasePath()) {
 			continue
 		}
 		meta, err := op.GetNearestMeta(node.Parent)
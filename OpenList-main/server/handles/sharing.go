@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -33,7 +35,7 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	url := ""
 	if !obj.IsDir() {
@@ -79,7 +81,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
@@ -135,7 +137,7 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
 	if s.Pwd != "" {
@@ -181,7 +183,7 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	total, objs := pagination(objs, &req.PageReq)
 	ret, _ := utils.SliceConvert(objs, func(src model.Obj) (ObjResp, error) {
 		return toObjsRespWithoutSignAndThumb(src), nil
@@ -193,18 +195,25 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 }
 
 func SharingDown(c *gin.Context) {
+	if _, ok := c.GetQuery("zip"); ok {
+		SharingZipDown(c)
+		return
+	}
 	sid := c.Request.Context().Value(conf.SharingIDKey).(string)
 	path := c.Request.Context().Value(conf.PathKey).(string)
 	path = utils.FixAndCleanPath(path)
 	pwd := c.Query("pwd")
 	s, err := op.GetSharingById(sid)
 	if err == nil {
-		if !s.Valid() {
+		if !op.SharingValid(s) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
-		} else if len(s.Files) != 1 && path == "/" {
-			err = errors.New("cannot get sharing root link")
+		} else {
+			s, err = sharing.ResolveFiles(s)
+			if err == nil && !s.SingleRoot() && path == "/" {
+				err = errors.New("cannot get sharing root link")
+			}
 		}
 	}
 	if dealErrorPage(c, err) {
@@ -219,11 +228,20 @@ func SharingDown(c *gin.Context) {
 	if dealErrorPage(c, err) {
 		return
 	}
+	// the traffic of a sharing is accounted to its creator
+	if !checkDownCap(c, s.Creator) {
+		return
+	}
 	if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
 		if _, ok := c.GetQuery("d"); !ok {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
+				obj, err := op.Get(c.Request.Context(), storage, actualPath)
+				if dealErrorPage(c, err) {
+					return
+				}
 				c.Redirect(302, url)
-				_ = countAccess(c.ClientIP(), s)
+				_ = countAccess(c, s)
+				accountRedirectDown(c, s.Creator, obj)
 				return
 			}
 		}
@@ -235,10 +253,11 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		accountProxyDown(c, s.Creator)
 	} else {
-		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
+		link, obj, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -248,8 +267,9 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s)
 		redirect(c, link)
+		accountRedirectDown(c, s.Creator, obj)
 	}
 }
 
@@ -266,12 +286,15 @@ func SharingArchiveExtract(c *gin.Context) {
 	archivePass := c.Query("pass")
 	s, err := op.GetSharingById(sid)
 	if err == nil {
-		if !s.Valid() {
+		if !op.SharingValid(s) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
-		} else if len(s.Files) != 1 && path == "/" {
-			err = errors.New("cannot extract sharing root")
+		} else {
+			s, err = sharing.ResolveFiles(s)
+			if err == nil && !s.SingleRoot() && path == "/" {
+				err = errors.New("cannot extract sharing root")
+			}
 		}
 	}
 	if dealErrorPage(c, err) {
@@ -410,6 +433,8 @@ func ListSharings(c *gin.Context) {
 
 type UpdateSharingReq struct {
 	Files       []string   `json:"files"`
+	SourceType  string     `json:"source_type"`
+	SourceId    uint       `json:"source_id"`
 	Expires     *time.Time `json:"expires"`
 	Pwd         string     `json:"pwd"`
 	MaxAccessed int        `json:"max_accessed"`
@@ -429,7 +454,13 @@ func UpdateSharing(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if len(req.Files) == 0 || (len(req.Files) == 1 && req.Files[0] == "") {
+	if !model.IsValidSharingSource(req.SourceType) {
+		common.ErrorStrResp(c, "invalid source type", 400)
+		return
+	}
+	if req.SourceType == model.SharingSourceFavoriteFolder {
+		req.Files = []string{}
+	} else if len(req.Files) == 0 || (len(req.Files) == 1 && req.Files[0] == "") {
 		common.ErrorStrResp(c, "must add at least 1 object", 400)
 		return
 	}
@@ -444,7 +475,8 @@ func UpdateSharing(c *gin.Context) {
 		}
 	} else {
 		user = reqUser
-		if !user.CanShare() {
+		// the files are checked one by one below, since the access rules can allow sharing them
+		if req.SourceType == model.SharingSourceFavoriteFolder && !user.CanShare() {
 			common.ErrorStrResp(c, "permission denied", 403)
 			return
 		}
@@ -452,7 +484,7 @@ func UpdateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && (!strings.HasPrefix(s, user.GetBasePath()) || !op.CanShare(user, s)) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
@@ -465,7 +497,12 @@ func UpdateSharing(c *gin.Context) {
 	if reqUser.IsAdmin() && req.CreatorName == "" {
 		user = s.Creator
 	}
+	if !checkSharingSource(c, &req, user) {
+		return
+	}
 	s.Files = req.Files
+	s.SourceType = req.SourceType
+	s.SourceId = req.SourceId
 	s.Expires = req.Expires
 	s.Pwd = req.Pwd
 	s.Accessed = req.Accessed
@@ -494,7 +531,13 @@ func CreateSharing(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if len(req.Files) == 0 || (len(req.Files) == 1 && req.Files[0] == "") {
+	if !model.IsValidSharingSource(req.SourceType) {
+		common.ErrorStrResp(c, "invalid source type", 400)
+		return
+	}
+	if req.SourceType == model.SharingSourceFavoriteFolder {
+		req.Files = []string{}
+	} else if len(req.Files) == 0 || (len(req.Files) == 1 && req.Files[0] == "") {
 		common.ErrorStrResp(c, "must add at least 1 object", 400)
 		return
 	}
@@ -508,7 +551,7 @@ func CreateSharing(c *gin.Context) {
 		}
 	} else {
 		user = reqUser
-		if !user.CanShare() || (!user.IsAdmin() && req.ID != "") {
+		if (req.SourceType == model.SharingSourceFavoriteFolder && !user.CanShare()) || (!user.IsAdmin() && req.ID != "") {
 			common.ErrorStrResp(c, "permission denied", 403)
 			return
 		}
@@ -516,14 +559,19 @@ func CreateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && (!strings.HasPrefix(s, user.GetBasePath()) || !op.CanShare(user, s)) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
 	}
+	if !checkSharingSource(c, &req, user) {
+		return
+	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
 			ID:          req.ID,
+			SourceType:  req.SourceType,
+			SourceId:    req.SourceId,
 			Expires:     req.Expires,
 			Pwd:         req.Pwd,
 			Accessed:    req.Accessed,
@@ -538,7 +586,9 @@ func CreateSharing(c *gin.Context) {
 		Creator: user,
 	}
 	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	id, err = op.CreateSharing(s)
+	audit.Record(c.Request.Context(), model.AuditShareCreate, id, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		s.ID = id
@@ -550,6 +600,19 @@ func CreateSharing(c *gin.Context) {
 	}
 }
 
+// checkSharingSource makes sure a shared favorite folder belongs to the creator of the sharing
+func checkSharingSource(c *gin.Context, req *UpdateSharingReq, creator *model.User) bool {
+	if req.SourceType != model.SharingSourceFavoriteFolder {
+		req.SourceId = 0
+		return true
+	}
+	if _, err := db.GetFavoriteFolderById(req.SourceId, creator.ID); err != nil {
+		common.ErrorStrResp(c, "favorite folder not found", 400)
+		return false
+	}
+	return true
+}
+
 func DeleteSharing(c *gin.Context) {
 	sid := c.Query("id")
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
@@ -588,13 +651,15 @@ var (
 	AccessCountDelay = 30 * time.Minute
 )
 
-func countAccess(ip string, s *model.Sharing) error {
-	key := fmt.Sprintf("%s:%s", s.ID, ip)
+func countAccess(c *gin.Context, s *model.Sharing) error {
+	key := fmt.Sprintf("%s:%s", s.ID, c.ClientIP())
 	_, ok := AccessCache.Get(key)
 	if !ok {
 		AccessCache.Set(key, struct{}{}, cache.WithEx[interface{}](AccessCountDelay))
 		s.Accessed += 1
-		return op.UpdateSharing(s, true)
+		err := op.UpdateSharing(s, true)
+		audit.Record(c.Request.Context(), model.AuditShareAccess, s.ID, "", err)
+		return err
 	}
 	return nil
 }
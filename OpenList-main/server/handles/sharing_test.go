@@ -0,0 +1,74 @@
+package handles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSharingGrantedByACL(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("shared"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/acl",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	if err := op.CreateMeta(&model.Meta{
+		Path: "/acl",
+		ACL:  []model.ACLRule{{User: "acl-sharer", Actions: []string{model.ACLShare}}},
+		ASub: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: "acl-sharer", BasePath: "/", Role: model.GENERAL}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if user.CanShare() {
+		t.Fatal("the user should only be allowed to share by the access rules")
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/share/create", strings.NewReader(`{"files":["/acl/a.txt"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	common.GinWithValue(c, conf.UserKey, user)
+	CreateSharing(c)
+	var resp struct {
+		Code int
+		Data struct {
+			ID string `json:"id"`
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != 200 {
+		t.Fatalf("failed to create the sharing: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/sd/"+resp.Data.ID, nil)
+	common.GinWithValue(c, conf.SharingIDKey, resp.Data.ID)
+	common.GinWithValue(c, conf.PathKey, "/")
+	SharingDown(c)
+	if w.Code != 200 || w.Body.String() != "shared" {
+		t.Errorf("SharingDown() = %d %q", w.Code, w.Body.String())
+	}
+}
@@ -12,9 +12,11 @@ import (
 
 	"github.com/OpenListTeam/go-cache"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
@@ -256,12 +258,20 @@ func OIDCLoginCallback(c *gin.Context) {
 			user, err = autoRegister(userID, userID, err)
 			if err != nil {
 				common.ErrorResp(c, err, 400)
+				audit.RecordLogin(c.Request.Context(), nil, userID, err)
+				return
 			}
 		}
+		var groups []string
+		utils.Json.Get(payload, setting.GetStr(conf.SSOOIDCGroupsKey, "groups")).ToVal(&groups)
+		if err := op.SyncExternalGroups(user, groups); err != nil {
+			utils.Log.Errorf("failed to sync OIDC groups of %s: %+v", user.Username, err)
+		}
 		token, err := common.GenerateToken(user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 		}
+		audit.RecordLogin(c.Request.Context(), user, "", nil)
 		if useCompatibility {
 			c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 			return
@@ -421,6 +431,7 @@ func SSOLoginCallback(c *gin.Context) {
 		user, err = autoRegister(username, userID, err)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
+			audit.RecordLogin(c.Request.Context(), nil, username, err)
 			return
 		}
 	}
@@ -428,6 +439,7 @@ func SSOLoginCallback(c *gin.Context) {
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 	}
+	audit.RecordLogin(c.Request.Context(), user, "", nil)
 	if usecompatibility {
 		c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 		return
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
@@ -13,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -96,7 +98,9 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c.Request.Context(), req); err != nil {
+	id, err := op.CreateStorage(c.Request.Context(), req)
+	audit.Record(c.Request.Context(), model.AuditStorageCreate, utils.FixAndCleanPath(req.MountPath), "", err)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -113,7 +117,9 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c.Request.Context(), req); err != nil {
+	err := op.UpdateStorage(c.Request.Context(), req)
+	audit.Record(c.Request.Context(), model.AuditStorageUpdate, utils.FixAndCleanPath(req.MountPath), "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -127,13 +133,25 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteStorageById(c.Request.Context(), uint(id)); err != nil {
+	mountPath := storageMountPath(uint(id))
+	err = op.DeleteStorageById(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageDelete, mountPath, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	common.SuccessResp(c)
 }
 
+// storageMountPath returns the mount path of the storage to be recorded in the audit log
+func storageMountPath(id uint) string {
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return ""
+	}
+	return storage.MountPath
+}
+
 func DisableStorage(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -141,7 +159,10 @@ func DisableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DisableStorage(c.Request.Context(), uint(id)); err != nil {
+	mountPath := storageMountPath(uint(id))
+	err = op.DisableStorage(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageUpdate, mountPath, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -155,7 +176,10 @@ func EnableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.EnableStorage(c.Request.Context(), uint(id)); err != nil {
+	mountPath := storageMountPath(uint(id))
+	err = op.EnableStorage(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageUpdate, mountPath, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -225,4 +225,11 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
+	taskRoute(g.Group("/sync"), fs.SyncTaskManager)
+	taskRoute(g.Group("/compress"), fs.ArchiveCompressTaskManager)
+	dedupe := g.Group("/dedupe")
+	taskRoute(dedupe, fs.DedupeTaskManager)
+	dedupe.POST("/report", getTargetedHandler(fs.DedupeTaskManager, func(c *gin.Context, task *fs.DedupeTask) {
+		common.SuccessResp(c, task.Report)
+	}))
 }
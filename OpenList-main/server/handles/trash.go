@@ -0,0 +1,101 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// FsTrashList lists the objects in the recycle bins, users other than admins only see the ones they removed
+func FsTrashList(c *gin.Context) {
+	var req model.TrashQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.IsAdmin() {
+		req.UserId = user.ID
+	}
+	if req.Path != "" {
+		var err error
+		if req.Path, err = user.JoinPath(req.Path); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+	items, total, err := op.GetTrashItems(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashReq struct {
+	Ids []uint `json:"ids" binding:"required"`
+}
+
+// getTrashItems returns the items of the request, the ones removed by others are
+// not found to users other than admins
+func getTrashItems(c *gin.Context) ([]*model.TrashItem, bool) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	items := make([]*model.TrashItem, 0, len(req.Ids))
+	for _, id := range req.Ids {
+		item, err := op.GetTrashItemById(id)
+		if err != nil || (!user.IsAdmin() && item.UserId != user.ID) {
+			common.ErrorStrResp(c, "trash item not found", 404)
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func FsTrashRestore(c *gin.Context) {
+	items, ok := getTrashItems(c)
+	if !ok {
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	for _, item := range items {
+		if !user.IsAdmin() && (!utils.IsSubPath(user.GetBasePath(), item.Path) || !user.CanAccessStorageOf(item.Path)) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	for _, item := range items {
+		if err := op.RestoreTrashItem(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func FsTrashPurge(c *gin.Context) {
+	items, ok := getTrashItems(c)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := op.PurgeTrashItem(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
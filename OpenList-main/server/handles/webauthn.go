@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/authn"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
@@ -108,6 +109,7 @@ func FinishAuthnLogin(c *gin.Context) {
 	}
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		audit.RecordLogin(c.Request.Context(), user, c.Query("username"), err)
 		return
 	}
 
@@ -117,6 +119,7 @@ func FinishAuthnLogin(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	audit.RecordLogin(c.Request.Context(), user, "", nil)
 }
 
 func BeginAuthnRegistration(c *gin.Context) {
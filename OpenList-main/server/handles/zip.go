@@ -0,0 +1,277 @@
+package handles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdpath "path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KirCute/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ZipDownReq struct {
+	Names StringOrArray `json:"names" form:"names"`
+}
+
+// zipRoot is a selected object, path is where it is read from, name is its path in the archive
+type zipRoot struct {
+	path string
+	name string
+	obj  model.Obj
+}
+
+// zipNames returns the names of the objects selected in the folder by a POST request, none for a GET one
+func zipNames(c *gin.Context) ([]string, error) {
+	if c.Request.Method != "POST" {
+		return nil, nil
+	}
+	var req ZipDownReq
+	if err := c.ShouldBind(&req); err != nil {
+		return nil, err
+	}
+	for _, name := range req.Names {
+		if name == "" || name == ".." || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid name: %s", name)
+		}
+	}
+	return req.Names, nil
+}
+
+// zipName is the file name of the archive of the objects selected in the folder
+func zipName(dir string, roots []zipRoot) string {
+	name := stdpath.Base(dir)
+	if len(roots) == 1 {
+		name = roots[0].name
+	}
+	if name == "" || name == "/" || name == "." {
+		name = "download"
+	}
+	return name + ".zip"
+}
+
+// ZipDown streams the folder, or the objects selected in it, as a zip archive
+func ZipDown(c *gin.Context) {
+	rawPath := c.Request.Context().Value(conf.PathKey).(string)
+	topMeta, _ := c.Request.Context().Value(conf.MetaKey).(*model.Meta)
+	user := downUser(c)
+	if !checkDownCap(c, user) {
+		return
+	}
+	names, err := zipNames(c)
+	if err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	// the sign only proves the password of the nearest meta of the requested path
+	password := func(meta *model.Meta) string {
+		if meta != nil && topMeta != nil && meta.Path == topMeta.Path {
+			return topMeta.Password
+		}
+		return ""
+	}
+	skip := func(path string, obj model.Obj) bool {
+		parentMeta, _ := op.GetNearestMeta(stdpath.Dir(path))
+		if !common.CanAccess(user, parentMeta, path, password(parentMeta)) {
+			return true
+		}
+		if obj.IsDir() {
+			meta, _ := op.GetNearestMeta(path)
+			return !common.CanAccess(user, meta, path, password(meta))
+		}
+		return false
+	}
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, user)
+	var roots []zipRoot
+	if len(names) == 0 {
+		roots = append(roots, zipRoot{path: rawPath, name: stdpath.Base(rawPath)})
+	}
+	for _, name := range names {
+		roots = append(roots, zipRoot{path: stdpath.Join(rawPath, name), name: name})
+	}
+	for i := range roots {
+		obj, err := fs.Get(ctx, roots[i].path, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			common.ErrorPage(c, err, 500)
+			return
+		}
+		if roots[i].path != "/" && skip(roots[i].path, obj) {
+			common.ErrorPage(c, errs.PermissionDenied, 403)
+			return
+		}
+		if roots[i].path == "/" {
+			roots[i].name = ""
+		}
+		roots[i].obj = obj
+	}
+	zipDown(c, ctx, zipName(rawPath, roots), roots, skip)
+	accountProxyDown(c, user)
+}
+
+// SharingZipDown streams the shared folder, or the objects selected in it, as a zip archive
+func SharingZipDown(c *gin.Context) {
+	sid := c.Request.Context().Value(conf.SharingIDKey).(string)
+	path := utils.FixAndCleanPath(c.Request.Context().Value(conf.PathKey).(string))
+	pwd := c.Query("pwd")
+	s, err := op.GetSharingById(sid)
+	if err == nil {
+		if !op.SharingValid(s) {
+			err = errs.InvalidSharing
+		} else if !s.Verify(pwd) {
+			err = errs.WrongShareCode
+		} else {
+			s, err = sharing.ResolveFiles(s)
+		}
+	}
+	if dealErrorPage(c, err) {
+		return
+	}
+	// the traffic of a sharing is accounted to its creator
+	if !checkDownCap(c, s.Creator) {
+		return
+	}
+	names, err := zipNames(c)
+	if err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	if len(names) == 0 {
+		if !s.SingleRoot() && path == "/" {
+			for _, f := range s.Files {
+				names = append(names, stdpath.Base(f))
+			}
+		} else {
+			path, names = stdpath.Dir(path), []string{stdpath.Base(path)}
+		}
+	}
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, s.Creator)
+	roots := make([]zipRoot, 0, len(names))
+	for _, name := range names {
+		unwrapPath, err := op.GetSharingUnwrapPath(s, stdpath.Join(path, name))
+		if err != nil {
+			common.ErrorPage(c, errors.New("failed get sharing unwrap path"), 500)
+			return
+		}
+		obj, err := fs.Get(ctx, unwrapPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			common.ErrorPage(c, err, 500)
+			return
+		}
+		if name == "/" {
+			name = stdpath.Base(unwrapPath)
+		}
+		roots = append(roots, zipRoot{path: unwrapPath, name: name, obj: obj})
+	}
+	_ = countAccess(c, s)
+	zipDown(c, ctx, zipName(path, roots), roots, func(path string, _ model.Obj) bool {
+		return op.SharingPathDenied(s, path)
+	})
+	accountProxyDown(c, s.Creator)
+}
+
+// zipErrorsName is the entry listing the files failed to be added to the archive
+const zipErrorsName = "zip_errors.txt"
+
+// zipDown writes the roots to the client as a zip archive while walking them, ZIP64 is used
+// as soon as the archive grows too large. The objects are read as the user of ctx,
+// skip tells the ones to leave out together with what is inside them.
+// As the response has been started, a file failed to be read is left out and listed in zipErrorsName.
+func zipDown(c *gin.Context, ctx context.Context, name string, roots []zipRoot, skip func(path string, obj model.Obj) bool) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(name))
+	c.Header("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
+	c.Status(200)
+	zw := zip.NewWriter(c.Writer)
+	var failed []string
+	for _, root := range roots {
+		err := fs.WalkFS(ctx, -1, root.path, root.obj, func(reqPath string, obj model.Obj) error {
+			if reqPath != root.path && skip(reqPath, obj) {
+				if obj.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			entryName := stdpath.Join(root.name, strings.TrimPrefix(reqPath, root.path))
+			if entryName == "" {
+				return nil
+			}
+			if err := writeZipEntry(ctx, zw, reqPath, entryName, obj); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				log.Warnf("failed zip %s, skip it: %+v", reqPath, err)
+				failed = append(failed, fmt.Sprintf("%s: %v", strings.TrimPrefix(entryName, "/"), err))
+			}
+			return nil
+		})
+		if err != nil {
+			// the client is gone
+			log.Errorf("failed zip %s: %+v", root.path, err)
+			return
+		}
+	}
+	if len(failed) > 0 {
+		fh := &zip.FileHeader{Name: zipErrorsName, Method: zip.Deflate, Flags: 0x800}
+		fh.SetModTime(time.Now())
+		w, err := zw.CreateHeader(fh)
+		if err == nil {
+			_, err = w.Write([]byte(strings.Join(failed, "\n") + "\n"))
+		}
+		if err != nil {
+			log.Errorf("failed write the errors of zip %s: %+v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Errorf("failed close zip %s: %+v", name, err)
+	}
+}
+
+func writeZipEntry(ctx context.Context, zw *zip.Writer, path, name string, obj model.Obj) error {
+	fh := &zip.FileHeader{
+		Name: strings.TrimPrefix(name, "/"),
+		// the files are mostly compressed already, deflating them only slows the download
+		Method: zip.Store,
+		// the names are in utf-8
+		Flags: 0x800,
+	}
+	fh.SetModTime(obj.ModTime())
+	if obj.IsDir() {
+		fh.Name += "/"
+		_, err := zw.CreateHeader(fh)
+		return err
+	}
+	link, file, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	defer link.Close()
+	rr, err := stream.GetRangeReaderFromLink(file.GetSize(), link)
+	if err != nil {
+		return err
+	}
+	rc, err := rr.RangeRead(ctx, http_range.Range{Length: -1})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	_, err = utils.CopyWithBuffer(w, rc)
+	return err
+}
@@ -0,0 +1,100 @@
+package handles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KirCute/zip"
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:handles_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestZipDown(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"dir/a.txt": "a", "dir/b.txt": "b", "dir/sub/c.txt": "c", "dir/hidden.txt": "h"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/zip",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Username: "zip", Role: model.ADMIN})
+	obj, err := fs.Get(ctx, "/zip/dir", &fs.GetArgs{NoLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/ad/zip/dir", nil)
+	zipDown(c, ctx, "dir.zip", []zipRoot{{path: "/zip/dir", name: "dir", obj: obj}}, func(path string, _ model.Obj) bool {
+		if path == "/zip/dir/b.txt" {
+			// the file is gone once it is walked, it can't be read any more
+			_ = os.Remove(filepath.Join(root, "dir", "b.txt"))
+		}
+		return path == "/zip/dir/hidden.txt"
+	})
+
+	r, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("the archive is broken: %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(data)
+	}
+	for name, content := range map[string]string{"dir/": "", "dir/a.txt": "a", "dir/sub/": "", "dir/sub/c.txt": "c"} {
+		if got[name] != content {
+			t.Errorf("entry %s = %q, want %q", name, got[name], content)
+		}
+		delete(got, name)
+	}
+	if failed, ok := got[zipErrorsName]; !ok || !strings.HasPrefix(failed, "dir/b.txt: ") {
+		t.Errorf("errors entry = %q, want the failed dir/b.txt", failed)
+	}
+	delete(got, zipErrorsName)
+	if len(got) > 0 {
+		t.Errorf("unexpected entries: %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -46,6 +47,19 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		if strings.HasPrefix(token, model.APITokenPrefix) {
+			user, apiToken, err := op.GetUserByAPIToken(token)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			common.GinWithValue(c, conf.UserKey, user)
+			common.GinWithValue(c, conf.APITokenKey, apiToken)
+			log.Debugf("use api token %s: %+v", apiToken.TokenPrefix, user)
+			c.Next()
+			return
+		}
 		userClaims, err := common.ParseToken(token)
 		if err != nil {
 			common.ErrorResp(c, err, 401)
@@ -129,6 +143,17 @@ func Authn(c *gin.Context) {
 	c.Next()
 }
 
+// TokenFromQuery takes the token from the token query when the Authorization header is missing,
+// browsers cannot set headers on websocket requests
+func TokenFromQuery(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		if token := c.Query("token"); token != "" {
+			c.Request.Header.Set("Authorization", token)
+		}
+	}
+	c.Next()
+}
+
 func AuthNotGuest(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if user.IsGuest() {
@@ -139,6 +164,16 @@ func AuthNotGuest(c *gin.Context) {
 	}
 }
 
+// AuthNotAPIToken keeps the api tokens away from managing the credentials of their user
+func AuthNotAPIToken(c *gin.Context) {
+	if _, ok := c.Request.Context().Value(conf.APITokenKey).(*model.APIToken); ok {
+		common.ErrorStrResp(c, "Not allowed with an api token", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.IsAdmin() {
@@ -36,10 +36,15 @@ func FsUp(c *gin.Context) {
 			return
 		}
 	}
-	if !(common.CanAccess(user, meta, path, password) && (user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)))) {
+	if !(common.CanAccess(user, meta, path, password) && (user.CanWrite() || common.CanWrite(user, meta, stdpath.Dir(path)))) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		c.Abort()
 		return
 	}
+	if err = op.CheckUploadQuota(user, c.Request.ContentLength); err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
+	}
 	c.Next()
 }
@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestInfo puts the client ip and the protocol to the request context, they are recorded in the audit log
+func RequestInfo(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinWithValue(c, conf.ClientIPKey, c.ClientIP())
+		common.GinWithValue(c, conf.ProtocolKey, protocol)
+		c.Next()
+	}
+}
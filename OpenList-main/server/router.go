@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -36,6 +37,7 @@ func Init(e *gin.Engine) {
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.RequestInfo(model.ProtocolHTTP))
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
@@ -48,6 +50,9 @@ func Init(e *gin.Engine) {
 	g.GET("/p/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", middlewares.PathParse, signCheck, handles.Down)
 	g.HEAD("/p/*path", middlewares.PathParse, signCheck, handles.Proxy)
+	g.GET("/z/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.ZipDown)
+	g.POST("/z/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.ZipDown)
+	g.GET("/pl/*path", middlewares.PathParse, handles.PlaylistDown)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
 	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveDown)
 	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
@@ -60,6 +65,8 @@ func Init(e *gin.Engine) {
 	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingDown)
 	g.HEAD("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingDown)
 	g.HEAD("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, handles.SharingDown)
+	g.POST("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingZipDown)
+	g.POST("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingZipDown)
 	g.GET("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
 	g.GET("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
 	g.HEAD("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
@@ -73,12 +80,19 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.POST("/me/update", middlewares.AuthNotAPIToken, handles.UpdateCurrent)
+	auth.GET("/me/continue_watching", middlewares.AuthNotGuest, handles.ListContinueWatching)
+	_playback(auth.Group("/me/playback", middlewares.AuthNotGuest))
+	api.GET("/me/playback/ws", middlewares.TokenFromQuery, middlewares.Auth(false), middlewares.AuthNotGuest, message.PlaybackInstance.WsHandle)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/sshkey/add", middlewares.AuthNotAPIToken, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.AuthNotAPIToken, handles.DeleteMyPublicKey)
+	tokens := auth.Group("/me/tokens", middlewares.AuthNotGuest, middlewares.AuthNotAPIToken)
+	tokens.GET("/list", handles.ListMyAPITokens)
+	tokens.POST("/create", handles.CreateMyAPIToken)
+	tokens.POST("/delete", handles.DeleteMyAPIToken)
+	auth.POST("/auth/2fa/generate", middlewares.AuthNotAPIToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.AuthNotAPIToken, handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -102,12 +116,16 @@ func Init(e *gin.Engine) {
 	public.Any("/archive_extensions", handles.ArchiveExtensions)
 
 	_fs(auth.Group("/fs"))
+	_library(auth.Group("/library", middlewares.SearchIndex))
 	fsAndShare(api.Group("/fs", middlewares.Auth(true)))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_sharing(auth.Group("/share", middlewares.AuthNotGuest))
-	_videoFavorites(auth.Group("/video_favorites", middlewares.AuthNotGuest))
-	_audioFavorites(auth.Group("/audio_favorites", middlewares.AuthNotGuest))
-	_imageFavorites(auth.Group("/image_favorites", middlewares.AuthNotGuest))
+	_favorites(auth.Group("/favorites", middlewares.AuthNotGuest))
+	// legacy per-media-type favorites, kept as aliases of /favorites
+	_favorites(auth.Group("/video_favorites", middlewares.AuthNotGuest, handles.FavoriteMediaType(model.FavoriteMediaVideo)))
+	_favorites(auth.Group("/audio_favorites", middlewares.AuthNotGuest, handles.FavoriteMediaType(model.FavoriteMediaAudio)))
+	_audioPlaylists(auth.Group("/audio_playlists", middlewares.AuthNotGuest))
+	_favorites(auth.Group("/image_favorites", middlewares.AuthNotGuest, handles.FavoriteMediaType(model.FavoriteMediaImage)))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
@@ -136,6 +154,13 @@ func admin(g *gin.RouterGroup) {
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
 
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
 	storage.GET("/get", handles.GetStorage)
@@ -169,6 +194,23 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_thunderx", handles.SetThunderX)
 	setting.POST("/set_thunder_browser", handles.SetThunderBrowser)
 
+	job := g.Group("/scheduled_job")
+	job.GET("/list", handles.ListScheduledJobs)
+	job.GET("/get", handles.GetScheduledJob)
+	job.POST("/create", handles.CreateScheduledJob)
+	job.POST("/update", handles.UpdateScheduledJob)
+	job.POST("/delete", handles.DeleteScheduledJob)
+	job.POST("/run", handles.RunScheduledJob)
+	job.GET("/runs", handles.ListScheduledJobRuns)
+
+	dedupe := g.Group("/dedupe")
+	dedupe.POST("/create", handles.CreateDedupe)
+	dedupe.POST("/resolve", handles.ResolveDuplicates)
+
+	auditLog := g.Group("/audit")
+	auditLog.GET("/list", handles.ListAuditEvents)
+	auditLog.POST("/prune", handles.PruneAuditEvents)
+
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
 
@@ -192,10 +234,20 @@ func fsAndShare(g *gin.RouterGroup) {
 	a.Any("/list", handles.FsArchiveListSplit)
 }
 
+func _library(g *gin.RouterGroup) {
+	g.Any("/artists", handles.LibraryArtists)
+	g.Any("/albums", handles.LibraryAlbums)
+	g.Any("/tracks", handles.LibraryTracks)
+}
+
 func _fs(g *gin.RouterGroup) {
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
 	g.Any("/other", handles.FsOther)
+	g.GET("/media_marks/export", handles.ExportMediaMarks)
+	g.POST("/media_marks/import", handles.ImportMediaMarks)
 	g.Any("/dirs", handles.FsDirs)
+	g.Any("/playlist", handles.FsPlaylist)
+	g.Any("/audio_meta", handles.FsAudioMeta)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
 	g.POST("/batch_rename", handles.FsBatchRename)
@@ -203,8 +255,17 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
+	g.POST("/sync", handles.FsSync)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	trash := g.Group("/trash")
+	trash.Any("/list", handles.FsTrashList)
+	trash.POST("/restore", handles.FsTrashRestore)
+	trash.POST("/purge", handles.FsTrashPurge)
+	versions := g.Group("/versions")
+	versions.Any("/list", handles.FsVersionList)
+	versions.POST("/restore", handles.FsVersionRestore)
+	versions.POST("/delete", handles.FsVersionDelete)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -214,10 +275,17 @@ func _fs(g *gin.RouterGroup) {
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
 	g.POST("/archive/decompress", handles.FsArchiveDecompress)
+	g.POST("/archive/compress", handles.FsArchiveCompress)
 	// Direct upload (client-side upload to storage)
 	g.POST("/get_direct_upload_info", middlewares.FsUp, handles.FsGetDirectUploadInfo)
 }
 
+func _playback(g *gin.RouterGroup) {
+	g.GET("/sessions", message.PlaybackInstance.SessionsHandle)
+	g.POST("/update", message.PlaybackInstance.UpdateHandle)
+	g.POST("/remove", message.PlaybackInstance.RemoveHandle)
+}
+
 func _task(g *gin.RouterGroup) {
 	handles.SetupTaskRoute(g)
 }
@@ -232,58 +300,42 @@ func _sharing(g *gin.RouterGroup) {
 	g.POST("/disable", handles.SetEnableSharing(true))
 }
 
-func _videoFavorites(g *gin.RouterGroup) {
+func _favorites(g *gin.RouterGroup) {
 	// Folder operations
 	folder := g.Group("/folder")
-	folder.GET("/list", handles.ListVideoFavoriteFolders)
-	folder.GET("/get", handles.GetVideoFavoriteFolder)
-	folder.POST("/create", handles.CreateVideoFavoriteFolder)
-	folder.POST("/update", handles.UpdateVideoFavoriteFolder)
-	folder.POST("/delete", handles.DeleteVideoFavoriteFolder)
-
-	// Video operations
-	g.GET("/list", handles.ListVideoFavorites)
-	g.POST("/create", handles.CreateVideoFavorite)
-	g.POST("/update", handles.UpdateVideoFavorite)
-	g.POST("/delete", handles.DeleteVideoFavorite)
-
-	// Get all video marks
-	g.GET("/all_marks", handles.ListAllVideoMarks)
+	folder.GET("/list", handles.ListFavoriteFolders)
+	folder.GET("/get", handles.GetFavoriteFolder)
+	folder.POST("/create", handles.CreateFavoriteFolder)
+	folder.POST("/update", handles.UpdateFavoriteFolder)
+	folder.POST("/delete", handles.DeleteFavoriteFolder)
+
+	// Favorite operations
+	g.GET("/list", handles.ListFavorites)
+	g.POST("/create", handles.CreateFavorite)
+	g.POST("/update", handles.UpdateFavorite)
+	g.POST("/delete", handles.DeleteFavorite)
+
+	// Get all media marks
+	g.GET("/all_marks", handles.ListAllFavoriteMarks)
+
+	// Re-resolve favorites whose files were moved
+	g.POST("/repair", middlewares.SearchIndex, handles.RepairFavorites)
 }
 
-func _audioFavorites(g *gin.RouterGroup) {
-	// Folder operations
-	folder := g.Group("/folder")
-	folder.GET("/list", handles.ListAudioFavoriteFolders)
-	folder.GET("/get", handles.GetAudioFavoriteFolder)
-	folder.POST("/create", handles.CreateAudioFavoriteFolder)
-	folder.POST("/update", handles.UpdateAudioFavoriteFolder)
-	folder.POST("/delete", handles.DeleteAudioFavoriteFolder)
-
-	// Audio operations
-	g.GET("/list", handles.ListAudioFavorites)
-	g.POST("/create", handles.CreateAudioFavorite)
-	g.POST("/update", handles.UpdateAudioFavorite)
-	g.POST("/delete", handles.DeleteAudioFavorite)
-
-	// Get all audio marks
-	g.GET("/all_marks", handles.ListAllAudioMarks)
-}
-
-func _imageFavorites(g *gin.RouterGroup) {
-	// Folder operations
-	folder := g.Group("/folder")
-	folder.GET("/list", handles.ListImageFavoriteFolders)
-	folder.GET("/get", handles.GetImageFavoriteFolder)
-	folder.POST("/create", handles.CreateImageFavoriteFolder)
-	folder.POST("/update", handles.UpdateImageFavoriteFolder)
-	folder.POST("/delete", handles.DeleteImageFavoriteFolder)
-
-	// Image operations
-	g.GET("/list", handles.ListImageFavorites)
-	g.POST("/create", handles.CreateImageFavorite)
-	g.POST("/update", handles.UpdateImageFavorite)
-	g.POST("/delete", handles.DeleteImageFavorite)
+func _audioPlaylists(g *gin.RouterGroup) {
+	// Playlist operations
+	g.GET("/list", handles.ListAudioPlaylists)
+	g.GET("/get", handles.GetAudioPlaylist)
+	g.POST("/create", handles.CreateAudioPlaylist)
+	g.POST("/update", handles.UpdateAudioPlaylist)
+	g.POST("/delete", handles.DeleteAudioPlaylist)
+	g.POST("/set_current", handles.SetAudioPlaylistCurrent)
+
+	// Item operations
+	item := g.Group("/item")
+	item.POST("/append", handles.AppendAudioPlaylistItems)
+	item.POST("/remove", handles.RemoveAudioPlaylistItems)
+	item.POST("/reorder", handles.ReorderAudioPlaylistItems)
 }
 
 func Cors(r *gin.Engine) {
@@ -5,6 +5,7 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -13,7 +14,9 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/itsHenry35/gofakes3/signature"
 )
 
 type Bucket struct {
@@ -29,8 +32,26 @@ func getAndParseBuckets() ([]Bucket, error) {
 	return res, err
 }
 
-func getBucketByName(name string) (Bucket, error) {
+// getUserBuckets returns the buckets the user of an api token can access,
+// requests signed with the key pair of the server can access all of them
+func getUserBuckets(ctx context.Context) ([]Bucket, error) {
 	buckets, err := getAndParseBuckets()
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if err != nil || !ok {
+		return buckets, err
+	}
+	var res []Bucket
+	for _, b := range buckets {
+		p := utils.FixAndCleanPath(b.Path)
+		if utils.IsSubPath(user.GetBasePath(), p) && user.CanAccessStorageOf(p) {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func getBucketByName(ctx context.Context, name string) (Bucket, error) {
+	buckets, err := getUserBuckets(ctx)
 	if err != nil {
 		return Bucket{}, err
 	}
@@ -150,6 +171,8 @@ func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 // 	}
 // }
 
+// authlistResolver returns the key pair of the s3 server, the key pairs of the api tokens
+// are checked by tokenAuth before the requests reach gofakes3
 func authlistResolver() map[string]string {
 	s3accesskeyid := setting.GetStr(conf.S3AccessKeyId)
 	s3secretaccesskey := setting.GetStr(conf.S3SecretAccessKey)
@@ -160,3 +183,83 @@ func authlistResolver() map[string]string {
 	authList[s3accesskeyid] = s3secretaccesskey
 	return authList
 }
+
+// accessKeyId returns the access key id a request is signed with, empty if it is not signed
+func accessKeyId(r *http.Request) string {
+	credential := r.URL.Query().Get("X-Amz-Credential")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if v2, ok := strings.CutPrefix(auth, "AWS "); ok {
+			accessKey, _, _ := strings.Cut(v2, ":")
+			return accessKey
+		}
+		for _, field := range strings.Split(auth, ",") {
+			if _, c, ok := strings.Cut(field, "Credential="); ok {
+				credential = c
+				break
+			}
+		}
+	}
+	accessKey, _, _ := strings.Cut(strings.TrimSpace(credential), "/")
+	return accessKey
+}
+
+// tokenAuth verifies the requests signed with the key pair of an api token, and runs them as the
+// user of the token. The other requests are left to gofakes3.
+func tokenAuth(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKey := accessKeyId(r)
+		if !strings.HasPrefix(accessKey, model.APITokenPrefix) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		user, secret, err := op.GetUserByS3AccessKeyId(accessKey)
+		if err != nil {
+			writeAuthError(w, signature.APIError{
+				Code:           "InvalidAccessKeyId",
+				Description:    err.Error(),
+				HTTPStatusCode: http.StatusForbidden,
+			})
+			return
+		}
+		// the signatures are verified against the stored keys, a deleted token is refused above
+		signature.StoreKeys(map[string]string{accessKey: secret})
+		result := signature.V4SignVerify(r)
+		if result == signature.ErrUnsupportAlgorithm {
+			result = signature.V2SignVerify(r)
+		}
+		if result != signature.ErrNone {
+			writeAuthError(w, signature.GetAPIError(result))
+			return
+		}
+		if !canRequest(user, r) {
+			writeAuthError(w, signature.APIError{
+				Code:           "AccessDenied",
+				Description:    "the api token has no permission to do this",
+				HTTPStatusCode: http.StatusForbidden,
+			})
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), conf.UserKey, user)))
+	})
+}
+
+// canRequest checks the permissions of the user needed by the method of the request
+func canRequest(user *model.User, r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodDelete:
+		return user.CanRemove()
+	case http.MethodPost:
+		if r.URL.Query().Has("delete") {
+			return user.CanRemove()
+		}
+	}
+	return user.CanWrite()
+}
+
+func writeAuthError(w http.ResponseWriter, err signature.APIError) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(err.HTTPStatusCode)
+	_, _ = w.Write(signature.EncodeAPIErrorToResponse(err))
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:s3_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestTokenAuth(t *testing.T) {
+	user := &model.User{Username: "s3", BasePath: "/", Permission: 1<<3 | 1<<7}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	token := model.APIToken{Name: "s3", PathPrefix: "/data", Permission: 1 << 7}
+	if _, err := op.CreateAPIToken(user, &token); err != nil {
+		t.Fatal(err)
+	}
+	accessKey, secret := token.S3AccessKeyId(), op.APITokenS3Secret(&token)
+
+	var got *model.User
+	var called bool
+	handler := tokenAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		got, _ = r.Context().Value(conf.UserKey).(*model.User)
+	}))
+	serve := func(method, accessKey, secret string) int {
+		called, got = false, nil
+		r := httptest.NewRequest(method, "/bucket/a.txt", nil)
+		signer := v4.NewSigner(credentials.NewStaticCredentials(accessKey, secret, ""))
+		if _, err := signer.Sign(r, nil, "s3", "us-east-1", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(http.MethodGet, accessKey, secret); code != http.StatusOK || !called || got == nil {
+		t.Fatalf("signed with the token: code %d, user %+v", code, got)
+	}
+	if got.Username != "s3" || got.GetBasePath() != "/data" || got.CanWrite() || !got.CanRemove() {
+		t.Errorf("the user is not restricted by the token: %+v", got)
+	}
+	if code := serve(http.MethodDelete, accessKey, secret); code != http.StatusOK || !called {
+		t.Errorf("deleting with the token: code %d", code)
+	}
+	// the token has no write permission
+	if code := serve(http.MethodPut, accessKey, secret); code != http.StatusForbidden || called {
+		t.Errorf("putting without permission: code %d", code)
+	}
+	if code := serve(http.MethodGet, accessKey, "wrong"); code != http.StatusForbidden || called {
+		t.Errorf("signed with a wrong secret: code %d", code)
+	}
+	// the other key pairs are left to gofakes3
+	if code := serve(http.MethodGet, "admin", "secret"); code != http.StatusOK || !called || got != nil {
+		t.Errorf("signed with another key pair: code %d, user %+v", code, got)
+	}
+
+	if err := op.DeleteAPITokenByIdAndUserId(token.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(http.MethodGet, accessKey, secret); code != http.StatusForbidden || called {
+		t.Errorf("signed with a deleted token: code %d", code)
+	}
+}
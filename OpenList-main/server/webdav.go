@@ -11,11 +11,14 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/webdav"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -29,7 +32,7 @@ func WebDav(dav *gin.RouterGroup) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
 	}
-	dav.Use(WebDAVAuth)
+	dav.Use(middlewares.RequestInfo(model.ProtocolWebDAV), WebDAVAuth)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
@@ -70,6 +73,15 @@ func WebDAVAuth(c *gin.Context) {
 		log.Debugf("[webdav auth] token: %s", bt)
 		if strings.HasPrefix(bt, "Bearer") {
 			bt = strings.TrimPrefix(bt, "Bearer ")
+			if strings.HasPrefix(bt, model.APITokenPrefix) {
+				user, apiToken, err := op.GetUserByAPIToken(bt)
+				if err == nil {
+					webdavAuthorized(c, user, apiToken)
+					return
+				}
+				model.LoginCache.Set(ip, count+1)
+				audit.RecordLogin(c.Request.Context(), nil, "", err)
+			}
 			token := setting.GetStr(conf.Token)
 			if token != "" && subtle.ConstantTimeCompare([]byte(bt), []byte(token)) == 1 {
 				admin, err := op.GetAdmin()
@@ -95,21 +107,44 @@ func WebDAVAuth(c *gin.Context) {
 		return
 	}
 	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	if err == nil {
+		err = user.ValidateRawPassword(password)
+	}
+	var apiToken *model.APIToken
+	if err != nil && strings.HasPrefix(password, model.APITokenPrefix) {
+		// an api token can be the password of its user
+		var tokenUser *model.User
+		if tokenUser, apiToken, err = op.GetUserByAPIToken(password); err == nil {
+			if tokenUser.Username == username {
+				user = tokenUser
+			} else {
+				err = errors.WithStack(errs.InvalidAPIToken)
+			}
+		}
+	}
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
 			return
 		}
 		model.LoginCache.Set(ip, count+1)
+		// basic auth comes with every request, so only the failures are worth recording
+		audit.RecordLogin(c.Request.Context(), nil, username, err)
 		c.Status(http.StatusUnauthorized)
 		c.Abort()
 		return
 	}
 	// at least auth is successful till here
 	model.LoginCache.Del(ip)
+	webdavAuthorized(c, user, apiToken)
+}
+
+// webdavAuthorized checks the permissions of the authenticated user for the request method
+func webdavAuthorized(c *gin.Context, user *model.User, apiToken *model.APIToken) {
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
+			guest, _ := op.GetGuest()
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
 			return
@@ -144,5 +179,8 @@ func WebDAVAuth(c *gin.Context) {
 		return
 	}
 	common.GinWithValue(c, conf.UserKey, user)
+	if apiToken != nil {
+		common.GinWithValue(c, conf.APITokenKey, apiToken)
+	}
 	c.Next()
 }